@@ -0,0 +1,151 @@
+package animation
+
+import (
+	"sort"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// Path is the part of a joint's transform a channel animates.
+type Path int
+
+const (
+	Translation Path = iota
+	Rotation
+	Scale
+)
+
+// Interpolation is how a channel fills in between keyframes.
+type Interpolation int
+
+const (
+	// Step holds each keyframe's value until the next.
+	Step Interpolation = iota
+	// Linear blends between keyframes, spherically for rotations.
+	Linear
+	// CubicSpline follows a Hermite curve through the keyframes, using tangents stored with them.
+	CubicSpline
+)
+
+// Channel animates one path of one joint.
+type Channel struct {
+	Joint         int
+	Path          Path
+	Interpolation Interpolation
+	// Times holds the keyframe times in seconds, in increasing order.
+	Times []float32
+	// Values holds 3 floats per keyframe for translation and scale, and 4 (x, y, z, w) for rotation. With
+	// CubicSpline each keyframe holds an in tangent, the value and an out tangent, in that order.
+	Values []float32
+}
+
+// Clip is a named set of channels, such as a walk cycle.
+type Clip struct {
+	Name     string
+	Duration float32
+	Channels []Channel
+}
+
+// Sample poses every joint the clip animates at time t in seconds. Joints it doesn't animate keep
+// whatever pose already holds.
+func (c *Clip) Sample(t float32, pose Pose) {
+	for i := range c.Channels {
+		ch := &c.Channels[i]
+		if ch.Joint < 0 || ch.Joint >= len(pose) || !ch.valid() {
+			continue
+		}
+		v := ch.Sample(t)
+		p := &pose[ch.Joint]
+		switch ch.Path {
+		case Translation:
+			p.Translation = mgl32.Vec3{v[0], v[1], v[2]}
+		case Rotation:
+			p.Rotation = mgl32.Quat{W: v[3], V: mgl32.Vec3{v[0], v[1], v[2]}}.Normalize()
+		case Scale:
+			p.Scale = mgl32.Vec3{v[0], v[1], v[2]}
+		}
+	}
+}
+
+func (ch *Channel) components() int {
+	if ch.Path == Rotation {
+		return 4
+	}
+	return 3
+}
+
+// valid reports whether the channel has keyframes and a value for every one of them.
+func (ch *Channel) valid() bool {
+	n := ch.components()
+	if ch.Interpolation == CubicSpline {
+		n *= 3
+	}
+	return len(ch.Times) > 0 && len(ch.Values) >= len(ch.Times)*n
+}
+
+// key returns the n floats of keyframe k's value, skipping the tangents of cubic splines.
+func (ch *Channel) key(k int) []float32 {
+	n := ch.components()
+	if ch.Interpolation == CubicSpline {
+		return ch.Values[(3*k+1)*n : (3*k+2)*n]
+	}
+	return ch.Values[k*n : (k+1)*n]
+}
+
+// Sample returns the channel's value at time t, held at the first and last keyframes outside their range.
+// Only the first 3 floats are used for translation and scale. A channel without keyframes, or without a
+// value for each, returns the identity for its path.
+func (ch *Channel) Sample(t float32) [4]float32 {
+	var out [4]float32
+	if !ch.valid() {
+		switch ch.Path {
+		case Rotation:
+			out[3] = 1
+		case Scale:
+			out = [4]float32{1, 1, 1}
+		}
+		return out
+	}
+	last := len(ch.Times) - 1
+	// next is the first keyframe after t.
+	next := sort.Search(len(ch.Times), func(i int) bool { return ch.Times[i] > t })
+	switch {
+	case next == 0:
+		copy(out[:], ch.key(0))
+		return out
+	case next > last:
+		copy(out[:], ch.key(last))
+		return out
+	}
+	k := next - 1
+	dt := ch.Times[next] - ch.Times[k]
+	u := (t - ch.Times[k]) / dt
+	n := ch.components()
+
+	switch ch.Interpolation {
+	case Step:
+		copy(out[:], ch.key(k))
+	case Linear:
+		a, b := ch.key(k), ch.key(next)
+		if ch.Path == Rotation {
+			q := slerp(mgl32.Quat{W: a[3], V: mgl32.Vec3{a[0], a[1], a[2]}}, mgl32.Quat{W: b[3], V: mgl32.Vec3{b[0], b[1], b[2]}}, u)
+			return [4]float32{q.V[0], q.V[1], q.V[2], q.W}
+		}
+		for i := 0; i < n; i++ {
+			out[i] = a[i] + (b[i]-a[i])*u
+		}
+	case CubicSpline:
+		p0, p1 := ch.key(k), ch.key(next)
+		m0 := ch.Values[(3*k+2)*n : (3*k+3)*n]
+		m1 := ch.Values[(3*next)*n : (3*next+1)*n]
+		u2, u3 := u*u, u*u*u
+		h00 := 2*u3 - 3*u2 + 1
+		h10 := u3 - 2*u2 + u
+		h01 := -2*u3 + 3*u2
+		h11 := u3 - u2
+		for i := 0; i < n; i++ {
+			out[i] = h00*p0[i] + h10*dt*m0[i] + h01*p1[i] + h11*dt*m1[i]
+		}
+	}
+	return out
+}
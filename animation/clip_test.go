@@ -0,0 +1,172 @@
+package animation
+
+import (
+	"math"
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+func near(a, b float32) bool {
+	return math.Abs(float64(a-b)) < 1e-4
+}
+
+func nearVec(a [4]float32, b [4]float32) bool {
+	for i := range a {
+		if !near(a[i], b[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// sameRotation reports whether two unit quaternions are the same rotation, either way round.
+func sameRotation(a, b mgl32.Quat) bool {
+	return near(float32(math.Abs(float64(a.Dot(b)))), 1)
+}
+
+func quat(v [4]float32) mgl32.Quat {
+	return mgl32.Quat{W: v[3], V: mgl32.Vec3{v[0], v[1], v[2]}}
+}
+
+func TestChannelSample(t *testing.T) {
+	translation := func(interpolation Interpolation) Channel {
+		return Channel{
+			Path:          Translation,
+			Interpolation: interpolation,
+			Times:         []float32{1, 2, 4},
+			Values:        []float32{0, 0, 0, 10, 20, 30, 20, 40, 60},
+		}
+	}
+	for _, tc := range []struct {
+		name    string
+		channel Channel
+		t       float32
+		want    [4]float32
+	}{
+		{"step holds the earlier key", translation(Step), 1.9, [4]float32{0, 0, 0}},
+		{"step on a key", translation(Step), 2, [4]float32{10, 20, 30}},
+		{"linear", translation(Linear), 1.25, [4]float32{2.5, 5, 7.5}},
+		{"linear over a longer gap", translation(Linear), 3, [4]float32{15, 30, 45}},
+		{"linear on the last key", translation(Linear), 4, [4]float32{20, 40, 60}},
+		{"clamped before the first key", translation(Linear), -1, [4]float32{0, 0, 0}},
+		{"clamped after the last key", translation(Linear), 10, [4]float32{20, 40, 60}},
+		{"no keyframes is identity", Channel{Path: Scale, Interpolation: Linear}, 1, [4]float32{1, 1, 1}},
+		{"missing values is identity", Channel{Path: Rotation, Times: []float32{0, 1}, Values: []float32{0, 0, 0, 1}}, 1, [4]float32{0, 0, 0, 1}},
+	} {
+		if got := tc.channel.Sample(tc.t); !nearVec(got, tc.want) {
+			t.Errorf("%s: Sample(%v) = %v, want %v", tc.name, tc.t, got, tc.want)
+		}
+	}
+}
+
+func TestCubicSplineTangents(t *testing.T) {
+	// One component from 0 to 1 over 2 seconds, leaving with a slope of 3 and arriving flat. Each keyframe
+	// is in tangent, value, out tangent.
+	ch := Channel{
+		Path:          Translation,
+		Interpolation: CubicSpline,
+		Times:         []float32{1, 3},
+		Values: []float32{
+			9, 9, 9, 0, 0, 0, 3, 0, 0,
+			0, 0, 0, 1, 0, 0, 9, 9, 9,
+		},
+	}
+	for _, tc := range []struct {
+		t, want float32
+	}{
+		{1, 0},
+		{3, 1},
+		// h00 0 + h10 dt m0 + h01 p1 + h11 dt m1, at u = 0.5: 0.125 * 2 * 3 + 0.5 * 1.
+		{2, 1.25},
+		// Clamped outside the keys, ignoring the tangents.
+		{0, 0},
+		{4, 1},
+	} {
+		if got := ch.Sample(tc.t); !near(got[0], tc.want) {
+			t.Errorf("Sample(%v) = %v, want %v", tc.t, got[0], tc.want)
+		}
+	}
+
+	// Tangents are per second, so the curve leaves the first key at slope 3 and arrives at the last flat.
+	const h = 1e-3
+	if slope := (ch.Sample(1 + h)[0] - ch.Sample(1)[0]) / h; math.Abs(float64(slope-3)) > 0.01 {
+		t.Errorf("slope leaving the first key is %v, want 3", slope)
+	}
+	if slope := (ch.Sample(3)[0] - ch.Sample(3 - h)[0]) / h; math.Abs(float64(slope)) > 0.01 {
+		t.Errorf("slope arriving at the last key is %v, want 0", slope)
+	}
+}
+
+func TestRotationSlerpsShortWay(t *testing.T) {
+	yaw := func(degrees float32) mgl32.Quat {
+		return mgl32.QuatRotate(mgl32.DegToRad(degrees), mgl32.Vec3{0, 1, 0})
+	}
+	// The second key is 90 degrees stored as its negation, which naively blends through 225 degrees.
+	a, b := yaw(0), yaw(90).Scale(-1)
+	ch := Channel{
+		Path:          Rotation,
+		Interpolation: Linear,
+		Times:         []float32{0, 1},
+		Values:        []float32{a.V[0], a.V[1], a.V[2], a.W, b.V[0], b.V[1], b.V[2], b.W},
+	}
+	for _, u := range []float32{0, 0.25, 0.5, 1} {
+		got := quat(ch.Sample(u))
+		if !near(got.Len(), 1) || !sameRotation(got, yaw(90*u)) {
+			t.Errorf("rotation at %v is %v, want %v degrees about y", u, got, 90*u)
+		}
+	}
+
+	// Nearly parallel keys take the normalized lerp path.
+	if got := slerp(yaw(0), yaw(0.01), 0.5); !near(got.Len(), 1) || !sameRotation(got, yaw(0.005)) {
+		t.Errorf("slerp of nearly parallel rotations is %v", got)
+	}
+}
+
+func TestClipSampleAndLerp(t *testing.T) {
+	s := &Skeleton{Joints: []Joint{
+		{Name: "root", Parent: -1, Rest: Identity()},
+		{Name: "arm", Parent: 0, Rest: Transform{Translation: mgl32.Vec3{0, 1, 0}, Rotation: mgl32.QuatIdent(), Scale: mgl32.Vec3{1, 1, 1}}},
+	}}
+	turn := mgl32.QuatRotate(math.Pi/2, mgl32.Vec3{0, 0, 1})
+	walk := &Clip{Name: "walk", Duration: 1, Channels: []Channel{
+		{Joint: 0, Path: Translation, Interpolation: Linear, Times: []float32{0, 1}, Values: []float32{0, 0, 0, 4, 0, 0}},
+	}}
+	wave := &Clip{Name: "wave", Duration: 1, Channels: []Channel{
+		{Joint: 1, Path: Rotation, Interpolation: Step, Times: []float32{0}, Values: []float32{turn.V[0], turn.V[1], turn.V[2], turn.W}},
+		{Joint: 0, Path: Scale, Interpolation: Step, Times: []float32{0}, Values: []float32{2, 2, 2}},
+		// Channels for joints the skeleton doesn't have, or without keyframes, are skipped.
+		{Joint: 5, Path: Scale, Interpolation: Step, Times: []float32{0}, Values: []float32{0, 0, 0}},
+		{Joint: 1, Path: Translation, Interpolation: Linear},
+	}}
+
+	walking, waving := s.RestPose(), s.RestPose()
+	walk.Sample(0.5, walking)
+	wave.Sample(0.5, waving)
+	// Joints a clip doesn't animate stay at rest.
+	if walking[1] != s.Joints[1].Rest || waving[1].Translation != s.Joints[1].Rest.Translation {
+		t.Errorf("unanimated parts of the pose moved: %v, %v", walking[1], waving[1])
+	}
+	if walking[0].Translation != (mgl32.Vec3{2, 0, 0}) {
+		t.Errorf("walk moved the root to %v, want x = 2", walking[0].Translation)
+	}
+
+	blend := Lerp(walking[0], waving[0], 0.25)
+	if !blend.Translation.ApproxEqual(mgl32.Vec3{1.5, 0, 0}) || !blend.Scale.ApproxEqual(mgl32.Vec3{1.25, 1.25, 1.25}) {
+		t.Errorf("blended root is %v, want x = 1.5 and scale 1.25", blend)
+	}
+	arm := Lerp(walking[1], waving[1], 0.5)
+	if want := mgl32.QuatRotate(math.Pi/4, mgl32.Vec3{0, 0, 1}); !sameRotation(arm.Rotation, want) {
+		t.Errorf("blended arm rotation is %v, want %v", arm.Rotation, want)
+	}
+
+	// The player blends the same way, holding the last frame when not looping.
+	p := NewPlayer(s)
+	p.Loop = false
+	p.Clips = [2]*Clip{walk, wave}
+	p.Weight = 0.25
+	p.Update(5)
+	if got := p.Pose()[0].Translation; !got.ApproxEqual(mgl32.Vec3{3, 0, 0}) {
+		t.Errorf("player root at %v, want x = 3", got)
+	}
+}
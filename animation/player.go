@@ -0,0 +1,97 @@
+package animation
+
+import (
+	"math"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// Player plays up to two clips on a skeleton at once and blends between them, such as walking into
+// running.
+type Player struct {
+	Skeleton *Skeleton
+	// Clips are the two clips being played. Either may be nil.
+	Clips [2]*Clip
+	// Times is the playback position of each clip in seconds.
+	Times [2]float32
+	// Weight blends from only Clips[0] at 0 to only Clips[1] at 1.
+	Weight float32
+	// Speed scales how fast time advances.
+	Speed float32
+	// Loop wraps clips back to the start, otherwise they hold their last frame.
+	Loop bool
+
+	poses    [2]Pose
+	pose     Pose
+	matrices []mgl32.Mat4
+}
+
+func NewPlayer(s *Skeleton) *Player {
+	return &Player{
+		Skeleton: s,
+		Speed:    1,
+		Loop:     true,
+		poses:    [2]Pose{s.RestPose(), s.RestPose()},
+		pose:     s.RestPose(),
+	}
+}
+
+// Play starts c from the beginning on its own, dropping any blend.
+func (p *Player) Play(c *Clip) {
+	p.Clips = [2]*Clip{c, nil}
+	p.Times = [2]float32{}
+	p.Weight = 0
+}
+
+// Update advances both clips by elapsed seconds and samples the blended pose.
+func (p *Player) Update(elapsed float64) {
+	for i, c := range p.Clips {
+		if c == nil {
+			continue
+		}
+		t := p.Times[i] + float32(elapsed)*p.Speed
+		switch {
+		case p.Loop && c.Duration > 0:
+			t = float32(math.Mod(float64(t), float64(c.Duration)))
+			if t < 0 {
+				t += c.Duration
+			}
+		case t > c.Duration:
+			t = c.Duration
+		case t < 0:
+			t = 0
+		}
+		p.Times[i] = t
+	}
+
+	for i, c := range p.Clips {
+		p.Skeleton.Reset(p.poses[i])
+		if c != nil {
+			c.Sample(p.Times[i], p.poses[i])
+		}
+	}
+	w := mgl32.Clamp(p.Weight, 0, 1)
+	switch {
+	case p.Clips[1] == nil:
+		w = 0
+	case p.Clips[0] == nil:
+		w = 1
+	}
+	for j := range p.pose {
+		p.pose[j] = Lerp(p.poses[0][j], p.poses[1][j], w)
+	}
+	p.matrices = p.Skeleton.JointMatrices(p.pose, p.matrices)
+}
+
+// Pose returns the blended pose from the last Update.
+func (p *Player) Pose() Pose {
+	return p.pose
+}
+
+// Matrices returns the skinning matrices from the last Update, see Skeleton.JointMatrices.
+func (p *Player) Matrices() []mgl32.Mat4 {
+	if p.matrices == nil {
+		p.matrices = p.Skeleton.JointMatrices(p.pose, nil)
+	}
+	return p.matrices
+}
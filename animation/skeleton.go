@@ -0,0 +1,87 @@
+package animation
+
+import (
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// Joint is one bone of a Skeleton.
+type Joint struct {
+	Name string
+	// Parent is the index of the parent joint, or -1 for roots.
+	Parent int
+	// Rest is the joint's transform when no clip animates it.
+	Rest Transform
+	// InverseBind takes mesh space into the joint's space at bind time.
+	InverseBind mgl32.Mat4
+}
+
+// Skeleton is a hierarchy of joints. Joint indices are the ones skinned vertices refer to, so joints may
+// come in any order.
+type Skeleton struct {
+	Joints []Joint
+	// Root is the transform above the root joints, from whatever parents the skeleton had in its file.
+	Root mgl32.Mat4
+
+	// order lists joint indices with every parent before its children, built on first use.
+	order []int
+}
+
+// Pose is a transform for every joint of a skeleton, indexed like Skeleton.Joints.
+type Pose []Transform
+
+// RestPose returns a new pose with every joint at rest.
+func (s *Skeleton) RestPose() Pose {
+	p := make(Pose, len(s.Joints))
+	s.Reset(p)
+	return p
+}
+
+// Reset puts every joint of p back at rest.
+func (s *Skeleton) Reset(p Pose) {
+	for i, j := range s.Joints {
+		p[i] = j.Rest
+	}
+}
+
+func (s *Skeleton) parentsFirst() []int {
+	if s.order != nil {
+		return s.order
+	}
+	added := make([]bool, len(s.Joints))
+	var add func(i int)
+	add = func(i int) {
+		if added[i] {
+			return
+		}
+		added[i] = true
+		if p := s.Joints[i].Parent; p >= 0 {
+			add(p)
+		}
+		s.order = append(s.order, i)
+	}
+	for i := range s.Joints {
+		add(i)
+	}
+	return s.order
+}
+
+// JointMatrices writes the skinning matrix of every joint for pose into out, growing it if needed, and
+// returns it. Each matrix takes a bind pose mesh space vertex to its posed position.
+func (s *Skeleton) JointMatrices(pose Pose, out []mgl32.Mat4) []mgl32.Mat4 {
+	if cap(out) < len(s.Joints) {
+		out = make([]mgl32.Mat4, len(s.Joints))
+	}
+	out = out[:len(s.Joints)]
+	// Build the global transforms in out first, then apply the inverse bind matrices.
+	for _, i := range s.parentsFirst() {
+		parent := s.Root
+		if p := s.Joints[i].Parent; p >= 0 {
+			parent = out[p]
+		}
+		out[i] = parent.Mul4(pose[i].Matrix())
+	}
+	for i := range out {
+		out[i] = out[i].Mul4(s.Joints[i].InverseBind)
+	}
+	return out
+}
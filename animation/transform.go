@@ -0,0 +1,55 @@
+package animation
+
+import (
+	"math"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// Transform is a joint's translation, rotation and scale relative to its parent.
+type Transform struct {
+	Translation mgl32.Vec3
+	Rotation    mgl32.Quat
+	Scale       mgl32.Vec3
+}
+
+// Identity returns the transform that changes nothing.
+func Identity() Transform {
+	return Transform{Rotation: mgl32.QuatIdent(), Scale: mgl32.Vec3{1, 1, 1}}
+}
+
+func (t Transform) Matrix() mgl32.Mat4 {
+	return mgl32.Translate3D(t.Translation.Elem()).Mul4(t.Rotation.Mat4()).Mul4(mgl32.Scale3D(t.Scale.Elem()))
+}
+
+// Lerp blends from a at weight 0 to b at weight 1, interpolating rotations along the shortest arc.
+func Lerp(a, b Transform, weight float32) Transform {
+	return Transform{
+		Translation: lerp3(a.Translation, b.Translation, weight),
+		Rotation:    slerp(a.Rotation, b.Rotation, weight),
+		Scale:       lerp3(a.Scale, b.Scale, weight),
+	}
+}
+
+func lerp3(a, b mgl32.Vec3, t float32) mgl32.Vec3 {
+	return a.Add(b.Sub(a).Mul(t))
+}
+
+// slerp spherically interpolates between two unit quaternions, taking the shorter way round.
+func slerp(a, b mgl32.Quat, t float32) mgl32.Quat {
+	dot := a.Dot(b)
+	if dot < 0 {
+		// q and -q are the same rotation, flip b so we don't go the long way.
+		b = b.Scale(-1)
+		dot = -dot
+	}
+	if dot > 0.9995 {
+		// Nearly parallel, where slerp is unstable and a normalized lerp is indistinguishable.
+		return a.Add(b.Sub(a).Scale(t)).Normalize()
+	}
+	theta := math.Acos(float64(dot))
+	sin := math.Sin(theta)
+	wa := float32(math.Sin((1-float64(t))*theta) / sin)
+	wb := float32(math.Sin(float64(t)*theta) / sin)
+	return a.Scale(wa).Add(b.Scale(wb))
+}
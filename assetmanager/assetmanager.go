@@ -0,0 +1,174 @@
+package assetmanager
+
+import (
+	"fmt"
+	"log"
+	"path/filepath"
+	"sync"
+
+	"github.com/brandonnelson3/GoPlay/texture"
+)
+
+const defaultRoot = "assets"
+
+// Global asset manager
+var M manager
+
+// Texture is a reference counted handle to a texture owned by the asset manager. Handles returned by
+// TextureAsync may not be uploaded yet; check Ready before binding.
+type Texture struct {
+	texture.Texture
+
+	path  string
+	refs  int
+	ready chan struct{}
+	err   error
+}
+
+// Ready reports whether the texture has finished loading, successfully or not.
+func (t *Texture) Ready() bool {
+	select {
+	case <-t.ready:
+		return true
+	default:
+		return false
+	}
+}
+
+// Err returns the load error, if any. Only meaningful once Ready returns true.
+func (t *Texture) Err() error {
+	return t.err
+}
+
+type manager struct {
+	// Root is the directory asset paths are resolved against.
+	Root string
+
+	mu       sync.Mutex
+	textures map[string]*Texture
+	shaders  map[string]*sharedShader
+
+	// Decoded assets waiting for their GL upload on the render thread.
+	uploads chan func()
+
+	watchMu sync.Mutex
+	watched map[string]*watchedFile
+}
+
+func init() {
+	M = manager{
+		Root:     defaultRoot,
+		textures: make(map[string]*Texture),
+		shaders:  make(map[string]*sharedShader),
+		uploads:  make(chan func(), 64),
+		watched:  make(map[string]*watchedFile),
+	}
+}
+
+// Path resolves an asset path against Root.
+func (m *manager) Path(path string) string {
+	return filepath.Join(m.Root, path)
+}
+
+// Texture returns the texture at path, loading and uploading it synchronously if it isn't cached.
+// Must be called on the render thread.
+func (m *manager) Texture(path string) (*Texture, error) {
+	t := m.TextureAsync(path)
+	for !t.Ready() {
+		// Run uploads until ours comes through. Another asset's upload may be ahead of it in the queue.
+		(<-m.uploads)()
+	}
+	if t.err != nil {
+		m.Release(t)
+		return nil, t.err
+	}
+	return t, nil
+}
+
+// TextureAsync returns a handle to the texture at path. If it isn't cached the file is decoded on a
+// background goroutine and uploaded by a later call to Update.
+func (m *manager) TextureAsync(path string) *Texture {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if t, ok := m.textures[path]; ok {
+		t.refs++
+		return t
+	}
+	t := &Texture{path: path, refs: 1, ready: make(chan struct{})}
+	m.textures[path] = t
+	go func() {
+		img, err := texture.Load(m.Path(path))
+		m.uploads <- func() {
+			if err == nil {
+				t.Texture, err = img.Upload()
+			}
+			if err != nil {
+				t.err = fmt.Errorf("failed to load texture %v: %v", path, err)
+			}
+			close(t.ready)
+		}
+	}()
+	m.watchTexture(t)
+	return t
+}
+
+// watchTexture reloads t whenever its file changes, for as long as it stays cached.
+func (m *manager) watchTexture(t *Texture) {
+	m.watch(m.Path(t.path), t, func() {
+		img, err := texture.Load(m.Path(t.path))
+		m.uploads <- func() {
+			m.mu.Lock()
+			defer m.mu.Unlock()
+			if m.textures[t.path] != t {
+				// Released while the reload was queued, the GL texture is already gone.
+				return
+			}
+			if !t.Ready() || t.err != nil {
+				// The initial load hasn't landed or failed, there is nothing to replace.
+				return
+			}
+			if err == nil {
+				err = t.Texture.Reload(img)
+			}
+			if err != nil {
+				log.Printf("Failed to reload texture %v: %v", t.path, err)
+			}
+		}
+	})
+}
+
+// Release drops a reference to t, deleting the texture once nothing uses it. Must be called on the
+// render thread.
+func (m *manager) Release(t *Texture) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	t.refs--
+	if t.refs > 0 {
+		return
+	}
+	delete(m.textures, t.path)
+	m.unwatch(m.Path(t.path), t)
+	if !t.Ready() {
+		// Still in flight, delete it once the upload lands.
+		go func() {
+			<-t.ready
+			m.uploads <- t.Texture.Delete
+		}()
+		return
+	}
+	if t.err == nil {
+		t.Texture.Delete()
+	}
+}
+
+// Update finishes any pending GL uploads. Must be called once per frame on the render thread.
+func (m *manager) Update() {
+	for {
+		select {
+		case f := <-m.uploads:
+			f()
+		default:
+			return
+		}
+	}
+}
@@ -0,0 +1,269 @@
+package assetmanager
+
+import (
+	"log"
+
+	"github.com/brandonnelson3/GoPlay/shaders"
+)
+
+// Shader is implemented by every program wrapper in the shaders package.
+type Shader interface {
+	Files() []string
+	Reload() error
+	Delete()
+}
+
+type sharedShader struct {
+	shader Shader
+	refs   int
+}
+
+// shader returns the shared shader registered under name, building it with create on first use.
+func (m *manager) shader(name string, create func() (Shader, error)) (Shader, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if s, ok := m.shaders[name]; ok {
+		s.refs++
+		return s.shader, nil
+	}
+	s, err := create()
+	if err != nil {
+		return nil, err
+	}
+	m.shaders[name] = &sharedShader{shader: s, refs: 1}
+	m.watchShader(s)
+	return s, nil
+}
+
+// ReleaseShader drops a reference to a shared shader, deleting it once nothing uses it.
+func (m *manager) ReleaseShader(s Shader) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for name, shared := range m.shaders {
+		if shared.shader != s {
+			continue
+		}
+		shared.refs--
+		if shared.refs == 0 {
+			for _, f := range s.Files() {
+				m.unwatch(f, s)
+			}
+			s.Delete()
+			delete(m.shaders, name)
+		}
+		return
+	}
+}
+
+func (m *manager) watchShader(s Shader) {
+	var reload func()
+	reload = func() {
+		m.uploads <- func() {
+			m.mu.Lock()
+			defer m.mu.Unlock()
+			if !m.shaderLive(s) {
+				// Released while the reload was queued.
+				return
+			}
+			old := s.Files()
+			if err := s.Reload(); err != nil {
+				log.Printf("Keeping previous program, shader reload failed: %v", err)
+				return
+			}
+			// The edit may have changed which files the program #includes.
+			m.rewatch(s, old, s.Files(), reload)
+		}
+	}
+	for _, f := range s.Files() {
+		m.watch(f, s, reload)
+	}
+}
+
+// shaderLive reports whether s is still shared. m.mu must be held.
+func (m *manager) shaderLive(s Shader) bool {
+	for _, shared := range m.shaders {
+		if shared.shader == s {
+			return true
+		}
+	}
+	return false
+}
+
+// DefaultShader returns the shared default shader program, compiling it on first use.
+func (m *manager) DefaultShader() (*shaders.DefaultShader, error) {
+	s, err := m.shader("default", func() (Shader, error) {
+		s, err := shaders.NewDefaultShader(m.Path("shaders/default.vert"), m.Path("shaders/default.frag"))
+		if err != nil {
+			return nil, err
+		}
+		return s, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.(*shaders.DefaultShader), nil
+}
+
+// LitShader returns the shared lit shader program, compiling it on first use.
+func (m *manager) LitShader() (*shaders.LitShader, error) {
+	s, err := m.shader("lit", func() (Shader, error) {
+		s, err := shaders.NewLitShader(m.Path("shaders/lit.vert"), m.Path("shaders/lit.frag"))
+		if err != nil {
+			return nil, err
+		}
+		return s, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.(*shaders.LitShader), nil
+}
+
+// SkinnedShader returns the shared skinned mesh shader program, compiling it on first use.
+func (m *manager) SkinnedShader() (*shaders.SkinnedShader, error) {
+	s, err := m.shader("skinned", func() (Shader, error) {
+		s, err := shaders.NewSkinnedShader(m.Path("shaders/skinned.vert"), m.Path("shaders/lit.frag"))
+		if err != nil {
+			return nil, err
+		}
+		return s, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.(*shaders.SkinnedShader), nil
+}
+
+// InstancedShader returns the shared instanced lit shader program, compiling it on first use.
+func (m *manager) InstancedShader() (*shaders.InstancedShader, error) {
+	s, err := m.shader("instanced", func() (Shader, error) {
+		s, err := shaders.NewInstancedShader(m.Path("shaders/instanced.vert"), m.Path("shaders/instanced.frag"))
+		if err != nil {
+			return nil, err
+		}
+		return s, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.(*shaders.InstancedShader), nil
+}
+
+// TerrainShader returns the shared voxel terrain shader program, compiling it on first use.
+func (m *manager) TerrainShader() (*shaders.TerrainShader, error) {
+	s, err := m.shader("terrain", func() (Shader, error) {
+		s, err := shaders.NewTerrainShader(m.Path("shaders/terrain.vert"), m.Path("shaders/terrain.frag"))
+		if err != nil {
+			return nil, err
+		}
+		return s, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.(*shaders.TerrainShader), nil
+}
+
+// SkyShader returns the shared sky program, compiling it on first use.
+func (m *manager) SkyShader() (*shaders.SkyShader, error) {
+	s, err := m.shader("sky", func() (Shader, error) {
+		s, err := shaders.NewSkyShader(m.Path("shaders/sky.vert"), m.Path("shaders/sky.frag"))
+		if err != nil {
+			return nil, err
+		}
+		return s, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.(*shaders.SkyShader), nil
+}
+
+// DebugShader returns the shared debug line program, compiling it on first use.
+func (m *manager) DebugShader() (*shaders.DebugShader, error) {
+	s, err := m.shader("debug", func() (Shader, error) {
+		s, err := shaders.NewDebugShader(m.Path("shaders/debug.vert"), m.Path("shaders/debug.frag"))
+		if err != nil {
+			return nil, err
+		}
+		return s, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.(*shaders.DebugShader), nil
+}
+
+// TextShader returns the shared screen space text program, compiling it on first use.
+func (m *manager) TextShader() (*shaders.TextShader, error) {
+	s, err := m.shader("text", func() (Shader, error) {
+		s, err := shaders.NewTextShader(m.Path("shaders/text.vert"), m.Path("shaders/text.frag"))
+		if err != nil {
+			return nil, err
+		}
+		return s, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.(*shaders.TextShader), nil
+}
+
+// PostShader returns the shared post-processing pass assets/shaders/post/<name>.frag, compiling it on
+// first use.
+func (m *manager) PostShader(name string) (*shaders.PostShader, error) {
+	s, err := m.shader("post/"+name, func() (Shader, error) {
+		s, err := shaders.NewPostShader(m.Path("shaders/post/post.vert"), m.Path("shaders/post/"+name+".frag"))
+		if err != nil {
+			return nil, err
+		}
+		return s, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.(*shaders.PostShader), nil
+}
+
+// DepthShader returns the shared depth only program for float vertices, compiling it on first use.
+func (m *manager) DepthShader() (*shaders.DepthShader, error) {
+	return m.depthShader("depth", "shaders/depth.vert", "shaders/depth.frag")
+}
+
+// TerrainDepthShader returns the shared depth only program for packed terrain vertices, compiling it on
+// first use.
+func (m *manager) TerrainDepthShader() (*shaders.DepthShader, error) {
+	return m.depthShader("terrain_depth", "shaders/terrain_depth.vert", "shaders/depth.frag")
+}
+
+// TerrainCutoutDepthShader returns the shared depth only program for packed terrain vertices of cutout
+// blocks, which discards clear texels, compiling it on first use.
+func (m *manager) TerrainCutoutDepthShader() (*shaders.DepthShader, error) {
+	return m.depthShader("terrain_cutout_depth", "shaders/terrain_cutout_depth.vert", "shaders/terrain_cutout_depth.frag")
+}
+
+// SkinnedDepthShader returns the shared depth only program for skinned vertices, compiling it on first
+// use.
+func (m *manager) SkinnedDepthShader() (*shaders.DepthShader, error) {
+	return m.depthShader("skinned_depth", "shaders/skinned_depth.vert", "shaders/depth.frag")
+}
+
+// InstancedDepthShader returns the shared depth only program for instanced float vertices, compiling it on
+// first use.
+func (m *manager) InstancedDepthShader() (*shaders.DepthShader, error) {
+	return m.depthShader("instanced_depth", "shaders/instanced_depth.vert", "shaders/depth.frag")
+}
+
+func (m *manager) depthShader(name, vertFile, fragFile string) (*shaders.DepthShader, error) {
+	s, err := m.shader(name, func() (Shader, error) {
+		s, err := shaders.NewDepthShader(m.Path(vertFile), m.Path(fragFile))
+		if err != nil {
+			return nil, err
+		}
+		return s, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.(*shaders.DepthShader), nil
+}
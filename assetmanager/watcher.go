@@ -0,0 +1,109 @@
+package assetmanager
+
+import (
+	"log"
+	"os"
+	"time"
+)
+
+// watchedFile is a file and the callbacks of everything using it, keyed by owner. Shaders share include
+// files, so the file is only dropped once its last owner unwatches it.
+type watchedFile struct {
+	modTime   time.Time
+	callbacks map[interface{}]func()
+}
+
+// watch registers onChange to be called from the watcher goroutine whenever file is modified, replacing
+// any callback owner already had for it. GL work must be pushed onto the uploads queue rather than done
+// directly.
+func (m *manager) watch(file string, owner interface{}, onChange func()) {
+	m.watchMu.Lock()
+	defer m.watchMu.Unlock()
+	w, ok := m.watched[file]
+	if !ok {
+		w = &watchedFile{callbacks: make(map[interface{}]func())}
+		if info, err := os.Stat(file); err == nil {
+			w.modTime = info.ModTime()
+		}
+		m.watched[file] = w
+	}
+	w.callbacks[owner] = onChange
+}
+
+// unwatch drops owner's callback for file, and stops watching the file once nothing else uses it.
+func (m *manager) unwatch(file string, owner interface{}) {
+	m.watchMu.Lock()
+	defer m.watchMu.Unlock()
+	w, ok := m.watched[file]
+	if !ok {
+		return
+	}
+	delete(w.callbacks, owner)
+	if len(w.callbacks) == 0 {
+		delete(m.watched, file)
+	}
+}
+
+// rewatch moves owner's callback from the files in old to the files in current.
+func (m *manager) rewatch(owner interface{}, old, current []string, onChange func()) {
+	for _, f := range old {
+		if !contains(current, f) {
+			m.unwatch(f, owner)
+		}
+	}
+	for _, f := range current {
+		if !contains(old, f) {
+			m.watch(f, owner, onChange)
+		}
+	}
+}
+
+func contains(files []string, file string) bool {
+	for _, f := range files {
+		if f == file {
+			return true
+		}
+	}
+	return false
+}
+
+// Watch starts polling every loaded texture and shader file for changes, reloading them when their
+// modification time moves.
+func (m *manager) Watch(interval time.Duration) {
+	go func() {
+		for {
+			<-time.After(interval)
+			m.poll()
+		}
+	}()
+}
+
+func (m *manager) poll() {
+	// Owners are called once however many of their files changed.
+	var changed []func()
+	called := map[interface{}]bool{}
+	m.watchMu.Lock()
+	for file, w := range m.watched {
+		info, err := os.Stat(file)
+		if err != nil {
+			// Editors often replace files by deleting and recreating them, try again next time.
+			continue
+		}
+		if info.ModTime().Equal(w.modTime) {
+			continue
+		}
+		log.Printf("Reloading %v", file)
+		w.modTime = info.ModTime()
+		for owner, f := range w.callbacks {
+			if !called[owner] {
+				called[owner] = true
+				changed = append(changed, f)
+			}
+		}
+	}
+	m.watchMu.Unlock()
+
+	for _, f := range changed {
+		f()
+	}
+}
@@ -0,0 +1,205 @@
+package assetmanager
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// fakeShader counts reloads. Reload switches its files to next, as if an edit changed its #includes.
+type fakeShader struct {
+	files, next []string
+	reloads     int
+	deleted     bool
+}
+
+func (s *fakeShader) Files() []string { return s.files }
+
+func (s *fakeShader) Reload() error {
+	s.reloads++
+	if s.next != nil {
+		s.files, s.next = s.next, nil
+	}
+	return nil
+}
+
+func (s *fakeShader) Delete() { s.deleted = true }
+
+type watchTest struct {
+	t     *testing.T
+	m     *manager
+	dir   string
+	clock time.Time
+}
+
+func newWatchTest(t *testing.T, files ...string) *watchTest {
+	dir, err := ioutil.TempDir("", "watch")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, f), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return &watchTest{
+		t: t,
+		m: &manager{
+			Root:     dir,
+			textures: make(map[string]*Texture),
+			shaders:  make(map[string]*sharedShader),
+			uploads:  make(chan func(), 64),
+			watched:  make(map[string]*watchedFile),
+		},
+		dir:   dir,
+		clock: time.Now(),
+	}
+}
+
+func (w *watchTest) paths(files ...string) []string {
+	var paths []string
+	for _, f := range files {
+		paths = append(paths, w.m.Path(f))
+	}
+	return paths
+}
+
+func (w *watchTest) shader(name string, files ...string) *fakeShader {
+	s := &fakeShader{files: w.paths(files...)}
+	if _, err := w.m.shader(name, func() (Shader, error) { return s, nil }); err != nil {
+		w.t.Fatal(err)
+	}
+	return s
+}
+
+// touch moves the modification time of files forward, polls and runs the queued reloads.
+func (w *watchTest) touch(files ...string) {
+	w.clock = w.clock.Add(time.Second)
+	for _, f := range w.paths(files...) {
+		if err := os.Chtimes(f, w.clock, w.clock); err != nil {
+			w.t.Fatal(err)
+		}
+	}
+	w.m.poll()
+	for len(w.m.uploads) > 0 {
+		(<-w.m.uploads)()
+	}
+}
+
+func (w *watchTest) checkReloads(s *fakeShader, want int, when string) {
+	if s.reloads != want {
+		w.t.Errorf("%s: shader reloaded %d times, want %d", when, s.reloads, want)
+	}
+}
+
+func TestWatchSharedIncludes(t *testing.T) {
+	w := newWatchTest(t, "a.frag", "b.frag", "lighting.glsl", "fog.glsl")
+	defer os.RemoveAll(w.dir)
+	a := w.shader("a", "a.frag", "lighting.glsl", "fog.glsl")
+	b := w.shader("b", "b.frag", "lighting.glsl")
+
+	w.touch("lighting.glsl")
+	w.checkReloads(a, 1, "shared include changed")
+	w.checkReloads(b, 1, "shared include changed")
+
+	// Several changed files still reload each program once.
+	w.touch("a.frag", "lighting.glsl", "fog.glsl")
+	w.checkReloads(a, 2, "three of a's files changed")
+	w.checkReloads(b, 2, "three of a's files changed")
+
+	// Releasing a leaves b watching the include they shared.
+	w.m.ReleaseShader(a)
+	if !a.deleted {
+		t.Errorf("released shader wasn't deleted")
+	}
+	w.touch("lighting.glsl", "fog.glsl", "a.frag")
+	w.checkReloads(a, 2, "after release")
+	w.checkReloads(b, 3, "include changed after a was released")
+	if _, ok := w.m.watched[w.m.Path("fog.glsl")]; ok {
+		t.Errorf("fog.glsl still watched after the only shader using it was released")
+	}
+
+	w.m.ReleaseShader(b)
+	if len(w.m.watched) != 0 {
+		t.Errorf("%d files still watched after every shader was released", len(w.m.watched))
+	}
+}
+
+func TestWatchFollowsIncludesAcrossReload(t *testing.T) {
+	w := newWatchTest(t, "a.frag", "lighting.glsl", "fog.glsl")
+	defer os.RemoveAll(w.dir)
+	a := w.shader("a", "a.frag", "lighting.glsl")
+	// An edit swaps the lighting include for fog.
+	a.next = w.paths("a.frag", "fog.glsl")
+
+	w.touch("a.frag")
+	w.checkReloads(a, 1, "source changed")
+	w.touch("lighting.glsl")
+	w.checkReloads(a, 1, "dropped include changed")
+	w.touch("fog.glsl")
+	w.checkReloads(a, 2, "new include changed")
+
+	// A reload queued before the shader was released does nothing.
+	w.clock = w.clock.Add(time.Second)
+	if err := os.Chtimes(w.m.Path("fog.glsl"), w.clock, w.clock); err != nil {
+		t.Fatal(err)
+	}
+	w.m.poll()
+	w.m.ReleaseShader(a)
+	for len(w.m.uploads) > 0 {
+		(<-w.m.uploads)()
+	}
+	w.checkReloads(a, 2, "reload queued before release")
+	if len(w.m.watched) != 0 {
+		t.Errorf("%d files still watched after the shader was released", len(w.m.watched))
+	}
+}
+
+func TestTextureReloadQueuedBeforeRelease(t *testing.T) {
+	w := newWatchTest(t)
+	defer os.RemoveAll(w.dir)
+	f, err := os.Create(w.m.Path("crate.png"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := png.Encode(f, image.NewRGBA(image.Rect(0, 0, 1, 1))); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	// A texture whose first upload has landed, registered the way TextureAsync does.
+	tex := &Texture{path: "crate.png", refs: 1, ready: make(chan struct{})}
+	close(tex.ready)
+	w.m.textures[tex.path] = tex
+	w.m.watchTexture(tex)
+
+	w.clock = w.clock.Add(time.Second)
+	if err := os.Chtimes(w.m.Path("crate.png"), w.clock, w.clock); err != nil {
+		t.Fatal(err)
+	}
+	w.m.poll()
+	if len(w.m.uploads) != 1 {
+		t.Fatalf("%d uploads queued for the changed texture, want 1", len(w.m.uploads))
+	}
+	// Released before the reload runs, as Release does without the GL delete. Reloading now would upload
+	// into texture 0.
+	delete(w.m.textures, tex.path)
+	w.m.unwatch(w.m.Path(tex.path), tex)
+	// The test texture has no GL object, so any attempt to reload it logs a failure.
+	var logged bytes.Buffer
+	log.SetOutput(&logged)
+	(<-w.m.uploads)()
+	log.SetOutput(os.Stderr)
+	if logged.Len() > 0 {
+		t.Errorf("released texture was reloaded: %s", logged.String())
+	}
+	if len(w.m.watched) != 0 {
+		t.Errorf("%d files still watched after the texture was released", len(w.m.watched))
+	}
+}
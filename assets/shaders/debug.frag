@@ -0,0 +1,9 @@
+#version 330
+in vec4 fragColor;
+
+out vec4 outputColor;
+
+void main() {
+    // Premultiplied like everything else that is blended.
+    outputColor = vec4(fragColor.rgb * fragColor.a, fragColor.a);
+}
@@ -0,0 +1,12 @@
+#version 330
+uniform mat4 viewProjection;
+
+in vec3 vert;
+in vec4 vertColor;
+
+out vec4 fragColor;
+
+void main() {
+    fragColor = vertColor;
+    gl_Position = viewProjection * vec4(vert, 1);
+}
@@ -0,0 +1,5 @@
+#version 330
+
+// Depth only, nothing to write.
+void main() {
+}
@@ -0,0 +1,9 @@
+#version 330
+uniform mat4 lightSpace;
+uniform mat4 model;
+
+in vec3 vert;
+
+void main() {
+    gl_Position = lightSpace * model * vec4(vert, 1);
+}
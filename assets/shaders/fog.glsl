@@ -0,0 +1,48 @@
+// Distance and height fog, fed by the Fog uniform block that fog.M uploads every frame.
+
+#define FOG_OFF 0
+#define FOG_LINEAR 1
+#define FOG_EXPONENTIAL 2
+
+layout(std140) uniform Fog {
+    vec4 fogColor;
+    // x is where linear fog starts, y where all fog is solid, z the exponential density.
+    vec4 fogDistances;
+    // x is the height fog base, y its density and z how fast it thins out with height.
+    vec4 fogHeight;
+    vec4 fogCamera;
+    ivec4 fogMode;
+};
+
+// fogAmount returns how much of worldPos is hidden by fog, from 0 to 1.
+float fogAmount(vec3 worldPos) {
+    if (fogMode.x == FOG_OFF) {
+        return 0.0;
+    }
+    vec3 ray = worldPos - fogCamera.xyz;
+    float d = length(ray);
+
+    float amount;
+    if (fogMode.x == FOG_LINEAR) {
+        amount = clamp((d - fogDistances.x) / (fogDistances.y - fogDistances.x), 0.0, 1.0);
+    } else {
+        float x = d * fogDistances.z;
+        amount = 1.0 - exp(-x * x);
+    }
+
+    // Height fog integrates an exponentially thinning density along the view ray.
+    float density = fogHeight.y;
+    float falloff = fogHeight.z;
+    if (density > 0.0 && falloff > 0.0) {
+        float start = density * exp(-(fogCamera.y - fogHeight.x) * falloff);
+        float rise = ray.y * falloff;
+        float optical = abs(rise) > 1e-4 ? start * d * (1.0 - exp(-rise)) / rise : start * d;
+        amount = max(amount, 1.0 - exp(-optical));
+    }
+    return amount;
+}
+
+// applyFog blends color towards the fog color by how far worldPos is from the camera.
+vec3 applyFog(vec3 color, vec3 worldPos) {
+    return mix(color, fogColor.rgb, fogAmount(worldPos));
+}
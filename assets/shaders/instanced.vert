@@ -0,0 +1,24 @@
+#version 330
+uniform mat4 projection;
+uniform mat4 view;
+
+in vec3 vert;
+in vec2 vertTexCoord;
+in vec3 vertNormal;
+in mat4 instanceModel;
+in vec4 instanceColor;
+
+out vec2 fragTexCoord;
+out vec3 fragNormal;
+out vec3 fragWorldPos;
+out vec4 fragColor;
+
+void main() {
+    fragTexCoord = vertTexCoord;
+    // Like lit.vert this assumes instances are only rotated, translated and uniformly scaled.
+    fragNormal = mat3(instanceModel) * vertNormal;
+    fragColor = instanceColor;
+    vec4 worldPos = instanceModel * vec4(vert, 1);
+    fragWorldPos = worldPos.xyz;
+    gl_Position = projection * view * worldPos;
+}
@@ -0,0 +1,9 @@
+#version 330
+uniform mat4 lightSpace;
+
+in vec3 vert;
+in mat4 instanceModel;
+
+void main() {
+    gl_Position = lightSpace * instanceModel * vec4(vert, 1);
+}
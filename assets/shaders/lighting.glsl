@@ -0,0 +1,47 @@
+// Shared lighting, fed by the Lights uniform block that lighting.M uploads every frame.
+#include "shadows.glsl"
+
+#define MAX_POINT_LIGHTS 32
+
+layout(std140) uniform Lights {
+    // xyz is the direction sunlight travels in.
+    vec4 sunDirection;
+    // Colors are premultiplied by intensity.
+    vec4 sunColor;
+    vec4 ambientSkyColor;
+    vec4 ambientGroundColor;
+    ivec4 pointLightCount;
+    // xyz is the position, w the radius at which the light fades out.
+    vec4 pointLightPositions[MAX_POINT_LIGHTS];
+    vec4 pointLightColors[MAX_POINT_LIGHTS];
+};
+
+// Color of light given off by emissive blocks.
+const vec3 blockLightColor = vec3(1.0, 0.85, 0.6);
+
+// applyVoxelLighting lights a surface that also has baked voxel light. skyLight scales how much of the sun
+// and ambient reach it, blockLight adds light from emissive blocks. Both run from 0 to 1.
+vec3 applyVoxelLighting(vec3 albedo, vec3 worldPos, vec3 normal, float skyLight, float blockLight) {
+    vec3 n = normalize(normal);
+
+    // Hemispheric ambient blends from the ground color below to the sky color above.
+    vec3 light = mix(ambientGroundColor.rgb, ambientSkyColor.rgb, n.y * 0.5 + 0.5);
+
+    vec3 toSun = -sunDirection.xyz;
+    light += sunColor.rgb * max(dot(n, toSun), 0.0) * shadowFactor(worldPos, n, toSun);
+    light *= skyLight;
+
+    light += blockLightColor * blockLight;
+
+    for (int i = 0; i < pointLightCount.x; i++) {
+        vec3 toLight = pointLightPositions[i].xyz - worldPos;
+        float d = length(toLight);
+        float falloff = clamp(1.0 - d / pointLightPositions[i].w, 0.0, 1.0);
+        light += pointLightColors[i].rgb * max(dot(n, toLight / d), 0.0) * falloff * falloff;
+    }
+    return albedo * light * shadowDebugTint(worldPos);
+}
+
+vec3 applyLighting(vec3 albedo, vec3 worldPos, vec3 normal) {
+    return applyVoxelLighting(albedo, worldPos, normal, 1.0, 0.0);
+}
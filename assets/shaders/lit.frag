@@ -0,0 +1,21 @@
+#version 330
+#include "lighting.glsl"
+#include "fog.glsl"
+
+uniform sampler2D tex;
+uniform vec4 baseColor = vec4(1);
+uniform bool textured = true;
+
+in vec2 fragTexCoord;
+in vec3 fragNormal;
+in vec3 fragWorldPos;
+
+out vec4 outputColor;
+
+void main() {
+    vec4 albedo = baseColor;
+    if (textured) {
+        albedo *= texture(tex, fragTexCoord);
+    }
+    outputColor = vec4(applyFog(applyLighting(albedo.rgb, fragWorldPos, fragNormal), fragWorldPos), albedo.a);
+}
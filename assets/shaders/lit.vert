@@ -0,0 +1,21 @@
+#version 330
+uniform mat4 projection;
+uniform mat4 view;
+uniform mat4 model;
+
+in vec3 vert;
+in vec2 vertTexCoord;
+in vec3 vertNormal;
+
+out vec2 fragTexCoord;
+out vec3 fragNormal;
+out vec3 fragWorldPos;
+
+void main() {
+    fragTexCoord = vertTexCoord;
+    // Models are only rotated, translated and uniformly scaled, so the model matrix works for normals.
+    fragNormal = mat3(model) * vertNormal;
+    vec4 worldPos = model * vec4(vert, 1);
+    fragWorldPos = worldPos.xyz;
+    gl_Position = projection * view * worldPos;
+}
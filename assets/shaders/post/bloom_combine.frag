@@ -0,0 +1,13 @@
+#version 330
+uniform sampler2D source;
+uniform sampler2D bloom;
+uniform float intensity;
+
+in vec2 fragTexCoord;
+
+out vec4 outputColor;
+
+void main() {
+    vec3 color = texture(source, fragTexCoord).rgb + texture(bloom, fragTexCoord).rgb * intensity;
+    outputColor = vec4(color, 1);
+}
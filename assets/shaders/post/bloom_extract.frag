@@ -0,0 +1,21 @@
+#version 330
+uniform sampler2D source;
+uniform float threshold;
+
+in vec2 fragTexCoord;
+
+out vec4 outputColor;
+
+void main() {
+    // Four taps average the full resolution source down into the half resolution target.
+    vec2 texel = 1.0 / vec2(textureSize(source, 0));
+    vec3 color = 0.25 * (
+        texture(source, fragTexCoord + texel * vec2(-0.5, -0.5)).rgb +
+        texture(source, fragTexCoord + texel * vec2(0.5, -0.5)).rgb +
+        texture(source, fragTexCoord + texel * vec2(-0.5, 0.5)).rgb +
+        texture(source, fragTexCoord + texel * vec2(0.5, 0.5)).rgb);
+    // Keep only what is brighter than the threshold, scaled down smoothly rather than cut off.
+    float brightness = max(color.r, max(color.g, color.b));
+    float contribution = max(brightness - threshold, 0.0) / max(brightness, 1e-4);
+    outputColor = vec4(color * contribution, 1);
+}
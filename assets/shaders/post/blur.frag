@@ -0,0 +1,19 @@
+#version 330
+uniform sampler2D source;
+// One texel along the blur axis.
+uniform vec2 direction;
+
+in vec2 fragTexCoord;
+
+out vec4 outputColor;
+
+const float weights[5] = float[5](0.227027, 0.1945946, 0.1216216, 0.054054, 0.016216);
+
+void main() {
+    vec3 color = texture(source, fragTexCoord).rgb * weights[0];
+    for (int i = 1; i < 5; i++) {
+        color += texture(source, fragTexCoord + direction * float(i)).rgb * weights[i];
+        color += texture(source, fragTexCoord - direction * float(i)).rgb * weights[i];
+    }
+    outputColor = vec4(color, 1);
+}
@@ -0,0 +1,41 @@
+#version 330
+uniform sampler2D source;
+
+in vec2 fragTexCoord;
+
+out vec4 outputColor;
+
+#define FXAA_SPAN_MAX 8.0
+#define FXAA_REDUCE_MUL (1.0 / 8.0)
+#define FXAA_REDUCE_MIN (1.0 / 128.0)
+
+const vec3 lumaWeights = vec3(0.299, 0.587, 0.114);
+
+// Blurs along the local edge direction, found from the luma of the four diagonal neighbours.
+void main() {
+    vec2 texel = 1.0 / vec2(textureSize(source, 0));
+    vec2 uv = fragTexCoord;
+
+    float lumaNW = dot(texture(source, uv + vec2(-1, -1) * texel).rgb, lumaWeights);
+    float lumaNE = dot(texture(source, uv + vec2(1, -1) * texel).rgb, lumaWeights);
+    float lumaSW = dot(texture(source, uv + vec2(-1, 1) * texel).rgb, lumaWeights);
+    float lumaSE = dot(texture(source, uv + vec2(1, 1) * texel).rgb, lumaWeights);
+    float lumaM = dot(texture(source, uv).rgb, lumaWeights);
+    float lumaMin = min(lumaM, min(min(lumaNW, lumaNE), min(lumaSW, lumaSE)));
+    float lumaMax = max(lumaM, max(max(lumaNW, lumaNE), max(lumaSW, lumaSE)));
+
+    vec2 dir = vec2(-((lumaNW + lumaNE) - (lumaSW + lumaSE)), (lumaNW + lumaSW) - (lumaNE + lumaSE));
+    float dirReduce = max((lumaNW + lumaNE + lumaSW + lumaSE) * 0.25 * FXAA_REDUCE_MUL, FXAA_REDUCE_MIN);
+    float rcpDirMin = 1.0 / (min(abs(dir.x), abs(dir.y)) + dirReduce);
+    dir = clamp(dir * rcpDirMin, -FXAA_SPAN_MAX, FXAA_SPAN_MAX) * texel;
+
+    vec3 rgbA = 0.5 * (
+        texture(source, uv + dir * (1.0 / 3.0 - 0.5)).rgb +
+        texture(source, uv + dir * (2.0 / 3.0 - 0.5)).rgb);
+    vec3 rgbB = rgbA * 0.5 + 0.25 * (
+        texture(source, uv - dir * 0.5).rgb +
+        texture(source, uv + dir * 0.5).rgb);
+    float lumaB = dot(rgbB, lumaWeights);
+    // If the wider blur stepped outside the local range it crossed another edge, so use the narrow one.
+    outputColor = vec4((lumaB < lumaMin || lumaB > lumaMax) ? rgbA : rgbB, 1);
+}
@@ -0,0 +1,12 @@
+#version 330
+uniform sampler2D source;
+uniform float gamma;
+
+in vec2 fragTexCoord;
+
+out vec4 outputColor;
+
+void main() {
+    vec3 color = texture(source, fragTexCoord).rgb;
+    outputColor = vec4(pow(max(color, 0.0), vec3(1.0 / gamma)), 1);
+}
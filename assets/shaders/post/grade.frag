@@ -0,0 +1,28 @@
+#version 330
+uniform sampler2D source;
+// A strip of size slices, each size x size, laid out left to right by blue. Within a slice red increases
+// to the right and green downwards.
+uniform sampler2D lut;
+uniform float strength;
+
+in vec2 fragTexCoord;
+
+out vec4 outputColor;
+
+void main() {
+    vec3 color = clamp(texture(source, fragTexCoord).rgb, 0.0, 1.0);
+    float size = float(textureSize(lut, 0).y);
+
+    float slice = color.b * (size - 1.0);
+    float slice0 = floor(slice);
+    float slice1 = min(slice0 + 1.0, size - 1.0);
+    // Sample texel centres so neighbouring slices don't bleed into each other.
+    float x = (color.r * (size - 1.0) + 0.5) / size;
+    // The strip was flipped on load, so its top row is at v = 1.
+    float v = 1.0 - (color.g * (size - 1.0) + 0.5) / size;
+    vec3 a = textureLod(lut, vec2((slice0 + x) / size, v), 0.0).rgb;
+    vec3 b = textureLod(lut, vec2((slice1 + x) / size, v), 0.0).rgb;
+    vec3 graded = mix(a, b, slice - slice0);
+
+    outputColor = vec4(mix(color, graded, strength), 1);
+}
@@ -0,0 +1,9 @@
+#version 330
+out vec2 fragTexCoord;
+
+void main() {
+    // A single triangle covering the screen, generated from the vertex index.
+    vec2 p = vec2((gl_VertexID << 1) & 2, gl_VertexID & 2);
+    fragTexCoord = p;
+    gl_Position = vec4(p * 2.0 - 1.0, 0, 1);
+}
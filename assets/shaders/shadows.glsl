@@ -0,0 +1,67 @@
+// Cascaded sun shadows, fed by the Shadows uniform block that shadows.M uploads every frame.
+#define MAX_CASCADES 4
+
+layout(std140) uniform Shadows {
+    mat4 cascadeLightSpace[MAX_CASCADES];
+    // Far distance of each cascade along the camera's forward axis.
+    vec4 cascadeSplits;
+    vec4 shadowCameraPosition;
+    vec4 shadowCameraForward;
+    // x is the cascade count, y enables the debug tint, z is the PCF kernel radius in texels.
+    ivec4 shadowParams;
+};
+
+uniform sampler2DArrayShadow shadowMap;
+
+int shadowCascade(vec3 worldPos) {
+    float depth = dot(worldPos - shadowCameraPosition.xyz, shadowCameraForward.xyz);
+    for (int i = 0; i < shadowParams.x; i++) {
+        if (depth < cascadeSplits[i]) {
+            return i;
+        }
+    }
+    return -1;
+}
+
+// shadowFactor returns how much sunlight reaches worldPos, from 0 in full shadow to 1 fully lit.
+float shadowFactor(vec3 worldPos, vec3 normal, vec3 toSun) {
+    int cascade = shadowCascade(worldPos);
+    if (cascade < 0) {
+        return 1.0;
+    }
+    // Push the sample point off the surface, more so at grazing angles, to avoid shadow acne.
+    float slope = 1.0 - max(dot(normal, toSun), 0.0);
+    vec4 ls = cascadeLightSpace[cascade] * vec4(worldPos + normal * (0.05 + 0.1 * slope), 1);
+    vec3 p = ls.xyz / ls.w * 0.5 + 0.5;
+    if (p.z >= 1.0) {
+        return 1.0;
+    }
+
+    vec2 texel = 1.0 / vec2(textureSize(shadowMap, 0).xy);
+    int r = shadowParams.z;
+    float lit = 0.0;
+    for (int x = -r; x <= r; x++) {
+        for (int y = -r; y <= r; y++) {
+            lit += texture(shadowMap, vec4(p.xy + vec2(x, y) * texel, float(cascade), p.z - 0.0005));
+        }
+    }
+    return lit / float((2 * r + 1) * (2 * r + 1));
+}
+
+// shadowDebugTint colors each cascade differently when the debug view is on.
+vec3 shadowDebugTint(vec3 worldPos) {
+    if (shadowParams.y == 0) {
+        return vec3(1);
+    }
+    switch (shadowCascade(worldPos)) {
+    case 0:
+        return vec3(1.0, 0.5, 0.5);
+    case 1:
+        return vec3(0.5, 1.0, 0.5);
+    case 2:
+        return vec3(0.5, 0.5, 1.0);
+    case 3:
+        return vec3(1.0, 1.0, 0.5);
+    }
+    return vec3(1);
+}
@@ -0,0 +1,24 @@
+#version 330
+#include "skinning.glsl"
+
+uniform mat4 projection;
+uniform mat4 view;
+uniform mat4 model;
+
+in vec3 vert;
+in vec2 vertTexCoord;
+in vec3 vertNormal;
+
+out vec2 fragTexCoord;
+out vec3 fragNormal;
+out vec3 fragWorldPos;
+
+void main() {
+    mat4 skin = skinMatrix();
+    fragTexCoord = vertTexCoord;
+    // Like lit.vert this assumes no non-uniform scale, in the joints as well as the model.
+    fragNormal = mat3(model) * mat3(skin) * vertNormal;
+    vec4 worldPos = model * skin * vec4(vert, 1);
+    fragWorldPos = worldPos.xyz;
+    gl_Position = projection * view * worldPos;
+}
@@ -0,0 +1,11 @@
+#version 330
+#include "skinning.glsl"
+
+uniform mat4 lightSpace;
+uniform mat4 model;
+
+in vec3 vert;
+
+void main() {
+    gl_Position = lightSpace * model * skinMatrix() * vec4(vert, 1);
+}
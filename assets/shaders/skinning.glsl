@@ -0,0 +1,14 @@
+#define MAX_JOINTS 64
+
+uniform mat4 joints[MAX_JOINTS];
+
+in uvec4 vertJoints;
+in vec4 vertWeights;
+
+// skinMatrix mixes the joint matrices that move this vertex.
+mat4 skinMatrix() {
+    return vertWeights.x * joints[vertJoints.x] +
+           vertWeights.y * joints[vertJoints.y] +
+           vertWeights.z * joints[vertJoints.z] +
+           vertWeights.w * joints[vertJoints.w];
+}
@@ -0,0 +1,32 @@
+#version 330
+uniform bool useSkybox;
+uniform samplerCube skybox;
+
+uniform vec3 zenithColor;
+uniform vec3 horizonColor;
+uniform vec3 toSun;
+uniform vec3 sunColor;
+
+in vec3 fragDirection;
+
+out vec4 outputColor;
+
+void main() {
+    vec3 d = normalize(fragDirection);
+    if (useSkybox) {
+        outputColor = texture(skybox, d);
+        return;
+    }
+
+    vec3 color;
+    if (d.y >= 0.0) {
+        color = mix(horizonColor, zenithColor, sqrt(d.y));
+    } else {
+        // Below the horizon fade towards a darker horizon color.
+        color = horizonColor * mix(1.0, 0.5, min(-d.y * 4.0, 1.0));
+    }
+
+    float s = max(dot(d, toSun), 0.0);
+    color += sunColor * (pow(s, 8.0) * 0.25 + pow(s, 1000.0) * 8.0);
+    outputColor = vec4(color, 1);
+}
@@ -0,0 +1,13 @@
+#version 330
+uniform mat4 invViewProjection;
+
+out vec3 fragDirection;
+
+void main() {
+    // A single triangle covering the screen, generated from the vertex index.
+    vec2 p = vec2((gl_VertexID << 1) & 2, gl_VertexID & 2) * 2.0 - 1.0;
+    vec4 world = invViewProjection * vec4(p, 1, 1);
+    fragDirection = world.xyz / world.w;
+    // z = w puts the sky exactly on the far plane.
+    gl_Position = vec4(p, 1, 1);
+}
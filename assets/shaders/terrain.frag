@@ -0,0 +1,29 @@
+#version 330
+#include "lighting.glsl"
+#include "fog.glsl"
+
+uniform sampler2DArray blocks;
+// Texels less opaque than this are discarded, for cutout blocks.
+uniform float alphaCutoff;
+
+in vec2 fragTexCoord;
+in vec3 fragNormal;
+in vec3 fragWorldPos;
+in float fragAO;
+in float fragSkyLight;
+in float fragBlockLight;
+flat in uint fragMaterial;
+
+out vec4 outputColor;
+
+void main() {
+    vec4 albedo = texture(blocks, vec3(fragTexCoord, float(fragMaterial - 1u)));
+    if (albedo.a < alphaCutoff) {
+        discard;
+    }
+    // Fully occluded corners keep a little light so crevices don't go black.
+    float ao = mix(0.35, 1.0, fragAO);
+    vec3 color = applyVoxelLighting(albedo.rgb, fragWorldPos, fragNormal, fragSkyLight, fragBlockLight) * ao;
+    // Albedo is premultiplied by alpha, so the fog color has to be as well.
+    outputColor = vec4(mix(color, fogColor.rgb * albedo.a, fogAmount(fragWorldPos)), albedo.a);
+}
@@ -0,0 +1,32 @@
+#version 330
+#include "terrain_vertex.glsl"
+
+uniform mat4 projection;
+uniform mat4 view;
+uniform mat4 model;
+
+out vec2 fragTexCoord;
+out vec3 fragNormal;
+out vec3 fragWorldPos;
+out float fragAO;
+out float fragSkyLight;
+out float fragBlockLight;
+flat out uint fragMaterial;
+
+const vec3 normals[6] = vec3[6](
+    vec3(1, 0, 0), vec3(-1, 0, 0),
+    vec3(0, 1, 0), vec3(0, -1, 0),
+    vec3(0, 0, 1), vec3(0, 0, -1));
+
+void main() {
+    vec3 vert = terrainVertex(fragTexCoord);
+    fragNormal = normals[(packed >> 15) & 7u];
+    fragAO = float((packed >> 18) & 3u) / 3.0;
+    fragMaterial = packed >> 28;
+    // Each light level is 80% as bright as the one above it.
+    fragBlockLight = pow(0.8, 15.0 - float((packed >> 20) & 15u));
+    fragSkyLight = pow(0.8, 15.0 - float((packed >> 24) & 15u));
+    vec4 worldPos = model * vec4(vert, 1);
+    fragWorldPos = worldPos.xyz;
+    gl_Position = projection * view * worldPos;
+}
@@ -0,0 +1,13 @@
+#version 330
+uniform sampler2DArray blocks;
+// Texels less opaque than this cast no shadow.
+uniform float alphaCutoff;
+
+in vec2 fragTexCoord;
+flat in uint fragMaterial;
+
+void main() {
+    if (texture(blocks, vec3(fragTexCoord, float(fragMaterial - 1u))).a < alphaCutoff) {
+        discard;
+    }
+}
@@ -0,0 +1,14 @@
+#version 330
+#include "terrain_vertex.glsl"
+
+uniform mat4 lightSpace;
+uniform mat4 model;
+
+out vec2 fragTexCoord;
+flat out uint fragMaterial;
+
+void main() {
+    vec3 vert = terrainVertex(fragTexCoord);
+    fragMaterial = packed >> 28;
+    gl_Position = lightSpace * model * vec4(vert, 1);
+}
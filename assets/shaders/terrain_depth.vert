@@ -0,0 +1,11 @@
+#version 330
+#include "terrain_vertex.glsl"
+
+uniform mat4 lightSpace;
+uniform mat4 model;
+
+void main() {
+    vec2 texCoord;
+    vec3 vert = terrainVertex(texCoord);
+    gl_Position = lightSpace * model * vec4(vert, 1);
+}
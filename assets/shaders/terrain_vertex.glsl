@@ -0,0 +1,19 @@
+// See TerrainShader_Vertex for the bit layout.
+in uint packed;
+
+// The axes a face's s and t texture coordinates run along, for faces perpendicular to x, y and z. See
+// faceAxes in the voxel mesher.
+const ivec2 faceAxes[3] = ivec2[3](ivec2(2, 1), ivec2(0, 2), ivec2(0, 1));
+
+// terrainVertex returns the cell local position and texture coordinate of this vertex. Quads are drawn
+// indexed, four vertices in corner order, so the index picks the corner.
+vec3 terrainVertex(out vec2 texCoord) {
+    uint corner = uint(gl_VertexID) & 3u;
+    texCoord = vec2(corner >> 1, corner & 1u);
+    int axis = int((packed >> 15) & 7u) / 2;
+    vec3 vert = vec3(packed & 31u, (packed >> 5) & 31u, (packed >> 10) & 31u);
+    vert[axis] += 1.0;
+    vert[faceAxes[axis].x] += texCoord.x;
+    vert[faceAxes[axis].y] += texCoord.y;
+    return vert;
+}
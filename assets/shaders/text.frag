@@ -0,0 +1,13 @@
+#version 330
+uniform sampler2D atlas;
+
+in vec2 fragTexCoord;
+in vec4 fragColor;
+
+out vec4 outputColor;
+
+void main() {
+    // The atlas holds glyph coverage in alpha, premultiplied like everything else that is blended.
+    float coverage = texture(atlas, fragTexCoord).a * fragColor.a;
+    outputColor = vec4(fragColor.rgb * coverage, coverage);
+}
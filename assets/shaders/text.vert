@@ -0,0 +1,15 @@
+#version 330
+uniform mat4 projection;
+
+in vec2 vert;
+in vec2 vertTexCoord;
+in vec4 vertColor;
+
+out vec2 fragTexCoord;
+out vec4 fragColor;
+
+void main() {
+    fragTexCoord = vertTexCoord;
+    fragColor = vertColor;
+    gl_Position = projection * vec4(vert, 0, 1);
+}
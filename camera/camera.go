@@ -7,8 +7,6 @@ import (
 	"math"
 	"sync"
 
-	"fmt"
-
 	"github.com/brandonnelson3/GoPlay/input"
 	"github.com/brandonnelson3/GoPlay/window"
 )
@@ -60,7 +58,7 @@ func init() {
 	input.M.Register(glfw.KeyRight, C.Right)
 
 	window.M.W.SetCursorPos(float64(window.M.Height)/2, float64(window.M.Width)/2)
-	window.M.W.SetCursorPosCallback(C.cursorPosCallback)
+	input.M.RegisterCursor(C.cursorPosCallback)
 	window.M.W.SetInputMode(glfw.CursorMode, glfw.CursorHidden)
 }
 
@@ -82,8 +80,6 @@ func (c *FPS) Up(_ bool, d float32) {
 	if c.verticalAngle > Pi2-0.0001 {
 		c.verticalAngle = float32(Pi2 - 0.0001)
 	}
-
-	fmt.Printf("Angle: %v", c.verticalAngle)
 }
 func (c *FPS) Down(_ bool, d float32) {
 	c.verticalAngle -= c.sensitivity * d
@@ -104,7 +100,7 @@ func (c *FPS) Right(_ bool, d float32) {
 	}
 }
 
-func (c *FPS) cursorPosCallback(w *glfw.Window, x, y float64) {
+func (c *FPS) cursorPosCallback(x, y float64) {
 	x -= float64(window.M.Width) / 2
 	y -= float64(window.M.Height) / 2
 	c.verticalAngle -= c.sensitivity * float32(y) * .01
@@ -134,6 +130,13 @@ func (c *FPS) GetPosition() mgl32.Vec3 {
 	return c.position
 }
 
+// SetPosition moves the camera to p without changing where it looks.
+func (c *FPS) SetPosition(p mgl32.Vec3) {
+	c.positionMu.Lock()
+	defer c.positionMu.Unlock()
+	c.position = p
+}
+
 // TODO: Move this to the base struct. Also not a bad idea to memoize the result, and update only when dirty.
 func (c *FPS) GetForward() mgl32.Vec3 {
 	return mgl32.Rotate3DY(c.horizontalAngle).Mul3x1(mgl32.Rotate3DZ(c.verticalAngle).Mul3x1((mgl32.Vec3{1, 0, 0})))
@@ -150,3 +153,8 @@ func (c *FPS) GetViewMatrix() mgl32.Mat4 {
 	defer c.positionMu.RUnlock()
 	return mgl32.LookAtV(c.position, c.position.Add(c.GetForward()), mgl32.Vec3{0, 1, 0})
 }
+
+// GetProjectionMatrix returns the perspective projection for the window's aspect ratio.
+func (c *FPS) GetProjectionMatrix() mgl32.Mat4 {
+	return mgl32.Perspective(mgl32.DegToRad(c.FOVDegrees), float32(window.M.Width)/float32(window.M.Height), c.NearPlaneDist, c.FarPlaneDist)
+}
@@ -0,0 +1,254 @@
+package debugdraw
+
+import (
+	"math"
+
+	"github.com/go-gl/gl/v4.1-core/gl"
+	"github.com/go-gl/mathgl/mgl32"
+
+	"github.com/brandonnelson3/GoPlay/assetmanager"
+	"github.com/brandonnelson3/GoPlay/shaders"
+)
+
+// Options control how long a shape stays and whether geometry hides it.
+type Options struct {
+	// Duration keeps the shape for that many seconds. Zero draws it in the next frame only.
+	Duration float64
+	// DepthTest hides the shape behind nearer geometry, otherwise it is drawn on top of everything.
+	DepthTest bool
+}
+
+// Global debug draw manager. Shapes are collected from anywhere on the render thread during a frame and
+// drawn together by Flush.
+var M manager
+
+type manager struct {
+	// Enabled turns debug drawing on. Shapes submitted while it is off are dropped.
+	Enabled bool
+
+	lines []line
+	texts []text
+
+	shader   *shaders.DebugShader
+	vbo      *shaders.VertexBuffer
+	vertices []shaders.DebugShader_Vertex
+}
+
+// shape is the lifetime of a line or text.
+type shape struct {
+	color     mgl32.Vec4
+	depthTest bool
+	// remaining is the seconds left to live, once marks shapes drawn for a single frame.
+	remaining float64
+	once      bool
+}
+
+type line struct {
+	shape
+	a, b mgl32.Vec3
+}
+
+type text struct {
+	shape
+	position mgl32.Vec3
+	s        string
+	size     float32
+}
+
+func init() {
+	M = manager{Enabled: true}
+}
+
+// Init loads the debug shader. Must be called on the render thread before Flush.
+func (m *manager) Init() error {
+	var err error
+	m.shader, err = assetmanager.M.DebugShader()
+	return err
+}
+
+func newShape(color mgl32.Vec4, o Options) shape {
+	return shape{color: color, depthTest: o.DepthTest, remaining: o.Duration, once: o.Duration <= 0}
+}
+
+// Update ages shapes with a duration by elapsed seconds, removing those whose time is up.
+func (m *manager) Update(elapsed float64) {
+	lines := m.lines[:0]
+	for _, l := range m.lines {
+		if l.remaining -= elapsed; l.once || l.remaining > 0 {
+			lines = append(lines, l)
+		}
+	}
+	m.lines = lines
+	texts := m.texts[:0]
+	for _, t := range m.texts {
+		if t.remaining -= elapsed; t.once || t.remaining > 0 {
+			texts = append(texts, t)
+		}
+	}
+	m.texts = texts
+}
+
+// Flush draws every shape with the camera's projection and view matrices, and forgets the ones drawn for
+// a single frame. Depth tested shapes need the frame's depth buffer bound.
+func (m *manager) Flush(projection, view mgl32.Mat4) {
+	if m.shader == nil || len(m.lines)+len(m.texts) == 0 {
+		return
+	}
+	// Depth tested shapes go first, then the rest, in one buffer. Text faces the camera, so its strokes are
+	// only known now.
+	right, up := view.Row(0).Vec3(), view.Row(1).Vec3()
+	m.vertices = m.vertices[:0]
+	m.appendShapes(true, right, up)
+	tested := int32(len(m.vertices))
+	m.appendShapes(false, right, up)
+
+	if m.vbo == nil {
+		m.vbo = shaders.NewVertexBuffer(shaders.DebugShader_Layout, m.vertices, nil)
+	} else {
+		m.vbo.Update(m.vertices, nil)
+	}
+	m.shader.Activate()
+	m.shader.SetViewProjection(projection.Mul4(view))
+	m.vbo.Activate()
+	gl.Enable(gl.BLEND)
+	gl.BlendFunc(gl.ONE, gl.ONE_MINUS_SRC_ALPHA)
+	gl.DepthMask(false)
+	if tested > 0 {
+		gl.DrawArrays(gl.LINES, 0, tested)
+	}
+	if n := int32(len(m.vertices)) - tested; n > 0 {
+		gl.Disable(gl.DEPTH_TEST)
+		gl.DrawArrays(gl.LINES, tested, n)
+		gl.Enable(gl.DEPTH_TEST)
+	}
+	gl.DepthMask(true)
+	gl.Disable(gl.BLEND)
+
+	// Drop everything that was only for this frame.
+	lines := m.lines[:0]
+	for _, l := range m.lines {
+		if !l.once {
+			lines = append(lines, l)
+		}
+	}
+	m.lines = lines
+	texts := m.texts[:0]
+	for _, t := range m.texts {
+		if !t.once {
+			texts = append(texts, t)
+		}
+	}
+	m.texts = texts
+}
+
+// appendShapes adds the vertices of the lines and text with or without depth testing. right and up are
+// the camera's axes in world space.
+func (m *manager) appendShapes(depthTest bool, right, up mgl32.Vec3) {
+	for _, l := range m.lines {
+		if l.depthTest == depthTest {
+			m.appendLine(l.a, l.b, l.color)
+		}
+	}
+	for _, t := range m.texts {
+		if t.depthTest == depthTest {
+			m.appendText(t, right, up)
+		}
+	}
+}
+
+func (m *manager) appendLine(a, b mgl32.Vec3, color mgl32.Vec4) {
+	m.vertices = append(m.vertices, shaders.DebugShader_Vertex{Vert: a, VertColor: color}, shaders.DebugShader_Vertex{Vert: b, VertColor: color})
+}
+
+// DrawLine draws a line from a to b.
+func DrawLine(a, b mgl32.Vec3, color mgl32.Vec4, o Options) {
+	if !M.Enabled {
+		return
+	}
+	M.lines = append(M.lines, line{shape: newShape(color, o), a: a, b: b})
+}
+
+// DrawAABB draws the edges of the box from min to max.
+func DrawAABB(min, max mgl32.Vec3, color mgl32.Vec4, o Options) {
+	var corners [8]mgl32.Vec3
+	for i := range corners {
+		for axis := 0; axis < 3; axis++ {
+			if i&(1<<uint(axis)) != 0 {
+				corners[i][axis] = max[axis]
+			} else {
+				corners[i][axis] = min[axis]
+			}
+		}
+	}
+	drawBox(corners, color, o)
+}
+
+// drawBox draws the edges of a box given its corners, corner i being at the max on the axes whose bit is
+// set in i.
+func drawBox(corners [8]mgl32.Vec3, color mgl32.Vec4, o Options) {
+	for i := range corners {
+		for axis := uint(0); axis < 3; axis++ {
+			if j := i | 1<<axis; j != i {
+				DrawLine(corners[i], corners[j], color, o)
+			}
+		}
+	}
+}
+
+// DrawFrustum draws the edges of the volume a combined projection and view matrix sees.
+func DrawFrustum(viewProjection mgl32.Mat4, color mgl32.Vec4, o Options) {
+	inv := viewProjection.Inv()
+	var corners [8]mgl32.Vec3
+	for i := range corners {
+		ndc := mgl32.Vec3{-1, -1, -1}
+		for axis := uint(0); axis < 3; axis++ {
+			if i&(1<<axis) != 0 {
+				ndc[axis] = 1
+			}
+		}
+		corners[i] = mgl32.TransformCoordinate(ndc, inv)
+	}
+	drawBox(corners, color, o)
+}
+
+// sphereSegments is how many lines each circle of a sphere is drawn with.
+const sphereSegments = 32
+
+// DrawSphere draws a sphere as three circles around its axes.
+func DrawSphere(center mgl32.Vec3, radius float32, color mgl32.Vec4, o Options) {
+	for axis := 0; axis < 3; axis++ {
+		u, v := (axis+1)%3, (axis+2)%3
+		previous := center
+		previous[u] += radius
+		for i := 1; i <= sphereSegments; i++ {
+			angle := float64(i) / sphereSegments * 2 * math.Pi
+			p := center
+			p[u] += radius * float32(math.Cos(angle))
+			p[v] += radius * float32(math.Sin(angle))
+			DrawLine(previous, p, color, o)
+			previous = p
+		}
+	}
+}
+
+// DrawAxes draws the X, Y and Z axes of transform in red, green and blue, each size long.
+func DrawAxes(transform mgl32.Mat4, size float32, o Options) {
+	origin := transform.Col(3).Vec3()
+	colors := [3]mgl32.Vec4{{1, 0, 0, 1}, {0, 1, 0, 1}, {0, 0, 1, 1}}
+	for axis := 0; axis < 3; axis++ {
+		d := transform.Col(axis).Vec3()
+		if l := d.Len(); l > 0 {
+			d = d.Mul(size / l)
+		}
+		DrawLine(origin, origin.Add(d), colors[axis], o)
+	}
+}
+
+// DrawText3D writes s centered on position, facing the camera, with letters size tall. Only digits, basic
+// punctuation and letters, drawn as capitals, are supported.
+func DrawText3D(position mgl32.Vec3, s string, size float32, color mgl32.Vec4, o Options) {
+	if !M.Enabled {
+		return
+	}
+	M.texts = append(M.texts, text{shape: newShape(color, o), position: position, s: s, size: size})
+}
@@ -0,0 +1,63 @@
+package debugdraw
+
+import (
+	"unicode"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// Text is drawn in the style of a sixteen segment display, which needs nothing but lines. Each segment
+// joins two points of a cell one unit wide and two tall, origin at the bottom left.
+var segments = map[byte][2]mgl32.Vec2{
+	'a': {{0, 2}, {0.5, 2}},   // top left
+	'A': {{0.5, 2}, {1, 2}},   // top right
+	'b': {{1, 2}, {1, 1}},     // right upper
+	'c': {{1, 1}, {1, 0}},     // right lower
+	'd': {{0, 0}, {0.5, 0}},   // bottom left
+	'D': {{0.5, 0}, {1, 0}},   // bottom right
+	'e': {{0, 0}, {0, 1}},     // left lower
+	'f': {{0, 1}, {0, 2}},     // left upper
+	'g': {{0, 1}, {0.5, 1}},   // middle left
+	'G': {{0.5, 1}, {1, 1}},   // middle right
+	'h': {{0, 2}, {0.5, 1}},   // diagonal to the top left
+	'i': {{0.5, 2}, {0.5, 1}}, // center upper
+	'j': {{1, 2}, {0.5, 1}},   // diagonal to the top right
+	'k': {{0, 0}, {0.5, 1}},   // diagonal to the bottom left
+	'l': {{0.5, 0}, {0.5, 1}}, // center lower
+	'm': {{1, 0}, {0.5, 1}},   // diagonal to the bottom right
+}
+
+// glyphs lists the segments lit for each character.
+var glyphs = map[rune]string{
+	'0': "aAbcdDefjk", '1': "bcj", '2': "aAbgGedD", '3': "aAbcdDG", '4': "fgGbc",
+	'5': "aAfgGcdD", '6': "aAfedDcgG", '7': "aAbc", '8': "aAbcdDefgG", '9': "aAbcdDfgG",
+	'A': "efaAbcgG", 'B': "aAbcdDilG", 'C': "aAfedD", 'D': "aAbcdDil", 'E': "aAfedDg",
+	'F': "aAfeg", 'G': "aAfedDcG", 'H': "febcgG", 'I': "aAildD", 'J': "bcdDe",
+	'K': "fegjm", 'L': "fedD", 'M': "efhjbc", 'N': "efhmcb", 'O': "aAbcdDef",
+	'P': "aAbfegG", 'Q': "aAbcdDefm", 'R': "aAbfegGm", 'S': "aAfgGcdD", 'T': "aAil",
+	'U': "fedDcb", 'V': "fekj", 'W': "fekmcb", 'X': "hjkm", 'Y': "hjl",
+	'Z': "aAjkdD", '-': "gG", '+': "gGil", '=': "gGdD", '/': "jk", '.': "d",
+	',': "k", '_': "dD", '(': "jm", ')': "hk", '*': "gGhjkmil", ':': "il",
+}
+
+// advance is the distance between letters in cells.
+const advance = 1.5
+
+// appendText adds the strokes of t, facing the camera whose axes are right and up.
+func (m *manager) appendText(t text, right, up mgl32.Vec3) {
+	scale := t.size / 2
+	width := advance*float32(len([]rune(t.s))) - (advance - 1)
+	// Center the text on its position.
+	origin := t.position.Sub(right.Mul(width / 2 * scale)).Sub(up.Mul(scale))
+	point := func(p mgl32.Vec2, x float32) mgl32.Vec3 {
+		return origin.Add(right.Mul((x + p[0]) * scale)).Add(up.Mul(p[1] * scale))
+	}
+	x := float32(0)
+	for _, r := range t.s {
+		for _, s := range glyphs[unicode.ToUpper(r)] {
+			seg := segments[byte(s)]
+			m.appendLine(point(seg[0], x), point(seg[1], x), t.color)
+		}
+		x += advance
+	}
+}
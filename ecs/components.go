@@ -0,0 +1,98 @@
+package ecs
+
+//go:generate go run gen_stores.go
+
+import (
+	"github.com/go-gl/mathgl/mgl32"
+
+	"github.com/brandonnelson3/GoPlay/assetmanager"
+	"github.com/brandonnelson3/GoPlay/lighting"
+	"github.com/brandonnelson3/GoPlay/physics"
+	"github.com/brandonnelson3/GoPlay/shaders"
+)
+
+// Transform places an entity relative to the scene node its World is attached to.
+type Transform struct {
+	Position mgl32.Vec3
+	Rotation mgl32.Quat
+	Scale    mgl32.Vec3
+}
+
+// NewTransform returns an unrotated, unscaled transform at position.
+func NewTransform(position mgl32.Vec3) Transform {
+	return Transform{Position: position, Rotation: mgl32.QuatIdent(), Scale: mgl32.Vec3{1, 1, 1}}
+}
+
+func (t *Transform) Matrix() mgl32.Mat4 {
+	return mgl32.Translate3D(t.Position.Elem()).Mul4(t.Rotation.Mat4()).Mul4(mgl32.Scale3D(t.Scale.Elem()))
+}
+
+// MeshRenderer draws a lit, textured mesh at the entity's transform.
+type MeshRenderer struct {
+	Mesh    *shaders.VertexBuffer
+	Shader  *shaders.LitShader
+	Texture *assetmanager.Texture
+	// DepthShader draws the mesh into the shadow map. Nil meshes cast no shadow.
+	DepthShader *shaders.DepthShader
+}
+
+// Velocity moves and spins an entity every tick.
+type Velocity struct {
+	// Linear is in units per second.
+	Linear mgl32.Vec3
+	// Angular is the rotation axis scaled by radians per second.
+	Angular mgl32.Vec3
+}
+
+// Collider is an axis aligned box around the entity's position.
+type Collider struct {
+	HalfExtents mgl32.Vec3
+}
+
+// RigidBody moves the entity with a physics body. The body is added to the World's Physics on the next
+// Update, starting from the entity's transform.
+type RigidBody struct {
+	Body *physics.Body
+
+	// world is the physics world the body was added to, nil until then.
+	world *physics.World
+}
+
+// replacedBy takes a replaced body out of the physics world, or hands the new component the world when the
+// body stays the same.
+func (r *RigidBody) replacedBy(c *RigidBody) {
+	if r.world != nil && r.Body != c.Body {
+		r.world.Remove(r.Body)
+	} else {
+		c.world = r.world
+	}
+}
+
+// removed takes the body out of the physics world.
+func (r *RigidBody) removed() {
+	if r.world != nil {
+		r.world.Remove(r.Body)
+	}
+}
+
+// Light is a point light that follows the entity.
+type Light struct {
+	Color     mgl32.Vec3
+	Intensity float32
+	Radius    float32
+
+	light *lighting.PointLight
+}
+
+// replacedBy keeps the existing point light when the component is replaced, the light system picks up the
+// new settings.
+func (l *Light) replacedBy(c *Light) {
+	c.light = l.light
+}
+
+// removed takes the light out of the lighting manager.
+func (l *Light) removed() {
+	if l.light != nil {
+		lighting.M.RemovePointLight(l.light)
+	}
+}
@@ -0,0 +1,84 @@
+package ecs
+
+// Entity identifies a game object. The low bits index into component storage, the high bits count how many
+// times that index has been reused, so stale handles to destroyed entities can be told apart.
+type Entity uint32
+
+const (
+	indexBits      = 22
+	indexMask      = 1<<indexBits - 1
+	generationMask = 1<<(32-indexBits) - 1
+)
+
+func newEntity(index, generation uint32) Entity {
+	return Entity(generation&generationMask<<indexBits | index)
+}
+
+func (e Entity) index() uint32 {
+	return uint32(e) & indexMask
+}
+
+func (e Entity) generation() uint32 {
+	return uint32(e) >> indexBits
+}
+
+// sparseSet maps entities to dense slots. Component stores keep their data in a slice parallel to
+// entities, so iterating a component type touches contiguous memory.
+type sparseSet struct {
+	// sparse holds each entity index's slot plus one, so zero means absent.
+	sparse   []int32
+	entities []Entity
+}
+
+// find returns the slot of e, or -1.
+func (s *sparseSet) find(e Entity) int {
+	i := e.index()
+	if int(i) >= len(s.sparse) || s.sparse[i] == 0 {
+		return -1
+	}
+	slot := int(s.sparse[i] - 1)
+	if s.entities[slot] != e {
+		return -1
+	}
+	return slot
+}
+
+func (s *sparseSet) has(e Entity) bool {
+	return s.find(e) >= 0
+}
+
+// add appends e and returns its slot. The caller appends the component data.
+func (s *sparseSet) add(e Entity) int {
+	i := int(e.index())
+	for len(s.sparse) <= i {
+		s.sparse = append(s.sparse, 0)
+	}
+	s.entities = append(s.entities, e)
+	s.sparse[i] = int32(len(s.entities))
+	return len(s.entities) - 1
+}
+
+// remove takes e out by moving the last entity into its slot. The caller does the same to its data, copying
+// slot last into slot and truncating to last.
+func (s *sparseSet) remove(e Entity) (slot, last int) {
+	slot = s.find(e)
+	if slot < 0 {
+		return -1, -1
+	}
+	last = len(s.entities) - 1
+	moved := s.entities[last]
+	s.entities[slot] = moved
+	s.sparse[moved.index()] = int32(slot + 1)
+	s.sparse[e.index()] = 0
+	s.entities = s.entities[:last]
+	return slot, last
+}
+
+// Entities returns every entity with the component, in the same order as the store's Data.
+func (s *sparseSet) Entities() []Entity {
+	return s.entities
+}
+
+func (s *sparseSet) Len() int {
+	return len(s.entities)
+}
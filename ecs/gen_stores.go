@@ -0,0 +1,97 @@
+//go:build ignore
+// +build ignore
+
+// gen_stores writes stores.go, the typed component store for every component in components.go. Run it with
+// go generate after adding a component.
+package main
+
+import (
+	"bytes"
+	"go/format"
+	"io/ioutil"
+	"log"
+	"text/template"
+)
+
+type component struct {
+	Name string
+	// Hooks is set for components with replacedBy and removed methods, which release whatever the
+	// component holds outside the store.
+	Hooks bool
+}
+
+var components = []component{
+	{Name: "Transform"},
+	{Name: "MeshRenderer"},
+	{Name: "Velocity"},
+	{Name: "Collider"},
+	{Name: "Light", Hooks: true},
+	{Name: "RigidBody", Hooks: true},
+}
+
+var stores = template.Must(template.New("stores").Parse(`// Code generated by gen_stores.go; DO NOT EDIT.
+
+package ecs
+
+// Each store keeps its components in a slice parallel to the entities of its sparseSet. Data returns the
+// components in the same order as Entities. Pointers from Add, Get and Data are only valid until the next
+// Add or Remove on the store.
+{{range .}}
+type {{.Name}}Store struct {
+	sparseSet
+	data []{{.Name}}
+}
+
+// Add gives e the component, replacing any it already had.
+func (s *{{.Name}}Store) Add(e Entity, c {{.Name}}) *{{.Name}} {
+	if i := s.find(e); i >= 0 {
+		{{- if .Hooks}}
+		s.data[i].replacedBy(&c)
+		{{- end}}
+		s.data[i] = c
+		return &s.data[i]
+	}
+	s.add(e)
+	s.data = append(s.data, c)
+	return &s.data[len(s.data)-1]
+}
+
+// Get returns e's component, or nil if it has none.
+func (s *{{.Name}}Store) Get(e Entity) *{{.Name}} {
+	if i := s.find(e); i >= 0 {
+		return &s.data[i]
+	}
+	return nil
+}
+
+func (s *{{.Name}}Store) Remove(e Entity) {
+	slot, last := s.remove(e)
+	if slot < 0 {
+		return
+	}
+	{{- if .Hooks}}
+	s.data[slot].removed()
+	{{- end}}
+	s.data[slot] = s.data[last]
+	s.data[last] = {{.Name}}{}
+	s.data = s.data[:last]
+}
+
+func (s *{{.Name}}Store) Data() []{{.Name}} {
+	return s.data
+}
+{{end}}`))
+
+func main() {
+	var b bytes.Buffer
+	if err := stores.Execute(&b, components); err != nil {
+		log.Fatal(err)
+	}
+	src, err := format.Source(b.Bytes())
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := ioutil.WriteFile("stores.go", src, 0644); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,68 @@
+package ecs
+
+import (
+	"github.com/go-gl/mathgl/mgl32"
+
+	"github.com/brandonnelson3/GoPlay/lighting"
+)
+
+// MovementSystem applies each Velocity to its entity's Transform.
+func MovementSystem(w *World, elapsed float64) {
+	dt := float32(elapsed)
+	for i, e := range w.Velocities.entities {
+		t := w.Transforms.Get(e)
+		if t == nil {
+			continue
+		}
+		v := &w.Velocities.data[i]
+		t.Position = t.Position.Add(v.Linear.Mul(dt))
+		if speed := v.Angular.Len(); speed > 0 {
+			t.Rotation = mgl32.QuatRotate(speed*dt, v.Angular.Mul(1/speed)).Mul(t.Rotation).Normalize()
+		}
+	}
+}
+
+// RigidBodySystem adds new RigidBodies to the world's Physics and moves each entity's Transform to its
+// body. Physics runs in world space, so positions are converted through the world's node.
+func RigidBodySystem(w *World, _ float64) {
+	if w.Physics == nil {
+		return
+	}
+	toLocal := w.origin.Inv()
+	for i, e := range w.RigidBodies.entities {
+		r := &w.RigidBodies.data[i]
+		t := w.Transforms.Get(e)
+		if r.world != w.Physics {
+			if r.world != nil {
+				r.world.Remove(r.Body)
+			}
+			if t != nil {
+				r.Body.Position = mgl32.TransformCoordinate(t.Position, w.origin)
+			}
+			w.Physics.Add(r.Body)
+			r.world = w.Physics
+		}
+		if t != nil {
+			t.Position = mgl32.TransformCoordinate(r.Body.Position, toLocal)
+		}
+	}
+}
+
+// LightSystem keeps a point light in the lighting manager at each Light entity's world position.
+func LightSystem(w *World, _ float64) {
+	for i, e := range w.Lights.entities {
+		l := &w.Lights.data[i]
+		position := w.origin.Col(3).Vec3()
+		if t := w.Transforms.Get(e); t != nil {
+			position = mgl32.TransformCoordinate(t.Position, w.origin)
+		}
+		if l.light == nil {
+			l.light = lighting.M.AddPointLight(position, l.Color, l.Intensity, l.Radius)
+			continue
+		}
+		l.light.Position = position
+		l.light.Color = l.Color
+		l.light.Intensity = l.Intensity
+		l.light.Radius = l.Radius
+	}
+}
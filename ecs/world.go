@@ -0,0 +1,184 @@
+package ecs
+
+import (
+	"github.com/go-gl/gl/v4.1-core/gl"
+	"github.com/go-gl/mathgl/mgl32"
+
+	"github.com/brandonnelson3/GoPlay/physics"
+	"github.com/brandonnelson3/GoPlay/scene"
+)
+
+// Mask selects component types for Query.
+type Mask uint32
+
+const (
+	TransformComponent Mask = 1 << iota
+	MeshRendererComponent
+	VelocityComponent
+	ColliderComponent
+	LightComponent
+	RigidBodyComponent
+	componentCount = iota
+)
+
+// System is one step of a World's tick.
+type System interface {
+	Update(w *World, elapsed float64)
+}
+
+// SystemFunc lets a plain function be used as a System.
+type SystemFunc func(w *World, elapsed float64)
+
+func (f SystemFunc) Update(w *World, elapsed float64) {
+	f(w, elapsed)
+}
+
+// World owns a set of entities, their components and the systems that run over them. It is a
+// scene.Renderable: attached to a node it ticks its systems on scene Update and draws every MeshRenderer
+// relative to the node.
+type World struct {
+	Transforms    TransformStore
+	MeshRenderers MeshRendererStore
+	Velocities    VelocityStore
+	Colliders     ColliderStore
+	Lights        LightStore
+	RigidBodies   RigidBodyStore
+
+	// Systems run in order every Update.
+	Systems []System
+
+	// Physics simulates the RigidBodies. Without it they stay where they are.
+	Physics *physics.World
+
+	// generations holds the current generation of every entity index, free the indices available for
+	// reuse.
+	generations []uint32
+	free        []uint32
+	alive       int
+
+	sets [componentCount]*sparseSet
+	// origin is the world transform of the node the World is attached to, as of the last Update.
+	origin mgl32.Mat4
+}
+
+// NewWorld returns an empty world running the movement, rigid body and light systems.
+func NewWorld() *World {
+	w := &World{
+		Systems: []System{SystemFunc(MovementSystem), SystemFunc(RigidBodySystem), SystemFunc(LightSystem)},
+		origin:  mgl32.Ident4(),
+	}
+	w.sets = [componentCount]*sparseSet{
+		&w.Transforms.sparseSet,
+		&w.MeshRenderers.sparseSet,
+		&w.Velocities.sparseSet,
+		&w.Colliders.sparseSet,
+		&w.Lights.sparseSet,
+		&w.RigidBodies.sparseSet,
+	}
+	return w
+}
+
+// Create returns a new entity with no components.
+func (w *World) Create() Entity {
+	w.alive++
+	if n := len(w.free); n > 0 {
+		index := w.free[n-1]
+		w.free = w.free[:n-1]
+		return newEntity(index, w.generations[index])
+	}
+	w.generations = append(w.generations, 0)
+	return newEntity(uint32(len(w.generations)-1), 0)
+}
+
+// Alive reports whether e has been created and not destroyed.
+func (w *World) Alive(e Entity) bool {
+	i := e.index()
+	return int(i) < len(w.generations) && w.generations[i]&generationMask == e.generation()
+}
+
+// Destroy removes e and all of its components. Its index is reused by a later Create.
+func (w *World) Destroy(e Entity) {
+	if !w.Alive(e) {
+		return
+	}
+	w.Transforms.Remove(e)
+	w.MeshRenderers.Remove(e)
+	w.Velocities.Remove(e)
+	w.Colliders.Remove(e)
+	w.Lights.Remove(e)
+	w.RigidBodies.Remove(e)
+	w.generations[e.index()]++
+	w.free = append(w.free, e.index())
+	w.alive--
+}
+
+// Len returns the number of live entities.
+func (w *World) Len() int {
+	return w.alive
+}
+
+// Query calls f for every entity that has all the components in mask. It doesn't allocate. f may change
+// component data but must not add or remove components of the queried types.
+func (w *World) Query(mask Mask, f func(e Entity)) {
+	var smallest *sparseSet
+	for i, s := range w.sets {
+		if mask&(1<<uint(i)) != 0 && (smallest == nil || s.Len() < smallest.Len()) {
+			smallest = s
+		}
+	}
+	if smallest == nil {
+		return
+	}
+	for _, e := range smallest.entities {
+		matches := true
+		for i, s := range w.sets {
+			if mask&(1<<uint(i)) != 0 && s != smallest && !s.has(e) {
+				matches = false
+				break
+			}
+		}
+		if matches {
+			f(e)
+		}
+	}
+}
+
+// Update runs every system in order. It makes World a scene.Updater.
+func (w *World) Update(n *scene.Node, elapsed float64) {
+	w.origin = n.World()
+	for _, s := range w.Systems {
+		s.Update(w, elapsed)
+	}
+}
+
+// Render draws every entity with a Transform and MeshRenderer.
+func (w *World) Render(v *scene.View, world mgl32.Mat4) {
+	for i, e := range w.MeshRenderers.entities {
+		t := w.Transforms.Get(e)
+		if t == nil {
+			continue
+		}
+		m := &w.MeshRenderers.data[i]
+		m.Shader.Activate()
+		m.Shader.SetProjection(v.Projection)
+		m.Shader.SetView(v.View)
+		m.Shader.SetModel(world.Mul4(t.Matrix()))
+		m.Texture.Bind(gl.TEXTURE0)
+		m.Mesh.Draw(gl.TRIANGLES)
+	}
+}
+
+// RenderShadow draws every MeshRenderer with a DepthShader into the shadow map.
+func (w *World) RenderShadow(lightSpace, world mgl32.Mat4) {
+	for i, e := range w.MeshRenderers.entities {
+		t := w.Transforms.Get(e)
+		m := &w.MeshRenderers.data[i]
+		if t == nil || m.DepthShader == nil {
+			continue
+		}
+		m.DepthShader.Activate()
+		m.DepthShader.SetLightSpace(lightSpace)
+		m.DepthShader.SetModel(world.Mul4(t.Matrix()))
+		m.Mesh.Draw(gl.TRIANGLES)
+	}
+}
@@ -0,0 +1,148 @@
+package ecs
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// model is what a World should hold: the transform x and velocity of every live entity that has them.
+type model struct {
+	transforms map[Entity]float32
+	velocities map[Entity]float32
+}
+
+func (m model) check(t *testing.T, w *World, step int) {
+	for e, x := range m.transforms {
+		if c := w.Transforms.Get(e); c == nil || c.Position.X() != x {
+			t.Fatalf("step %d: transform of %v is %v, want x = %v", step, e, c, x)
+		}
+	}
+	for e, x := range m.velocities {
+		if c := w.Velocities.Get(e); c == nil || c.Linear.X() != x {
+			t.Fatalf("step %d: velocity of %v is %v, want x = %v", step, e, c, x)
+		}
+	}
+	if w.Transforms.Len() != len(m.transforms) || w.Velocities.Len() != len(m.velocities) {
+		t.Fatalf("step %d: stores hold %d transforms and %d velocities, want %d and %d",
+			step, w.Transforms.Len(), w.Velocities.Len(), len(m.transforms), len(m.velocities))
+	}
+	for i, e := range w.Transforms.Entities() {
+		if w.Transforms.Data()[i] != *w.Transforms.Get(e) {
+			t.Fatalf("step %d: transform data out of step with entities at slot %d", step, i)
+		}
+	}
+
+	matched := 0
+	w.Query(TransformComponent|VelocityComponent, func(e Entity) {
+		_, hasTransform := m.transforms[e]
+		_, hasVelocity := m.velocities[e]
+		if !hasTransform || !hasVelocity {
+			t.Fatalf("step %d: query matched %v, which doesn't have both components", step, e)
+		}
+		matched++
+	})
+	want := 0
+	for e := range m.transforms {
+		if _, ok := m.velocities[e]; ok {
+			want++
+		}
+	}
+	if matched != want {
+		t.Fatalf("step %d: query matched %d entities, want %d", step, matched, want)
+	}
+}
+
+func TestStoresMatchModel(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	w := NewWorld()
+	m := model{transforms: map[Entity]float32{}, velocities: map[Entity]float32{}}
+	var alive, dead []Entity
+
+	for step := 0; step < 5000; step++ {
+		x := float32(step)
+		switch op := r.Intn(6); {
+		case op == 0 || len(alive) == 0:
+			alive = append(alive, w.Create())
+		case op == 1:
+			e := alive[r.Intn(len(alive))]
+			w.Transforms.Add(e, NewTransform(mgl32.Vec3{x, 0, 0}))
+			m.transforms[e] = x
+		case op == 2:
+			e := alive[r.Intn(len(alive))]
+			w.Velocities.Add(e, Velocity{Linear: mgl32.Vec3{x, 0, 0}})
+			m.velocities[e] = x
+		case op == 3:
+			e := alive[r.Intn(len(alive))]
+			w.Transforms.Remove(e)
+			delete(m.transforms, e)
+		case op == 4:
+			e := alive[r.Intn(len(alive))]
+			w.Velocities.Remove(e)
+			delete(m.velocities, e)
+		default:
+			i := r.Intn(len(alive))
+			e := alive[i]
+			w.Destroy(e)
+			delete(m.transforms, e)
+			delete(m.velocities, e)
+			alive = append(alive[:i], alive[i+1:]...)
+			dead = append(dead, e)
+		}
+		m.check(t, w, step)
+	}
+
+	if w.Len() != len(alive) {
+		t.Errorf("world has %d entities, want %d", w.Len(), len(alive))
+	}
+	// Destroyed handles stay dead even once their index is reused.
+	for _, e := range dead {
+		if w.Alive(e) || w.Transforms.Get(e) != nil {
+			t.Errorf("destroyed entity %v still alive", e)
+		}
+	}
+}
+
+// benchmarkWorld returns a world of 100000 entities, all with a Transform, half of them moving and a
+// tenth of those with a Collider too.
+func benchmarkWorld() *World {
+	w := NewWorld()
+	for i := 0; i < 100000; i++ {
+		e := w.Create()
+		w.Transforms.Add(e, NewTransform(mgl32.Vec3{float32(i), 0, 0}))
+		if i%2 == 0 {
+			w.Velocities.Add(e, Velocity{Linear: mgl32.Vec3{1, 0, 0}, Angular: mgl32.Vec3{0, 1, 0}})
+			if i%20 == 0 {
+				w.Colliders.Add(e, Collider{HalfExtents: mgl32.Vec3{1, 1, 1}})
+			}
+		}
+	}
+	return w
+}
+
+func BenchmarkQuery(b *testing.B) {
+	w := benchmarkWorld()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		n := 0
+		w.Query(TransformComponent|VelocityComponent|ColliderComponent, func(e Entity) {
+			n++
+		})
+		if n != 5000 {
+			b.Fatalf("query matched %d entities, want 5000", n)
+		}
+	}
+}
+
+func BenchmarkSystems(b *testing.B) {
+	w := benchmarkWorld()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, s := range w.Systems {
+			s.Update(w, 1.0/60)
+		}
+	}
+}
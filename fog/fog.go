@@ -0,0 +1,132 @@
+package fog
+
+import (
+	"math"
+	"unsafe"
+
+	"github.com/go-gl/gl/v4.1-core/gl"
+	"github.com/go-gl/glfw/v3.1/glfw"
+	"github.com/go-gl/mathgl/mgl32"
+
+	"github.com/brandonnelson3/GoPlay/camera"
+	"github.com/brandonnelson3/GoPlay/input"
+	"github.com/brandonnelson3/GoPlay/shaders"
+)
+
+// Global fog manager
+var M manager
+
+// Mode picks how fog thickens with distance. The values must match assets/shaders/fog.glsl.
+type Mode int32
+
+const (
+	Off Mode = iota
+	// Linear fades from nothing at half the fog distance to solid at the fog distance.
+	Linear
+	// Exponential thickens smoothly as exp(-(d*density)^2), reaching 99% at the fog distance.
+	Exponential
+	modeCount
+)
+
+func (m Mode) String() string {
+	switch m {
+	case Off:
+		return "off"
+	case Linear:
+		return "linear"
+	case Exponential:
+		return "exponential"
+	}
+	return "unknown"
+}
+
+// fogBlock mirrors the std140 layout of the Fog uniform block.
+type fogBlock struct {
+	color     [4]float32
+	distances [4]float32
+	height    [4]float32
+	camera    [4]float32
+	mode      [4]int32
+}
+
+var fogBlockSize = int(unsafe.Sizeof(fogBlock{}))
+
+type manager struct {
+	Mode Mode
+	// Color is what fully fogged geometry turns into. The sky keeps it matched to its horizon.
+	Color mgl32.Vec3
+	// Distance is where fog becomes solid. Zero uses AutoDistance.
+	Distance float32
+	// AutoDistance is set by whatever streams the world in, to the distance of its nearest unloaded edge.
+	AutoDistance float32
+
+	// HeightDensity adds fog that pools below HeightBase, thinning out by HeightFalloff per unit above
+	// it. Zero turns height fog off.
+	HeightDensity float32
+	HeightBase    float32
+	HeightFalloff float32
+
+	ubo   uint32
+	block fogBlock
+}
+
+func init() {
+	M = manager{
+		Mode:          Linear,
+		Color:         mgl32.Vec3{0.7, 0.8, 0.95},
+		HeightDensity: 0.02,
+		HeightBase:    0,
+		HeightFalloff: 0.15,
+	}
+	input.M.Register(glfw.KeyF5, M.cycleMode)
+}
+
+func (m *manager) cycleMode(repeat bool, _ float32) {
+	if repeat {
+		return
+	}
+	m.NextMode()
+}
+
+// NextMode switches to the next fog mode, wrapping back to Off after the last.
+func (m *manager) NextMode() {
+	m.Mode = (m.Mode + 1) % modeCount
+}
+
+// CurrentDistance returns where fog becomes solid: Distance if set, otherwise AutoDistance, falling back to
+// a long way off when nothing has set either.
+func (m *manager) CurrentDistance() float32 {
+	if m.Distance > 0 {
+		return m.Distance
+	}
+	if m.AutoDistance > 0 {
+		return m.AutoDistance
+	}
+	return 1000
+}
+
+// Update uploads the Fog uniform block. Must be called once per frame on the render thread, before
+// anything fogged is drawn.
+func (m *manager) Update() {
+	if m.ubo == 0 {
+		gl.GenBuffers(1, &m.ubo)
+		gl.BindBuffer(gl.UNIFORM_BUFFER, m.ubo)
+		gl.BufferData(gl.UNIFORM_BUFFER, fogBlockSize, nil, gl.DYNAMIC_DRAW)
+		gl.BindBufferBase(gl.UNIFORM_BUFFER, shaders.FogBlockBinding, m.ubo)
+	}
+
+	end := m.CurrentDistance()
+	// exp(-(end*density)^2) = 0.01
+	density := float32(math.Sqrt(math.Log(100))) / end
+	position := camera.C.GetPosition()
+
+	b := &m.block
+	b.color = [4]float32{m.Color[0], m.Color[1], m.Color[2], 1}
+	b.distances = [4]float32{end * 0.5, end, density, 0}
+	b.height = [4]float32{m.HeightBase, m.HeightDensity, m.HeightFalloff, 0}
+	b.camera = [4]float32{position[0], position[1], position[2], 1}
+	b.mode = [4]int32{int32(m.Mode), 0, 0, 0}
+
+	gl.BindBuffer(gl.UNIFORM_BUFFER, m.ubo)
+	gl.BufferSubData(gl.UNIFORM_BUFFER, 0, fogBlockSize, gl.Ptr(b))
+}
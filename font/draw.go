@@ -0,0 +1,156 @@
+package font
+
+import (
+	"strings"
+
+	"github.com/go-gl/gl/v4.1-core/gl"
+	"github.com/go-gl/mathgl/mgl32"
+
+	"github.com/brandonnelson3/GoPlay/assetmanager"
+	"github.com/brandonnelson3/GoPlay/shaders"
+	"github.com/brandonnelson3/GoPlay/window"
+)
+
+// Align says which side of each line lines up with the x passed to Draw.
+type Align int
+
+const (
+	AlignLeft Align = iota
+	AlignCenter
+	AlignRight
+)
+
+// DefaultSize is the pixel size of the Default font loaded by Init.
+const DefaultSize = 16
+
+// Global text manager. Text is queued from anywhere on the render thread during a frame and drawn on top
+// of it by Flush.
+var M manager
+
+type manager struct {
+	// Default is the font Init loads, for callers that don't bring their own.
+	Default *Font
+
+	// One batch per font ever drawn with, in that order. They are emptied by Flush and kept for reuse.
+	batches []*batch
+
+	shader   *shaders.TextShader
+	vbo      *shaders.VertexBuffer
+	vertices []shaders.TextShader_Vertex
+}
+
+type batch struct {
+	font     *Font
+	vertices []shaders.TextShader_Vertex
+}
+
+// Init loads the text shader and the default font. Must be called on the render thread before Flush.
+func (m *manager) Init() error {
+	var err error
+	if m.shader, err = assetmanager.M.TextShader(); err != nil {
+		return err
+	}
+	m.Default, err = Default(DefaultSize)
+	return err
+}
+
+func (m *manager) batch(f *Font) *batch {
+	for _, b := range m.batches {
+		if b.font == f {
+			return b
+		}
+	}
+	b := &batch{font: f}
+	m.batches = append(m.batches, b)
+	return b
+}
+
+// Draw writes s with f in color for the next Flush. x and y are in pixels from the top left of the window
+// and give the top of the first line, lined up horizontally as align says. Lines are split on '\n'.
+func Draw(f *Font, x, y float32, s string, color mgl32.Vec4, align Align) {
+	b := M.batch(f)
+	for _, line := range strings.Split(s, "\n") {
+		penX := x
+		switch align {
+		case AlignCenter:
+			penX -= f.lineWidth(line) / 2
+		case AlignRight:
+			penX -= f.lineWidth(line)
+		}
+		// Keep the pen on whole pixels so glyphs sample the atlas texel for texel.
+		penX = float32(int(penX + 0.5))
+		baseline := float32(int(y+0.5)) + f.Ascent
+
+		previous := rune(-1)
+		for _, r := range line {
+			g, r := f.glyph(r)
+			if previous >= 0 {
+				penX += f.kern(previous, r)
+			}
+			previous = r
+			if g.max[0] > g.min[0] {
+				b.vertices = appendQuad(b.vertices, g, penX, baseline, color)
+			}
+			penX += g.advance
+		}
+		y += f.LineHeight
+	}
+}
+
+// FillRect draws a solid rectangle w by h pixels with its top left corner at x, y. It is drawn in order
+// with the text drawn with f, so text drawn afterwards lands on top of it.
+func FillRect(f *Font, x, y, w, h float32, color mgl32.Vec4) {
+	b := M.batch(f)
+	g := glyph{max: [2]float32{w, h}, uvMin: f.white, uvMax: f.white}
+	b.vertices = appendQuad(b.vertices, g, x, y, color)
+}
+
+// appendQuad adds the two triangles of glyph g with the pen at x on baseline y.
+func appendQuad(vertices []shaders.TextShader_Vertex, g glyph, x, y float32, color mgl32.Vec4) []shaders.TextShader_Vertex {
+	corner := func(i, j int) shaders.TextShader_Vertex {
+		px := [2]float32{g.min[0], g.max[0]}[i]
+		py := [2]float32{g.min[1], g.max[1]}[j]
+		u := [2]float32{g.uvMin[0], g.uvMax[0]}[i]
+		v := [2]float32{g.uvMin[1], g.uvMax[1]}[j]
+		return shaders.TextShader_Vertex{Vert: mgl32.Vec2{x + px, y + py}, VertTexCoord: mgl32.Vec2{u, v}, VertColor: color}
+	}
+	return append(vertices, corner(0, 0), corner(0, 1), corner(1, 0), corner(1, 0), corner(0, 1), corner(1, 1))
+}
+
+// Flush draws everything queued this frame over the whole window and clears the queue.
+func (m *manager) Flush() {
+	if m.shader == nil {
+		return
+	}
+	m.vertices = m.vertices[:0]
+	for _, b := range m.batches {
+		m.vertices = append(m.vertices, b.vertices...)
+	}
+	if len(m.vertices) == 0 {
+		return
+	}
+	if m.vbo == nil {
+		m.vbo = shaders.NewVertexBuffer(shaders.TextShader_Layout, m.vertices, nil)
+	} else {
+		m.vbo.Update(m.vertices, nil)
+	}
+
+	m.shader.Activate()
+	m.shader.SetProjection(mgl32.Ortho(0, float32(window.M.Width), float32(window.M.Height), 0, -1, 1))
+	m.vbo.Activate()
+	gl.Enable(gl.BLEND)
+	gl.BlendFunc(gl.ONE, gl.ONE_MINUS_SRC_ALPHA)
+	gl.Disable(gl.DEPTH_TEST)
+	first := int32(0)
+	for _, b := range m.batches {
+		n := int32(len(b.vertices))
+		if n > 0 {
+			b.font.atlas.Bind(gl.TEXTURE0)
+			gl.DrawArrays(gl.TRIANGLES, first, n)
+		}
+		first += n
+		b.vertices = b.vertices[:0]
+	}
+	gl.Enable(gl.DEPTH_TEST)
+	gl.Disable(gl.BLEND)
+}
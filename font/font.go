@@ -0,0 +1,188 @@
+package font
+
+import (
+	"fmt"
+	"image"
+	"image/draw"
+	"strings"
+
+	xfont "golang.org/x/image/font"
+	"golang.org/x/image/font/gofont/goregular"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/math/fixed"
+
+	"github.com/brandonnelson3/GoPlay/texture"
+)
+
+// Characters rasterized into every atlas. Anything else is drawn as fallback.
+const (
+	firstRune = ' '
+	lastRune  = '~'
+	fallback  = '?'
+)
+
+// atlasWidth is the width of the glyph atlas in pixels. Its height grows to fit.
+const atlasWidth = 512
+
+// whiteSize is the side of the solid block in the atlas's top left corner that FillRect samples. Its
+// center texel is white however it is filtered.
+const whiteSize = 3
+
+// Font is a TrueType face rasterized at one size into a texture atlas.
+type Font struct {
+	// LineHeight is the distance between baselines, Ascent from the top of a line to its baseline, in
+	// pixels.
+	LineHeight, Ascent float32
+
+	face   xfont.Face
+	glyphs map[rune]glyph
+	atlas  texture.Texture
+	// white is the atlas coordinate of the center of the solid block.
+	white [2]float32
+}
+
+// glyph is where a character sits in the atlas and how to place it relative to the pen.
+type glyph struct {
+	// Pixel offsets of the glyph's top left and bottom right corners from the pen on the baseline, y down.
+	min, max [2]float32
+	// Atlas coordinates of the same corners.
+	uvMin, uvMax [2]float32
+	advance      float32
+}
+
+// Load rasterizes the TrueType or OpenType font in ttf at size pixels. Must be called on the render thread.
+func Load(ttf []byte, size float64) (*Font, error) {
+	parsed, err := opentype.Parse(ttf)
+	if err != nil {
+		return nil, err
+	}
+	face, err := opentype.NewFace(parsed, &opentype.FaceOptions{Size: size, DPI: 72, Hinting: xfont.HintingFull})
+	if err != nil {
+		return nil, err
+	}
+	metrics := face.Metrics()
+	f := &Font{
+		LineHeight: float32(metrics.Height.Ceil()),
+		Ascent:     float32(metrics.Ascent.Ceil()),
+		face:       face,
+		glyphs:     map[rune]glyph{},
+	}
+
+	// The face reuses its mask between calls, so each glyph is copied out before packing.
+	type raster struct {
+		r       rune
+		bounds  image.Rectangle
+		mask    *image.Alpha
+		advance fixed.Int26_6
+	}
+	var rasters []raster
+	for r := rune(firstRune); r <= lastRune; r++ {
+		dr, mask, maskp, advance, ok := face.Glyph(fixed.Point26_6{}, r)
+		if !ok {
+			continue
+		}
+		copied := image.NewAlpha(image.Rect(0, 0, dr.Dx(), dr.Dy()))
+		draw.Draw(copied, copied.Bounds(), mask, maskp, draw.Src)
+		rasters = append(rasters, raster{r: r, bounds: dr, mask: copied, advance: advance})
+	}
+
+	// Pack the glyphs into rows after the solid block, leaving a pixel between them so filtering doesn't
+	// bleed.
+	positions := make([]image.Point, len(rasters))
+	x, y, rowHeight := whiteSize+1, 1, whiteSize
+	for i, g := range rasters {
+		w, h := g.bounds.Dx(), g.bounds.Dy()
+		if w+2 > atlasWidth {
+			return nil, fmt.Errorf("glyph %q is wider than the atlas", g.r)
+		}
+		if x+w+1 > atlasWidth {
+			x, y, rowHeight = 1, y+rowHeight+1, 0
+		}
+		positions[i] = image.Pt(x, y)
+		x += w + 1
+		if h > rowHeight {
+			rowHeight = h
+		}
+	}
+	height := 1
+	for height < y+rowHeight+1 {
+		height *= 2
+	}
+
+	// White, with coverage in alpha, so the shader can tint it.
+	rgba := image.NewRGBA(image.Rect(0, 0, atlasWidth, height))
+	draw.Draw(rgba, image.Rect(0, 0, whiteSize, whiteSize), image.White, image.Point{}, draw.Src)
+	f.white = [2]float32{whiteSize / 2.0 / atlasWidth, whiteSize / 2.0 / float32(height)}
+	for i, g := range rasters {
+		p := positions[i]
+		dst := image.Rectangle{Min: p, Max: p.Add(g.bounds.Size())}
+		draw.DrawMask(rgba, dst, image.White, image.Point{}, g.mask, image.Point{}, draw.Over)
+		f.glyphs[g.r] = glyph{
+			min:     [2]float32{float32(g.bounds.Min.X), float32(g.bounds.Min.Y)},
+			max:     [2]float32{float32(g.bounds.Max.X), float32(g.bounds.Max.Y)},
+			uvMin:   [2]float32{float32(dst.Min.X) / atlasWidth, float32(dst.Min.Y) / float32(height)},
+			uvMax:   [2]float32{float32(dst.Max.X) / atlasWidth, float32(dst.Max.Y) / float32(height)},
+			advance: float32(g.advance.Round()),
+		}
+	}
+	if _, ok := f.glyphs[fallback]; !ok {
+		return nil, fmt.Errorf("font has no %q glyph", fallback)
+	}
+
+	img, err := texture.FromRGBA(rgba)
+	if err != nil {
+		return nil, err
+	}
+	if f.atlas, err = img.Upload(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// Default loads the Go Regular font at size pixels.
+func Default(size float64) (*Font, error) {
+	return Load(goregular.TTF, size)
+}
+
+// Delete frees the atlas. The Font must not be used afterwards.
+func (f *Font) Delete() {
+	f.atlas.Delete()
+}
+
+func (f *Font) glyph(r rune) (glyph, rune) {
+	if g, ok := f.glyphs[r]; ok {
+		return g, r
+	}
+	return f.glyphs[fallback], fallback
+}
+
+// kern is the extra space between a and b, usually negative.
+func (f *Font) kern(a, b rune) float32 {
+	return float32(f.face.Kern(a, b).Round())
+}
+
+// lineWidth is how far the pen moves drawing a single line.
+func (f *Font) lineWidth(line string) float32 {
+	var width float32
+	previous := rune(-1)
+	for _, r := range line {
+		g, r := f.glyph(r)
+		if previous >= 0 {
+			width += f.kern(previous, r)
+		}
+		width += g.advance
+		previous = r
+	}
+	return width
+}
+
+// Measure returns the width of the widest line of s and the height of all its lines, in pixels.
+func (f *Font) Measure(s string) (width, height float32) {
+	lines := strings.Split(s, "\n")
+	for _, line := range lines {
+		if w := f.lineWidth(line); w > width {
+			width = w
+		}
+	}
+	return width, float32(len(lines)) * f.LineHeight
+}
@@ -0,0 +1,134 @@
+package framebuffer
+
+import (
+	"fmt"
+
+	"github.com/go-gl/gl/v4.1-core/gl"
+
+	"github.com/brandonnelson3/GoPlay/texture"
+	"github.com/brandonnelson3/GoPlay/window"
+)
+
+// Config describes the size and attachments of a Framebuffer.
+type Config struct {
+	Width, Height int32
+	// Samples above 1 makes every attachment multisampled. Multisampled framebuffers can't be sampled, so
+	// Resolve them into a single sampled one first.
+	Samples int32
+	// Color lists the internal format of each color attachment, such as gl.RGBA16F.
+	Color []uint32
+	// Depth adds a 24 bit depth buffer.
+	Depth bool
+}
+
+// Framebuffer is an offscreen render target.
+type Framebuffer struct {
+	Config
+
+	id uint32
+	// Single sampled color attachments are textures, multisampled ones renderbuffers.
+	colors        []texture.Texture
+	renderbuffers []uint32
+	depth         uint32
+}
+
+// New allocates a framebuffer and all of its attachments.
+func New(c Config) (*Framebuffer, error) {
+	f := &Framebuffer{Config: c}
+	gl.GenFramebuffers(1, &f.id)
+	gl.BindFramebuffer(gl.FRAMEBUFFER, f.id)
+	defer gl.BindFramebuffer(gl.FRAMEBUFFER, 0)
+
+	var drawBuffers []uint32
+	for i, format := range c.Color {
+		attachment := uint32(gl.COLOR_ATTACHMENT0 + i)
+		drawBuffers = append(drawBuffers, attachment)
+		if c.Samples > 1 {
+			var rb uint32
+			gl.GenRenderbuffers(1, &rb)
+			gl.BindRenderbuffer(gl.RENDERBUFFER, rb)
+			gl.RenderbufferStorageMultisample(gl.RENDERBUFFER, c.Samples, format, c.Width, c.Height)
+			gl.FramebufferRenderbuffer(gl.FRAMEBUFFER, attachment, gl.RENDERBUFFER, rb)
+			f.renderbuffers = append(f.renderbuffers, rb)
+			continue
+		}
+		t := texture.NewRenderTarget(c.Width, c.Height, format)
+		gl.FramebufferTexture2D(gl.FRAMEBUFFER, attachment, gl.TEXTURE_2D, t.ID(), 0)
+		f.colors = append(f.colors, t)
+	}
+	if c.Depth {
+		gl.GenRenderbuffers(1, &f.depth)
+		gl.BindRenderbuffer(gl.RENDERBUFFER, f.depth)
+		if c.Samples > 1 {
+			gl.RenderbufferStorageMultisample(gl.RENDERBUFFER, c.Samples, gl.DEPTH_COMPONENT24, c.Width, c.Height)
+		} else {
+			gl.RenderbufferStorage(gl.RENDERBUFFER, gl.DEPTH_COMPONENT24, c.Width, c.Height)
+		}
+		gl.FramebufferRenderbuffer(gl.FRAMEBUFFER, gl.DEPTH_ATTACHMENT, gl.RENDERBUFFER, f.depth)
+	}
+	gl.BindRenderbuffer(gl.RENDERBUFFER, 0)
+
+	if len(drawBuffers) > 0 {
+		gl.DrawBuffers(int32(len(drawBuffers)), &drawBuffers[0])
+	} else {
+		gl.DrawBuffer(gl.NONE)
+	}
+
+	if status := gl.CheckFramebufferStatus(gl.FRAMEBUFFER); status != gl.FRAMEBUFFER_COMPLETE {
+		f.Delete()
+		return nil, fmt.Errorf("framebuffer %dx%d with %d samples is incomplete: 0x%x", c.Width, c.Height, c.Samples, status)
+	}
+	return f, nil
+}
+
+// Bind makes the framebuffer the render target and sets the viewport to cover it.
+func (f *Framebuffer) Bind() {
+	gl.BindFramebuffer(gl.FRAMEBUFFER, f.id)
+	gl.Viewport(0, 0, f.Width, f.Height)
+}
+
+// BindDefault makes the window the render target again.
+func BindDefault() {
+	gl.BindFramebuffer(gl.FRAMEBUFFER, 0)
+	gl.Viewport(0, 0, int32(window.M.Width), int32(window.M.Height))
+}
+
+// Color returns the i'th color attachment of a single sampled framebuffer.
+func (f *Framebuffer) Color(i int) texture.Texture {
+	return f.colors[i]
+}
+
+// Resolve copies the first color attachment into dst, averaging samples if this framebuffer is
+// multisampled. A nil dst is the window. Sizes must match.
+func (f *Framebuffer) Resolve(dst *Framebuffer) {
+	var dstID uint32
+	if dst != nil {
+		dstID = dst.id
+	}
+	gl.BindFramebuffer(gl.READ_FRAMEBUFFER, f.id)
+	gl.BindFramebuffer(gl.DRAW_FRAMEBUFFER, dstID)
+	gl.ReadBuffer(gl.COLOR_ATTACHMENT0)
+	gl.BlitFramebuffer(0, 0, f.Width, f.Height, 0, 0, f.Width, f.Height, gl.COLOR_BUFFER_BIT, gl.NEAREST)
+	gl.BindFramebuffer(gl.FRAMEBUFFER, 0)
+}
+
+// Matches reports whether the framebuffer was created with the given size and sample count, so callers
+// can tell when it needs to be recreated.
+func (f *Framebuffer) Matches(width, height, samples int32) bool {
+	return f != nil && f.Width == width && f.Height == height && f.Samples == samples
+}
+
+// Delete frees the framebuffer and its attachments. The Framebuffer must not be used afterwards.
+func (f *Framebuffer) Delete() {
+	for i := range f.colors {
+		f.colors[i].Delete()
+	}
+	if len(f.renderbuffers) > 0 {
+		gl.DeleteRenderbuffers(int32(len(f.renderbuffers)), &f.renderbuffers[0])
+	}
+	if f.depth != 0 {
+		gl.DeleteRenderbuffers(1, &f.depth)
+	}
+	gl.DeleteFramebuffers(1, &f.id)
+	f.colors, f.renderbuffers, f.depth, f.id = nil, nil, 0, 0
+}
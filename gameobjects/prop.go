@@ -0,0 +1,102 @@
+package gameobjects
+
+import (
+	"github.com/go-gl/gl/v4.1-core/gl"
+	"github.com/go-gl/mathgl/mgl32"
+
+	"github.com/brandonnelson3/GoPlay/assetmanager"
+	"github.com/brandonnelson3/GoPlay/scene"
+	"github.com/brandonnelson3/GoPlay/shaders"
+)
+
+// PropMesh is a textured mesh shared by any number of Props, such as a plant or a dropped item.
+type PropMesh struct {
+	vbo         *shaders.VertexBuffer
+	texture     *assetmanager.Texture
+	shader      *shaders.InstancedShader
+	depthShader *shaders.DepthShader
+
+	// single is reused by Props drawn outside of a scene batch.
+	single [1]shaders.Instance
+}
+
+// NewPropMesh uploads vertices, drawn with the texture at the asset path texturePath.
+func NewPropMesh(vertices []shaders.LitShader_Vertex, texturePath string) (*PropMesh, error) {
+	shader, err := assetmanager.M.InstancedShader()
+	if err != nil {
+		return nil, err
+	}
+	depthShader, err := assetmanager.M.InstancedDepthShader()
+	if err != nil {
+		return nil, err
+	}
+	texture, err := assetmanager.M.Texture(texturePath)
+	if err != nil {
+		return nil, err
+	}
+	return &PropMesh{
+		vbo:         shaders.NewVertexBuffer(shaders.LitShader_Layout, vertices, nil),
+		texture:     texture,
+		shader:      shader,
+		depthShader: depthShader,
+	}, nil
+}
+
+// NewCrateMesh returns a PropMesh of the crate cube.
+func NewCrateMesh() (*PropMesh, error) {
+	return NewPropMesh(cubeVertices, "crate.jpg")
+}
+
+func (m *PropMesh) render(v *scene.View, instances []shaders.Instance) {
+	m.vbo.SetInstances(shaders.Instance_Layout, instances)
+	m.shader.Activate()
+	m.shader.SetProjection(v.Projection)
+	m.shader.SetView(v.View)
+	m.texture.Bind(gl.TEXTURE0)
+	m.vbo.DrawInstanced(gl.TRIANGLES)
+}
+
+func (m *PropMesh) renderShadow(lightSpace mgl32.Mat4, instances []shaders.Instance) {
+	m.vbo.SetInstances(shaders.Instance_Layout, instances)
+	m.depthShader.Activate()
+	m.depthShader.SetLightSpace(lightSpace)
+	m.vbo.DrawInstanced(gl.TRIANGLES)
+}
+
+// Prop is one copy of a PropMesh, placed by its scene node. A scene draws all Props of the same PropMesh
+// with a single instanced draw call.
+type Prop struct {
+	Mesh *PropMesh
+	// Color tints this copy.
+	Color mgl32.Vec4
+}
+
+func NewProp(m *PropMesh) *Prop {
+	return &Prop{Mesh: m, Color: mgl32.Vec4{1, 1, 1, 1}}
+}
+
+func (p *Prop) BatchKey() interface{} {
+	return p.Mesh
+}
+
+func (p *Prop) InstanceColor() mgl32.Vec4 {
+	return p.Color
+}
+
+func (p *Prop) RenderInstances(v *scene.View, instances []shaders.Instance) {
+	p.Mesh.render(v, instances)
+}
+
+func (p *Prop) RenderShadowInstances(lightSpace mgl32.Mat4, instances []shaders.Instance) {
+	p.Mesh.renderShadow(lightSpace, instances)
+}
+
+func (p *Prop) Render(v *scene.View, world mgl32.Mat4) {
+	p.Mesh.single[0] = shaders.Instance{Model: world, Color: p.Color}
+	p.Mesh.render(v, p.Mesh.single[:])
+}
+
+func (p *Prop) RenderShadow(lightSpace, world mgl32.Mat4) {
+	p.Mesh.single[0] = shaders.Instance{Model: world, Color: p.Color}
+	p.Mesh.renderShadow(lightSpace, p.Mesh.single[:])
+}
@@ -10,14 +10,30 @@ import (
 
 const keyRange = 349
 
+const mouseButtons = int(glfw.MouseButtonLast) + 1
+
 // Global input manager
 var M manager
 
 type keyFunction func(bool, float32)
+type cursorFunction func(x, y float64)
 type manager struct {
 	down          []bool
 	downThisFrame []bool
 	functions     [][]keyFunction
+
+	cursorFunctions []cursorFunction
+
+	// Events since the last Poll, for the UI. pressed includes key repeats.
+	pressed      []bool
+	chars        []rune
+	mouseDown    [mouseButtons]bool
+	mouseClicked [mouseButtons]bool
+
+	// MouseX and MouseY are the cursor position in pixels from the top left of the window.
+	MouseX, MouseY float64
+
+	captured bool
 }
 
 func init() {
@@ -25,13 +41,20 @@ func init() {
 		down:          make([]bool, keyRange),
 		downThisFrame: make([]bool, keyRange),
 		functions:     make([][]keyFunction, keyRange),
+		pressed:       make([]bool, keyRange),
 	}
 	window.M.W.SetKeyCallback(M.keyCallBack)
+	window.M.W.SetCharCallback(M.charCallBack)
+	window.M.W.SetMouseButtonCallback(M.mouseButtonCallBack)
+	window.M.W.SetCursorPosCallback(M.cursorPosCallBack)
 
 	M.Register(glfw.KeyEscape, exit)
 }
 
 func (inputManager *manager) keyCallBack(w *glfw.Window, key glfw.Key, scancode int, action glfw.Action, mods glfw.ModifierKey) {
+	if key < 0 || int(key) >= keyRange {
+		return
+	}
 	if action == glfw.Press {
 		//log.Printf("Got key press event: %v", key)
 		inputManager.down[key] = true
@@ -42,6 +65,33 @@ func (inputManager *manager) keyCallBack(w *glfw.Window, key glfw.Key, scancode
 		inputManager.down[key] = false
 		inputManager.downThisFrame[key] = false
 	}
+	if action == glfw.Press || action == glfw.Repeat {
+		inputManager.pressed[key] = true
+	}
+}
+
+func (inputManager *manager) charCallBack(w *glfw.Window, char rune) {
+	inputManager.chars = append(inputManager.chars, char)
+}
+
+func (inputManager *manager) mouseButtonCallBack(w *glfw.Window, button glfw.MouseButton, action glfw.Action, mods glfw.ModifierKey) {
+	if button < 0 || int(button) >= mouseButtons {
+		return
+	}
+	inputManager.mouseDown[button] = action == glfw.Press
+	if action == glfw.Press {
+		inputManager.mouseClicked[button] = true
+	}
+}
+
+func (inputManager *manager) cursorPosCallBack(w *glfw.Window, x, y float64) {
+	inputManager.MouseX, inputManager.MouseY = x, y
+	if inputManager.captured {
+		return
+	}
+	for _, f := range inputManager.cursorFunctions {
+		f(x, y)
+	}
 }
 
 func exit(bool, float32) {
@@ -57,7 +107,68 @@ func (inputManager *manager) Register(key glfw.Key, f keyFunction) {
 	inputManager.functions[key] = append(inputManager.functions[key], f)
 }
 
+// RegisterCursor calls f with the cursor position whenever the mouse moves while it isn't captured.
+func (inputManager *manager) RegisterCursor(f cursorFunction) {
+	inputManager.cursorFunctions = append(inputManager.cursorFunctions, f)
+}
+
+// Capture hands the keyboard and mouse to the UI. While captured the cursor is shown and left where it is,
+// and neither key nor cursor functions run. Releasing it hides the cursor and centers it again.
+func (inputManager *manager) Capture(captured bool) {
+	if captured == inputManager.captured {
+		return
+	}
+	inputManager.captured = captured
+	if captured {
+		window.M.W.SetInputMode(glfw.CursorMode, glfw.CursorNormal)
+		return
+	}
+	window.M.W.SetInputMode(glfw.CursorMode, glfw.CursorHidden)
+	window.M.W.SetCursorPos(float64(window.M.Width)/2, float64(window.M.Height)/2)
+}
+
+func (inputManager *manager) Captured() bool {
+	return inputManager.captured
+}
+
+// Pressed reports whether key was pressed or auto repeated since the last Poll.
+func (inputManager *manager) Pressed(key glfw.Key) bool {
+	return key >= 0 && int(key) < keyRange && inputManager.pressed[key]
+}
+
+// Chars returns the text typed since the last Poll.
+func (inputManager *manager) Chars() []rune {
+	return inputManager.chars
+}
+
+func (inputManager *manager) MouseDown(button glfw.MouseButton) bool {
+	return inputManager.mouseDown[button]
+}
+
+// MouseClicked reports whether button was pressed since the last Poll.
+func (inputManager *manager) MouseClicked(button glfw.MouseButton) bool {
+	return inputManager.mouseClicked[button]
+}
+
+// Poll forgets the previous frame's events and processes new ones. Unless captured, the cursor is then
+// moved back to the center of the window so mouse look never runs into its edge.
+func (inputManager *manager) Poll() {
+	for i := range inputManager.pressed {
+		inputManager.pressed[i] = false
+	}
+	inputManager.chars = inputManager.chars[:0]
+	inputManager.mouseClicked = [mouseButtons]bool{}
+	glfw.PollEvents()
+
+	if !inputManager.captured {
+		window.M.W.SetCursorPos(float64(window.M.Width)/2, float64(window.M.Height)/2)
+	}
+}
+
 func (inputManager *manager) RunKeys(d float32) {
+	if inputManager.captured {
+		return
+	}
 	// glfw.KeySpace is the lowest key.
 	for i := glfw.KeySpace; i < keyRange; i++ {
 		if inputManager.down[i] {
@@ -0,0 +1,142 @@
+package lighting
+
+import (
+	"sync"
+	"unsafe"
+
+	"github.com/go-gl/gl/v4.1-core/gl"
+	"github.com/go-gl/mathgl/mgl32"
+
+	"github.com/brandonnelson3/GoPlay/shaders"
+)
+
+// MaxPointLights must match MAX_POINT_LIGHTS in assets/shaders/lighting.glsl.
+const MaxPointLights = 32
+
+// Global light manager
+var M manager
+
+// DirectionalLight is an infinitely distant light such as the sun.
+type DirectionalLight struct {
+	// Direction the light travels in.
+	Direction mgl32.Vec3
+	Color     mgl32.Vec3
+	Intensity float32
+}
+
+// HemisphereLight is ambient light that fades from GroundColor on downward facing surfaces to SkyColor on
+// upward facing ones.
+type HemisphereLight struct {
+	SkyColor    mgl32.Vec3
+	GroundColor mgl32.Vec3
+	Intensity   float32
+}
+
+// PointLight radiates in every direction, fading out completely at Radius. Gameplay code may move or
+// recolor it by changing its fields.
+type PointLight struct {
+	Position  mgl32.Vec3
+	Color     mgl32.Vec3
+	Intensity float32
+	Radius    float32
+}
+
+// lightBlock mirrors the std140 layout of the Lights uniform block.
+type lightBlock struct {
+	sunDirection       [4]float32
+	sunColor           [4]float32
+	ambientSkyColor    [4]float32
+	ambientGroundColor [4]float32
+	pointLightCount    [4]int32
+	pointPositions     [MaxPointLights][4]float32
+	pointColors        [MaxPointLights][4]float32
+}
+
+var lightBlockSize = int(unsafe.Sizeof(lightBlock{}))
+
+type manager struct {
+	Sun     DirectionalLight
+	Ambient HemisphereLight
+
+	mu     sync.Mutex
+	points []*PointLight
+
+	ubo   uint32
+	block lightBlock
+}
+
+func init() {
+	M = manager{
+		Sun: DirectionalLight{
+			Direction: mgl32.Vec3{-0.4, -1, -0.3}.Normalize(),
+			Color:     mgl32.Vec3{1, 0.95, 0.85},
+			Intensity: 0.8,
+		},
+		Ambient: HemisphereLight{
+			SkyColor:    mgl32.Vec3{0.55, 0.65, 0.8},
+			GroundColor: mgl32.Vec3{0.3, 0.25, 0.2},
+			Intensity:   0.4,
+		},
+	}
+}
+
+// AddPointLight adds a light to the world. Only the first MaxPointLights lights are drawn.
+func (m *manager) AddPointLight(position, color mgl32.Vec3, intensity, radius float32) *PointLight {
+	l := &PointLight{Position: position, Color: color, Intensity: intensity, Radius: radius}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.points = append(m.points, l)
+	return l
+}
+
+func (m *manager) RemovePointLight(l *PointLight) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i, p := range m.points {
+		if p == l {
+			m.points = append(m.points[:i], m.points[i+1:]...)
+			return
+		}
+	}
+}
+
+// Update uploads the current lights to the Lights uniform block. Must be called once per frame on the
+// render thread, before anything lit is drawn.
+func (m *manager) Update() {
+	if m.ubo == 0 {
+		gl.GenBuffers(1, &m.ubo)
+		gl.BindBuffer(gl.UNIFORM_BUFFER, m.ubo)
+		gl.BufferData(gl.UNIFORM_BUFFER, lightBlockSize, nil, gl.DYNAMIC_DRAW)
+		gl.BindBufferBase(gl.UNIFORM_BUFFER, shaders.LightsBlockBinding, m.ubo)
+	}
+
+	b := &m.block
+	d := m.Sun.Direction.Normalize()
+	b.sunDirection = [4]float32{d[0], d[1], d[2], 0}
+	b.sunColor = premultiply(m.Sun.Color, m.Sun.Intensity)
+	b.ambientSkyColor = premultiply(m.Ambient.SkyColor, m.Ambient.Intensity)
+	b.ambientGroundColor = premultiply(m.Ambient.GroundColor, m.Ambient.Intensity)
+
+	m.mu.Lock()
+	n := 0
+	for _, l := range m.points {
+		if n == MaxPointLights {
+			break
+		}
+		if l.Intensity <= 0 || l.Radius <= 0 {
+			continue
+		}
+		b.pointPositions[n] = [4]float32{l.Position[0], l.Position[1], l.Position[2], l.Radius}
+		b.pointColors[n] = premultiply(l.Color, l.Intensity)
+		n++
+	}
+	m.mu.Unlock()
+	b.pointLightCount[0] = int32(n)
+
+	gl.BindBuffer(gl.UNIFORM_BUFFER, m.ubo)
+	gl.BufferSubData(gl.UNIFORM_BUFFER, 0, lightBlockSize, gl.Ptr(b))
+}
+
+func premultiply(c mgl32.Vec3, intensity float32) [4]float32 {
+	return [4]float32{c[0] * intensity, c[1] * intensity, c[2] * intensity, 1}
+}
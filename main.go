@@ -1,18 +1,32 @@
 package main
 
 import (
+	"flag"
 	"fmt"
-	_ "image/jpeg"
 	"runtime"
 	"time"
 
 	"github.com/go-gl/gl/v4.1-core/gl"
 	"github.com/go-gl/glfw/v3.1/glfw"
+	"github.com/go-gl/mathgl/mgl32"
 
 	"sync/atomic"
 
+	"github.com/brandonnelson3/GoPlay/assetmanager"
 	"github.com/brandonnelson3/GoPlay/camera"
+	"github.com/brandonnelson3/GoPlay/debugdraw"
+	"github.com/brandonnelson3/GoPlay/ecs"
+	"github.com/brandonnelson3/GoPlay/fog"
+	"github.com/brandonnelson3/GoPlay/font"
+	"github.com/brandonnelson3/GoPlay/gameobjects"
 	"github.com/brandonnelson3/GoPlay/input"
+	"github.com/brandonnelson3/GoPlay/lighting"
+	"github.com/brandonnelson3/GoPlay/mesh"
+	"github.com/brandonnelson3/GoPlay/physics"
+	"github.com/brandonnelson3/GoPlay/postprocess"
+	"github.com/brandonnelson3/GoPlay/scene"
+	"github.com/brandonnelson3/GoPlay/sky"
+	"github.com/brandonnelson3/GoPlay/ui"
 	"github.com/brandonnelson3/GoPlay/voxelterrain"
 	"github.com/brandonnelson3/GoPlay/window"
 )
@@ -22,16 +36,36 @@ func init() {
 	runtime.LockOSThread()
 }
 
-var fps uint32
+// fps counts frames drawn this second, lastFPS holds the count for the previous one.
+var fps, lastFPS uint32
 
-func printFPS() {
-	//log.Printf("FPS is currently %d/second", atomic.SwapUint32(&fps, 0))
-	time.AfterFunc(1*time.Second, printFPS)
+var (
+	assetRoot = flag.String("assets", "assets", "Directory that asset paths are resolved against.")
+	hotReload = flag.Bool("hotreload", true, "Reload textures and shaders when their files change.")
+)
+
+func countFPS() {
+	atomic.StoreUint32(&lastFPS, atomic.SwapUint32(&fps, 0))
+	time.AfterFunc(1*time.Second, countFPS)
+}
+
+// drawOverlay writes the frame rate, camera position and number of loaded terrain cells in the top left
+// corner.
+func drawOverlay(cells int) {
+	p := camera.C.GetPosition()
+	s := fmt.Sprintf("%d FPS\nPosition %.1f, %.1f, %.1f\n%d cells", atomic.LoadUint32(&lastFPS), p.X(), p.Y(), p.Z(), cells)
+	font.Draw(font.M.Default, 8, 8, s, mgl32.Vec4{1, 1, 1, 1}, font.AlignLeft)
 }
 
 func main() {
+	flag.Parse()
+	assetmanager.M.Root = *assetRoot
+	if *hotReload {
+		assetmanager.M.Watch(500 * time.Millisecond)
+	}
+
 	defer glfw.Terminate()
-	go printFPS()
+	go countFPS()
 
 	version := gl.GoStr(gl.GetString(gl.VERSION))
 	fmt.Println("OpenGL version", version)
@@ -39,41 +73,108 @@ func main() {
 	// Configure global settings
 	gl.Enable(gl.DEPTH_TEST)
 	gl.DepthFunc(gl.LESS)
-	gl.ClearColor(1.0, 1.0, 1.0, 1.0)
 
-	//cube, err := gameobjects.NewCube()
-	//if err != nil {
-	//	panic(err)
-	//}
+	world := scene.New()
 
 	terrain, err := voxelterrain.NewTerrain()
 	if err != nil {
 		panic(err)
 	}
+	world.Root.AddChild(scene.NewNode("terrain", terrain))
+
+	bodies := physics.NewWorld()
+	bodies.Terrain = terrain
+
+	entities := ecs.NewWorld()
+	entities.Physics = bodies
+	world.Root.AddChild(scene.NewNode("entities", entities))
+	if _, err := gameobjects.NewCube(entities, mgl32.Vec3{0, 24, 0}); err != nil {
+		panic(err)
+	}
+
+	crate, err := mesh.NewRenderer("models/crate.obj")
+	if err != nil {
+		panic(err)
+	}
+	crateNode := scene.NewNode("crate", crate)
+	crateNode.SetPosition(mgl32.Vec3{4, 24, 0})
+	world.Root.AddChild(crateNode)
+
+	bendy, err := mesh.NewRenderer("models/bendy.gltf")
+	if err != nil {
+		panic(err)
+	}
+	// Half bend, half twist.
+	bendy.Player.Clips[1] = bendy.Clips[1]
+	bendy.Player.Weight = 0.5
+	bendyNode := scene.NewNode("bendy", bendy)
+	bendyNode.SetPosition(mgl32.Vec3{-4, 24, 0})
+	world.Root.AddChild(bendyNode)
+
+	// A field of small crates, drawn as one instanced batch.
+	crateMesh, err := gameobjects.NewCrateMesh()
+	if err != nil {
+		panic(err)
+	}
+	crates := scene.NewNode("crates", nil)
+	crates.SetPosition(mgl32.Vec3{-10, 28, 8})
+	world.Root.AddChild(crates)
+	for x := 0; x < 20; x++ {
+		for z := 0; z < 20; z++ {
+			prop := gameobjects.NewProp(crateMesh)
+			prop.Color = mgl32.Vec4{0.6 + 0.02*float32(x), 0.6 + 0.02*float32(z), 0.8, 1}
+			n := scene.NewNode("crate", prop)
+			n.SetPosition(mgl32.Vec3{float32(x), 0, float32(z)})
+			n.SetScale(mgl32.Vec3{0.25, 0.25, 0.25})
+			crates.AddChild(n)
+		}
+	}
+
+	if err := sky.M.Init(); err != nil {
+		panic(err)
+	}
+	if err := postprocess.M.Init(); err != nil {
+		panic(err)
+	}
+	if err := debugdraw.M.Init(); err != nil {
+		panic(err)
+	}
+	if err := font.M.Init(); err != nil {
+		panic(err)
+	}
+	ui.M.Init()
 
 	previousTime := glfw.GetTime()
 	gl.ClearColor(0, 0, 0, 0)
 	for !window.M.W.ShouldClose() {
-		gl.Clear(gl.COLOR_BUFFER_BIT | gl.DEPTH_BUFFER_BIT)
-
 		// Update
 		t := glfw.GetTime()
 		elapsed := t - previousTime
 		previousTime = t
 
+		assetmanager.M.Update()
 		input.M.RunKeys(float32(elapsed))
+		ui.M.Update()
 
 		camera.C.Update(elapsed)
+		bodies.Update(elapsed)
+		world.Update(elapsed)
+		sky.M.Update(elapsed)
+		lighting.M.Update()
+		fog.M.Update()
+		debugdraw.M.Update(elapsed)
 
-		//cube.Render()
-		terrain.Render()
+		world.Render(&camera.C)
+
+		cells, _, _ := terrain.Stats()
+		drawOverlay(cells)
+		drawSettings(terrain, &terrain.ShowCells)
+		font.M.Flush()
 
 		atomic.AddUint32(&fps, 1)
 
 		// Maintenance
 		window.M.W.SwapBuffers()
-		glfw.PollEvents()
-
-		window.M.W.SetCursorPos(float64(window.M.Width)/2, float64(window.M.Height)/2)
+		input.M.Poll()
 	}
 }
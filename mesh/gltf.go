@@ -0,0 +1,582 @@
+package mesh
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"net/url"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-gl/mathgl/mgl32"
+
+	"github.com/brandonnelson3/GoPlay/shaders"
+)
+
+// The subset of the glTF 2.0 JSON schema that is loaded.
+type gltfDocument struct {
+	Scene  *int `json:"scene"`
+	Scenes []struct {
+		Nodes []int `json:"nodes"`
+	} `json:"scenes"`
+	Nodes  []gltfNode `json:"nodes"`
+	Meshes []struct {
+		Name       string          `json:"name"`
+		Primitives []gltfPrimitive `json:"primitives"`
+	} `json:"meshes"`
+	Accessors   []gltfAccessor   `json:"accessors"`
+	BufferViews []gltfBufferView `json:"bufferViews"`
+	Buffers     []struct {
+		URI        string `json:"uri"`
+		ByteLength int    `json:"byteLength"`
+	} `json:"buffers"`
+	Materials []struct {
+		Name string `json:"name"`
+		PBR  struct {
+			BaseColorFactor  []float32 `json:"baseColorFactor"`
+			BaseColorTexture *struct {
+				Index int `json:"index"`
+			} `json:"baseColorTexture"`
+		} `json:"pbrMetallicRoughness"`
+	} `json:"materials"`
+	Textures []struct {
+		Source *int `json:"source"`
+	} `json:"textures"`
+	Images []struct {
+		URI        string `json:"uri"`
+		BufferView *int   `json:"bufferView"`
+	} `json:"images"`
+	Skins []struct {
+		Name                string `json:"name"`
+		InverseBindMatrices *int   `json:"inverseBindMatrices"`
+		Joints              []int  `json:"joints"`
+	} `json:"skins"`
+	Animations []gltfAnimation `json:"animations"`
+}
+
+type gltfNode struct {
+	Name        string    `json:"name"`
+	Mesh        *int      `json:"mesh"`
+	Skin        *int      `json:"skin"`
+	Children    []int     `json:"children"`
+	Matrix      []float32 `json:"matrix"`
+	Translation []float32 `json:"translation"`
+	Rotation    []float32 `json:"rotation"`
+	Scale       []float32 `json:"scale"`
+}
+
+type gltfPrimitive struct {
+	Attributes map[string]int `json:"attributes"`
+	Indices    *int           `json:"indices"`
+	Material   *int           `json:"material"`
+	Mode       *int           `json:"mode"`
+}
+
+type gltfAccessor struct {
+	BufferView    *int   `json:"bufferView"`
+	ByteOffset    int    `json:"byteOffset"`
+	ComponentType int    `json:"componentType"`
+	Normalized    bool   `json:"normalized"`
+	Count         int    `json:"count"`
+	Type          string `json:"type"`
+}
+
+type gltfBufferView struct {
+	Buffer     int `json:"buffer"`
+	ByteOffset int `json:"byteOffset"`
+	ByteLength int `json:"byteLength"`
+	ByteStride int `json:"byteStride"`
+}
+
+// Accessor component types.
+const (
+	gltfByte          = 5120
+	gltfUnsignedByte  = 5121
+	gltfShort         = 5122
+	gltfUnsignedShort = 5123
+	gltfUnsignedInt   = 5125
+	gltfFloat         = 5126
+)
+
+const gltfTriangles = 4
+
+var gltfComponents = map[string]int{"SCALAR": 1, "VEC2": 2, "VEC3": 3, "VEC4": 4, "MAT4": 16}
+
+// GLB container constants.
+const (
+	glbMagic     = 0x46546C67
+	glbChunkJSON = 0x4E4F534A
+	glbChunkBIN  = 0x004E4942
+)
+
+// gltfLoader holds a parsed document and its resolved buffers.
+type gltfLoader struct {
+	file    string
+	doc     gltfDocument
+	buffers [][]byte
+}
+
+// LoadGLTF reads a glTF 2.0 model, either JSON (.gltf) with external or data URI buffers, or binary
+// (.glb). Node transforms of the default scene are baked into the vertices, except for skinned meshes which
+// are left in bind space. Only the first skin is loaded, along with every animation of its joints.
+func LoadGLTF(file string) (*Model, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	l := &gltfLoader{file: file}
+	var bin []byte
+	if len(data) >= 12 && binary.LittleEndian.Uint32(data) == glbMagic {
+		if data, bin, err = splitGLB(data); err != nil {
+			return nil, fmt.Errorf("%v: %v", file, err)
+		}
+	}
+	if err := json.Unmarshal(data, &l.doc); err != nil {
+		return nil, fmt.Errorf("%v: %v", file, err)
+	}
+	if err := l.loadBuffers(bin); err != nil {
+		return nil, fmt.Errorf("%v: %v", file, err)
+	}
+	model, err := l.model()
+	if err != nil {
+		return nil, fmt.Errorf("%v: %v", file, err)
+	}
+	return model, nil
+}
+
+// splitGLB returns the JSON and binary chunks of a GLB container.
+func splitGLB(data []byte) (jsonChunk, bin []byte, err error) {
+	if version := binary.LittleEndian.Uint32(data[4:]); version != 2 {
+		return nil, nil, fmt.Errorf("unsupported GLB version %d", version)
+	}
+	length := int(binary.LittleEndian.Uint32(data[8:]))
+	if length > len(data) {
+		return nil, nil, fmt.Errorf("GLB is truncated")
+	}
+	for offset := 12; offset+8 <= length; {
+		size := int(binary.LittleEndian.Uint32(data[offset:]))
+		kind := binary.LittleEndian.Uint32(data[offset+4:])
+		offset += 8
+		if offset+size > length {
+			return nil, nil, fmt.Errorf("GLB chunk overruns the file")
+		}
+		switch kind {
+		case glbChunkJSON:
+			jsonChunk = data[offset : offset+size]
+		case glbChunkBIN:
+			bin = data[offset : offset+size]
+		}
+		offset += size
+	}
+	if jsonChunk == nil {
+		return nil, nil, fmt.Errorf("GLB has no JSON chunk")
+	}
+	return jsonChunk, bin, nil
+}
+
+func (l *gltfLoader) loadBuffers(bin []byte) error {
+	for i, b := range l.doc.Buffers {
+		var data []byte
+		switch {
+		case b.URI == "":
+			if i != 0 || bin == nil {
+				return fmt.Errorf("buffer %d has no data", i)
+			}
+			data = bin
+		default:
+			var err error
+			if data, err = l.readURI(b.URI); err != nil {
+				return fmt.Errorf("buffer %d: %v", i, err)
+			}
+		}
+		if len(data) < b.ByteLength {
+			return fmt.Errorf("buffer %d is %d bytes, expected %d", i, len(data), b.ByteLength)
+		}
+		l.buffers = append(l.buffers, data)
+	}
+	return nil
+}
+
+// readURI returns the contents of a data URI or of a file relative to the model.
+func (l *gltfLoader) readURI(uri string) ([]byte, error) {
+	if strings.HasPrefix(uri, "data:") {
+		comma := strings.IndexByte(uri, ',')
+		if comma < 0 || !strings.HasSuffix(uri[:comma], ";base64") {
+			return nil, fmt.Errorf("only base64 data URIs are supported")
+		}
+		return base64.StdEncoding.DecodeString(uri[comma+1:])
+	}
+	path, err := url.PathUnescape(uri)
+	if err != nil {
+		return nil, err
+	}
+	return ioutil.ReadFile(filepath.Join(filepath.Dir(l.file), path))
+}
+
+// bufferView returns the bytes a buffer view covers.
+func (l *gltfLoader) bufferView(i int) (gltfBufferView, []byte, error) {
+	if i < 0 || i >= len(l.doc.BufferViews) {
+		return gltfBufferView{}, nil, fmt.Errorf("buffer view %d doesn't exist", i)
+	}
+	v := l.doc.BufferViews[i]
+	if v.ByteOffset < 0 || v.ByteLength < 0 || v.ByteStride < 0 {
+		return v, nil, fmt.Errorf("buffer view %d has a negative offset, length or stride", i)
+	}
+	if v.Buffer < 0 || v.Buffer >= len(l.buffers) || v.ByteOffset+v.ByteLength > len(l.buffers[v.Buffer]) {
+		return v, nil, fmt.Errorf("buffer view %d is out of range", i)
+	}
+	return v, l.buffers[v.Buffer][v.ByteOffset : v.ByteOffset+v.ByteLength], nil
+}
+
+// indices reads a SCALAR accessor of unsigned integers, as used for indices.
+func (l *gltfLoader) indices(i int) ([]uint32, error) {
+	if i < 0 || i >= len(l.doc.Accessors) {
+		return nil, fmt.Errorf("accessor %d doesn't exist", i)
+	}
+	a := l.doc.Accessors[i]
+	if a.ByteOffset < 0 || a.Count < 0 {
+		return nil, fmt.Errorf("accessor %d has a negative offset or count", i)
+	}
+	if a.Type != "SCALAR" || a.BufferView == nil {
+		return nil, fmt.Errorf("accessor %d isn't usable as indices", i)
+	}
+	view, data, err := l.bufferView(*a.BufferView)
+	if err != nil {
+		return nil, err
+	}
+	var size int
+	var read func(b []byte) uint32
+	switch a.ComponentType {
+	case gltfUnsignedInt:
+		size, read = 4, binary.LittleEndian.Uint32
+	case gltfUnsignedShort:
+		size, read = 2, func(b []byte) uint32 { return uint32(binary.LittleEndian.Uint16(b)) }
+	case gltfUnsignedByte:
+		size, read = 1, func(b []byte) uint32 { return uint32(b[0]) }
+	default:
+		return nil, fmt.Errorf("accessor %d has unsupported index type %d", i, a.ComponentType)
+	}
+	stride := view.ByteStride
+	if stride == 0 {
+		stride = size
+	}
+	if a.Count > 0 && a.ByteOffset+(a.Count-1)*stride+size > len(data) {
+		return nil, fmt.Errorf("accessor %d overruns its buffer view", i)
+	}
+	out := make([]uint32, a.Count)
+	for e := range out {
+		out[e] = read(data[a.ByteOffset+e*stride:])
+	}
+	return out, nil
+}
+
+// accessor reads accessor i as floats, converting integer components and normalizing them if the accessor
+// says so. It returns the values and the number of components per element.
+func (l *gltfLoader) accessor(i int) ([]float32, int, error) {
+	if i < 0 || i >= len(l.doc.Accessors) {
+		return nil, 0, fmt.Errorf("accessor %d doesn't exist", i)
+	}
+	a := l.doc.Accessors[i]
+	if a.ByteOffset < 0 || a.Count < 0 {
+		return nil, 0, fmt.Errorf("accessor %d has a negative offset or count", i)
+	}
+	components, ok := gltfComponents[a.Type]
+	if !ok {
+		return nil, 0, fmt.Errorf("accessor %d has unsupported type %v", i, a.Type)
+	}
+	out := make([]float32, a.Count*components)
+	if a.BufferView == nil {
+		// Accessors without a buffer view are all zeros.
+		return out, components, nil
+	}
+	view, data, err := l.bufferView(*a.BufferView)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var size int
+	var read func(b []byte) float32
+	switch a.ComponentType {
+	case gltfFloat:
+		size, read = 4, func(b []byte) float32 { return math.Float32frombits(binary.LittleEndian.Uint32(b)) }
+	case gltfUnsignedInt:
+		size, read = 4, func(b []byte) float32 { return float32(binary.LittleEndian.Uint32(b)) }
+	case gltfUnsignedShort:
+		size, read = 2, func(b []byte) float32 { return float32(binary.LittleEndian.Uint16(b)) }
+		if a.Normalized {
+			size, read = 2, func(b []byte) float32 { return float32(binary.LittleEndian.Uint16(b)) / 65535 }
+		}
+	case gltfShort:
+		size, read = 2, func(b []byte) float32 { return float32(int16(binary.LittleEndian.Uint16(b))) }
+		if a.Normalized {
+			size, read = 2, func(b []byte) float32 {
+				return float32(math.Max(float64(int16(binary.LittleEndian.Uint16(b)))/32767, -1))
+			}
+		}
+	case gltfUnsignedByte:
+		size, read = 1, func(b []byte) float32 { return float32(b[0]) }
+		if a.Normalized {
+			size, read = 1, func(b []byte) float32 { return float32(b[0]) / 255 }
+		}
+	case gltfByte:
+		size, read = 1, func(b []byte) float32 { return float32(int8(b[0])) }
+		if a.Normalized {
+			size, read = 1, func(b []byte) float32 { return float32(math.Max(float64(int8(b[0]))/127, -1)) }
+		}
+	default:
+		return nil, 0, fmt.Errorf("accessor %d has unsupported component type %d", i, a.ComponentType)
+	}
+
+	stride := view.ByteStride
+	if stride == 0 {
+		stride = size * components
+	}
+	if a.Count > 0 && a.ByteOffset+(a.Count-1)*stride+size*components > len(data) {
+		return nil, 0, fmt.Errorf("accessor %d overruns its buffer view", i)
+	}
+	for e := 0; e < a.Count; e++ {
+		base := a.ByteOffset + e*stride
+		for c := 0; c < components; c++ {
+			out[e*components+c] = read(data[base+c*size:])
+		}
+	}
+	return out, components, nil
+}
+
+// nodeMatrix returns a node's transform relative to its parent.
+func nodeMatrix(n gltfNode) mgl32.Mat4 {
+	if len(n.Matrix) == 16 {
+		var m mgl32.Mat4
+		copy(m[:], n.Matrix)
+		return m
+	}
+	m := mgl32.Ident4()
+	if len(n.Translation) == 3 {
+		m = mgl32.Translate3D(n.Translation[0], n.Translation[1], n.Translation[2])
+	}
+	if len(n.Rotation) == 4 {
+		q := mgl32.Quat{W: n.Rotation[3], V: mgl32.Vec3{n.Rotation[0], n.Rotation[1], n.Rotation[2]}}
+		m = m.Mul4(q.Normalize().Mat4())
+	}
+	if len(n.Scale) == 3 {
+		m = m.Mul4(mgl32.Scale3D(n.Scale[0], n.Scale[1], n.Scale[2]))
+	}
+	return m
+}
+
+func (l *gltfLoader) model() (*Model, error) {
+	model := &Model{}
+	for i, m := range l.doc.Materials {
+		material := newMaterial(m.Name)
+		if f := m.PBR.BaseColorFactor; len(f) == 4 {
+			material.Color = mgl32.Vec4{f[0], f[1], f[2], f[3]}
+		}
+		if t := m.PBR.BaseColorTexture; t != nil {
+			if err := l.materialTexture(material, t.Index); err != nil {
+				return nil, fmt.Errorf("material %d: %v", i, err)
+			}
+		}
+		model.Materials = append(model.Materials, material)
+	}
+
+	// The skeleton comes first so skinned meshes can check their joint indices against it.
+	if len(l.doc.Skins) > 0 {
+		var err error
+		if model.Skeleton, err = l.skeleton(0); err != nil {
+			return nil, fmt.Errorf("skin 0: %v", err)
+		}
+		if model.Clips, err = l.clips(0); err != nil {
+			return nil, err
+		}
+	}
+
+	// Walk the default scene, or every node if there are no scenes.
+	var roots []int
+	switch {
+	case l.doc.Scene != nil && *l.doc.Scene < len(l.doc.Scenes):
+		roots = l.doc.Scenes[*l.doc.Scene].Nodes
+	case len(l.doc.Scenes) > 0:
+		roots = l.doc.Scenes[0].Nodes
+	default:
+		for i := range l.doc.Nodes {
+			roots = append(roots, i)
+		}
+	}
+	visited := make([]bool, len(l.doc.Nodes))
+	var walk func(i int, parent mgl32.Mat4) error
+	walk = func(i int, parent mgl32.Mat4) error {
+		if i < 0 || i >= len(l.doc.Nodes) {
+			return fmt.Errorf("node %d doesn't exist", i)
+		}
+		if visited[i] {
+			return nil
+		}
+		visited[i] = true
+		n := l.doc.Nodes[i]
+		world := parent.Mul4(nodeMatrix(n))
+		switch {
+		case n.Mesh != nil && n.Skin != nil:
+			if *n.Skin != 0 || model.Skeleton == nil {
+				return fmt.Errorf("node %d: skin %d isn't loaded, only the first skin is supported", i, *n.Skin)
+			}
+			// Skinned meshes ignore their node's transform, the joints place them.
+			if err := l.appendMesh(model, *n.Mesh, mgl32.Ident4(), true); err != nil {
+				return fmt.Errorf("node %d: %v", i, err)
+			}
+		case n.Mesh != nil:
+			if err := l.appendMesh(model, *n.Mesh, world, false); err != nil {
+				return fmt.Errorf("node %d: %v", i, err)
+			}
+		}
+		for _, c := range n.Children {
+			if err := walk(c, world); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	for _, r := range roots {
+		if err := walk(r, mgl32.Ident4()); err != nil {
+			return nil, err
+		}
+	}
+	return model, nil
+}
+
+// materialTexture points material at the image behind texture i, by path or embedded bytes.
+func (l *gltfLoader) materialTexture(material *Material, i int) error {
+	if i < 0 || i >= len(l.doc.Textures) || l.doc.Textures[i].Source == nil {
+		return fmt.Errorf("texture %d has no image", i)
+	}
+	src := *l.doc.Textures[i].Source
+	if src < 0 || src >= len(l.doc.Images) {
+		return fmt.Errorf("image %d doesn't exist", src)
+	}
+	img := l.doc.Images[src]
+	switch {
+	case img.BufferView != nil:
+		_, data, err := l.bufferView(*img.BufferView)
+		if err != nil {
+			return err
+		}
+		material.TextureData = data
+	case strings.HasPrefix(img.URI, "data:"):
+		data, err := l.readURI(img.URI)
+		if err != nil {
+			return err
+		}
+		material.TextureData = data
+	default:
+		path, err := url.PathUnescape(img.URI)
+		if err != nil {
+			return err
+		}
+		material.Texture = path
+	}
+	return nil
+}
+
+// appendMesh adds every primitive of mesh i to model, transformed by world. Skinned primitives also read
+// their joints and weights.
+func (l *gltfLoader) appendMesh(model *Model, i int, world mgl32.Mat4, skinned bool) error {
+	if i < 0 || i >= len(l.doc.Meshes) {
+		return fmt.Errorf("mesh %d doesn't exist", i)
+	}
+	normalMatrix := world.Mat3().Inv().Transpose()
+	for p, prim := range l.doc.Meshes[i].Primitives {
+		if prim.Mode != nil && *prim.Mode != gltfTriangles {
+			return fmt.Errorf("mesh %d primitive %d: only triangles are supported", i, p)
+		}
+		position, ok := prim.Attributes["POSITION"]
+		if !ok {
+			return fmt.Errorf("mesh %d primitive %d has no positions", i, p)
+		}
+		positions, n, err := l.accessor(position)
+		if err != nil {
+			return err
+		}
+		if n != 3 {
+			return fmt.Errorf("mesh %d primitive %d positions aren't VEC3", i, p)
+		}
+		count := len(positions) / 3
+
+		var normals, uvs []float32
+		if a, ok := prim.Attributes["NORMAL"]; ok {
+			if normals, n, err = l.accessor(a); err != nil {
+				return err
+			}
+			if n != 3 || len(normals) != count*3 {
+				return fmt.Errorf("mesh %d primitive %d normals don't match its positions", i, p)
+			}
+		}
+		if a, ok := prim.Attributes["TEXCOORD_0"]; ok {
+			if uvs, n, err = l.accessor(a); err != nil {
+				return err
+			}
+			if n != 2 || len(uvs) != count*2 {
+				return fmt.Errorf("mesh %d primitive %d texture coordinates don't match its positions", i, p)
+			}
+		}
+
+		m := &Mesh{Name: l.doc.Meshes[i].Name, Vertices: make([]shaders.LitShader_Vertex, count)}
+		for v := range m.Vertices {
+			pos := mgl32.Vec3{positions[v*3], positions[v*3+1], positions[v*3+2]}
+			m.Vertices[v].Vert = mgl32.TransformCoordinate(pos, world)
+			if normals != nil {
+				normal := mgl32.Vec3{normals[v*3], normals[v*3+1], normals[v*3+2]}
+				m.Vertices[v].VertNormal = normalMatrix.Mul3x1(normal).Normalize()
+			}
+			if uvs != nil {
+				// glTF puts the texture origin at the top left, GL at the bottom left.
+				m.Vertices[v].VertTexCoord = mgl32.Vec2{uvs[v*2], 1 - uvs[v*2+1]}
+			}
+		}
+
+		if prim.Indices != nil {
+			if m.Indices, err = l.indices(*prim.Indices); err != nil {
+				return err
+			}
+			for _, index := range m.Indices {
+				if int(index) >= count {
+					return fmt.Errorf("mesh %d primitive %d index %d is out of range", i, p, index)
+				}
+			}
+		} else {
+			m.Indices = make([]uint32, count)
+			for j := range m.Indices {
+				m.Indices[j] = uint32(j)
+			}
+		}
+		// A mirroring transform turns triangles inside out.
+		if world.Det() < 0 {
+			for j := 0; j+2 < len(m.Indices); j += 3 {
+				m.Indices[j+1], m.Indices[j+2] = m.Indices[j+2], m.Indices[j+1]
+			}
+		}
+
+		if skinned {
+			if err := l.skinAttributes(m, prim, len(model.Skeleton.Joints)); err != nil {
+				return fmt.Errorf("mesh %d primitive %d: %v", i, p, err)
+			}
+		}
+
+		if prim.Material != nil {
+			if *prim.Material < 0 || *prim.Material >= len(model.Materials) {
+				return fmt.Errorf("mesh %d primitive %d material %d doesn't exist", i, p, *prim.Material)
+			}
+			m.Material = model.Materials[*prim.Material]
+		}
+		if normals == nil {
+			missing := make([]bool, count)
+			for j := range missing {
+				missing[j] = true
+			}
+			computeNormals(m, missing)
+		}
+		model.Meshes = append(model.Meshes, m)
+	}
+	return nil
+}
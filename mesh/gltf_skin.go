@@ -0,0 +1,246 @@
+package mesh
+
+import (
+	"fmt"
+
+	"github.com/go-gl/mathgl/mgl32"
+
+	"github.com/brandonnelson3/GoPlay/animation"
+)
+
+type gltfAnimation struct {
+	Name     string `json:"name"`
+	Channels []struct {
+		Sampler int `json:"sampler"`
+		Target  struct {
+			Node *int   `json:"node"`
+			Path string `json:"path"`
+		} `json:"target"`
+	} `json:"channels"`
+	Samplers []struct {
+		Input         int    `json:"input"`
+		Output        int    `json:"output"`
+		Interpolation string `json:"interpolation"`
+	} `json:"samplers"`
+}
+
+var gltfPaths = map[string]animation.Path{
+	"translation": animation.Translation,
+	"rotation":    animation.Rotation,
+	"scale":       animation.Scale,
+}
+
+var gltfInterpolations = map[string]animation.Interpolation{
+	"":            animation.Linear,
+	"LINEAR":      animation.Linear,
+	"STEP":        animation.Step,
+	"CUBICSPLINE": animation.CubicSpline,
+}
+
+// nodeTransform returns a node's transform relative to its parent as separate parts, decomposing its
+// matrix if it has one.
+func nodeTransform(n gltfNode) animation.Transform {
+	t := animation.Identity()
+	if len(n.Matrix) == 16 {
+		m := nodeMatrix(n)
+		t.Translation = m.Col(3).Vec3()
+		t.Scale = mgl32.Vec3{m.Col(0).Vec3().Len(), m.Col(1).Vec3().Len(), m.Col(2).Vec3().Len()}
+		rotation := mgl32.Ident3()
+		for c := 0; c < 3; c++ {
+			if t.Scale[c] != 0 {
+				rotation.SetCol(c, m.Col(c).Vec3().Mul(1/t.Scale[c]))
+			}
+		}
+		t.Rotation = mgl32.Mat4ToQuat(rotation.Mat4())
+		return t
+	}
+	if len(n.Translation) == 3 {
+		t.Translation = mgl32.Vec3{n.Translation[0], n.Translation[1], n.Translation[2]}
+	}
+	if len(n.Rotation) == 4 {
+		t.Rotation = mgl32.Quat{W: n.Rotation[3], V: mgl32.Vec3{n.Rotation[0], n.Rotation[1], n.Rotation[2]}}.Normalize()
+	}
+	if len(n.Scale) == 3 {
+		t.Scale = mgl32.Vec3{n.Scale[0], n.Scale[1], n.Scale[2]}
+	}
+	return t
+}
+
+// nodeParents returns the parent of every node, -1 for roots.
+func (l *gltfLoader) nodeParents() []int {
+	parents := make([]int, len(l.doc.Nodes))
+	for i := range parents {
+		parents[i] = -1
+	}
+	for i, n := range l.doc.Nodes {
+		for _, c := range n.Children {
+			if c >= 0 && c < len(parents) {
+				parents[c] = i
+			}
+		}
+	}
+	return parents
+}
+
+// jointIndices maps the nodes of skin i to their joint index.
+func (l *gltfLoader) jointIndices(i int) map[int]int {
+	joints := map[int]int{}
+	for j, node := range l.doc.Skins[i].Joints {
+		joints[node] = j
+	}
+	return joints
+}
+
+// skeleton builds the skeleton of skin i. Each joint's parent is its nearest ancestor node that is also a
+// joint, and the transform of the nodes above the root joint becomes the skeleton's Root.
+func (l *gltfLoader) skeleton(i int) (*animation.Skeleton, error) {
+	skin := l.doc.Skins[i]
+	if len(skin.Joints) == 0 {
+		return nil, fmt.Errorf("skin has no joints")
+	}
+	var inverseBind []float32
+	if skin.InverseBindMatrices != nil {
+		var n int
+		var err error
+		if inverseBind, n, err = l.accessor(*skin.InverseBindMatrices); err != nil {
+			return nil, err
+		}
+		if n != 16 || len(inverseBind) != len(skin.Joints)*16 {
+			return nil, fmt.Errorf("inverse bind matrices don't match the joints")
+		}
+	}
+
+	parents := l.nodeParents()
+	joints := l.jointIndices(i)
+	s := &animation.Skeleton{Root: mgl32.Ident4()}
+	rootFound := false
+	for j, node := range skin.Joints {
+		if node < 0 || node >= len(l.doc.Nodes) {
+			return nil, fmt.Errorf("joint %d node %d doesn't exist", j, node)
+		}
+		n := l.doc.Nodes[node]
+		joint := animation.Joint{Name: n.Name, Parent: -1, Rest: nodeTransform(n), InverseBind: mgl32.Ident4()}
+		if inverseBind != nil {
+			copy(joint.InverseBind[:], inverseBind[j*16:])
+		}
+		p := parents[node]
+		for ; p >= 0; p = parents[p] {
+			if pj, ok := joints[p]; ok {
+				joint.Parent = pj
+				break
+			}
+		}
+		if joint.Parent < 0 && !rootFound {
+			// Root joints are assumed to share their ancestors, the first one's are used.
+			rootFound = true
+			for a := parents[node]; a >= 0; a = parents[a] {
+				s.Root = nodeMatrix(l.doc.Nodes[a]).Mul4(s.Root)
+			}
+		}
+		s.Joints = append(s.Joints, joint)
+	}
+	return s, nil
+}
+
+// clips reads every animation that moves the joints of skin i. Channels animating other nodes are ignored,
+// as are animations left with no channels.
+func (l *gltfLoader) clips(i int) ([]*animation.Clip, error) {
+	joints := l.jointIndices(i)
+	var clips []*animation.Clip
+	for a, anim := range l.doc.Animations {
+		clip := &animation.Clip{Name: anim.Name}
+		for c, ch := range anim.Channels {
+			path, ok := gltfPaths[ch.Target.Path]
+			if !ok || ch.Target.Node == nil {
+				continue
+			}
+			joint, ok := joints[*ch.Target.Node]
+			if !ok {
+				continue
+			}
+			if ch.Sampler < 0 || ch.Sampler >= len(anim.Samplers) {
+				return nil, fmt.Errorf("animation %d channel %d sampler %d doesn't exist", a, c, ch.Sampler)
+			}
+			sampler := anim.Samplers[ch.Sampler]
+			interpolation, ok := gltfInterpolations[sampler.Interpolation]
+			if !ok {
+				return nil, fmt.Errorf("animation %d has unknown interpolation %v", a, sampler.Interpolation)
+			}
+			times, n, err := l.accessor(sampler.Input)
+			if err != nil {
+				return nil, err
+			}
+			if n != 1 || len(times) == 0 {
+				return nil, fmt.Errorf("animation %d channel %d has no keyframe times", a, c)
+			}
+			values, n, err := l.accessor(sampler.Output)
+			if err != nil {
+				return nil, err
+			}
+			channel := animation.Channel{Joint: joint, Path: path, Interpolation: interpolation, Times: times, Values: values}
+			want := len(times) * n
+			if interpolation == animation.CubicSpline {
+				want *= 3
+			}
+			if (path == animation.Rotation) != (n == 4) || len(values) != want {
+				return nil, fmt.Errorf("animation %d channel %d values don't match its keyframes", a, c)
+			}
+			if end := times[len(times)-1]; end > clip.Duration {
+				clip.Duration = end
+			}
+			clip.Channels = append(clip.Channels, channel)
+		}
+		if len(clip.Channels) > 0 {
+			clips = append(clips, clip)
+		}
+	}
+	return clips, nil
+}
+
+// skinAttributes reads the joints and weights of a skinned primitive into m, which already has its
+// vertices.
+func (l *gltfLoader) skinAttributes(m *Mesh, prim gltfPrimitive, jointCount int) error {
+	ja, ok := prim.Attributes["JOINTS_0"]
+	wa, ok2 := prim.Attributes["WEIGHTS_0"]
+	if !ok || !ok2 {
+		return fmt.Errorf("skinned primitive has no joints or weights")
+	}
+	joints, n, err := l.accessor(ja)
+	if err != nil {
+		return err
+	}
+	if n != 4 || len(joints) != len(m.Vertices)*4 {
+		return fmt.Errorf("joints don't match the positions")
+	}
+	weights, n, err := l.accessor(wa)
+	if err != nil {
+		return err
+	}
+	if n != 4 || len(weights) != len(m.Vertices)*4 {
+		return fmt.Errorf("weights don't match the positions")
+	}
+
+	m.Joints = make([][4]uint16, len(m.Vertices))
+	m.Weights = make([]mgl32.Vec4, len(m.Vertices))
+	for v := range m.Vertices {
+		var sum float32
+		for c := 0; c < 4; c++ {
+			j, w := joints[v*4+c], weights[v*4+c]
+			if w != 0 && int(j) >= jointCount {
+				return fmt.Errorf("vertex %d uses joint %d of %d", v, int(j), jointCount)
+			}
+			if w != 0 {
+				m.Joints[v][c] = uint16(j)
+			}
+			m.Weights[v][c] = w
+			sum += w
+		}
+		// Weights should add up to one, exporters don't always quite manage it.
+		if sum > 0 {
+			m.Weights[v] = m.Weights[v].Mul(1 / sum)
+		} else {
+			m.Weights[v] = mgl32.Vec4{1, 0, 0, 0}
+		}
+	}
+	return nil
+}
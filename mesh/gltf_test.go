@@ -0,0 +1,443 @@
+package mesh
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// le packs values little endian, as glTF buffers are.
+func le(values ...interface{}) []byte {
+	var b bytes.Buffer
+	for _, v := range values {
+		binary.Write(&b, binary.LittleEndian, v)
+	}
+	return b.Bytes()
+}
+
+func intp(i int) *int { return &i }
+
+// accessorLoader returns a loader whose accessor 0 reads buffer through a single buffer view.
+func accessorLoader(buffer []byte, view gltfBufferView, a gltfAccessor) *gltfLoader {
+	l := &gltfLoader{file: "test.gltf", buffers: [][]byte{buffer}}
+	l.doc.BufferViews = []gltfBufferView{view}
+	l.doc.Accessors = []gltfAccessor{a}
+	return l
+}
+
+func TestGLTFAccessor(t *testing.T) {
+	for _, tc := range []struct {
+		name       string
+		buffer     []byte
+		view       gltfBufferView
+		accessor   gltfAccessor
+		components int
+		want       []float32
+		err        string
+	}{
+		{
+			name:       "float VEC2",
+			buffer:     le(float32(1), float32(2), float32(3), float32(4)),
+			view:       gltfBufferView{ByteLength: 16},
+			accessor:   gltfAccessor{BufferView: intp(0), ComponentType: gltfFloat, Count: 2, Type: "VEC2"},
+			components: 2,
+			want:       []float32{1, 2, 3, 4},
+		},
+		{
+			name: "interleaved float VEC3 with offsets",
+			// Two vertices of a position and a 4 byte color, reading the positions of the view from byte 4.
+			buffer:     le(uint32(0), float32(1), float32(2), float32(3), uint32(0), float32(4), float32(5), float32(6), uint32(0)),
+			view:       gltfBufferView{ByteOffset: 4, ByteLength: 32, ByteStride: 16},
+			accessor:   gltfAccessor{BufferView: intp(0), ComponentType: gltfFloat, Count: 2, Type: "VEC3"},
+			components: 3,
+			want:       []float32{1, 2, 3, 4, 5, 6},
+		},
+		{
+			name:       "accessor offset into a strided view",
+			buffer:     le(uint16(1), uint16(2), uint16(3), uint16(4), uint16(5), uint16(6)),
+			view:       gltfBufferView{ByteLength: 12, ByteStride: 4},
+			accessor:   gltfAccessor{BufferView: intp(0), ByteOffset: 2, ComponentType: gltfUnsignedShort, Count: 3, Type: "SCALAR"},
+			components: 1,
+			want:       []float32{2, 4, 6},
+		},
+		{
+			name:       "unsigned byte",
+			buffer:     le(uint8(0), uint8(200), uint8(255)),
+			view:       gltfBufferView{ByteLength: 3},
+			accessor:   gltfAccessor{BufferView: intp(0), ComponentType: gltfUnsignedByte, Count: 3, Type: "SCALAR"},
+			components: 1,
+			want:       []float32{0, 200, 255},
+		},
+		{
+			name:       "normalized unsigned byte",
+			buffer:     le(uint8(0), uint8(51), uint8(255), uint8(0)),
+			view:       gltfBufferView{ByteLength: 4},
+			accessor:   gltfAccessor{BufferView: intp(0), ComponentType: gltfUnsignedByte, Normalized: true, Count: 1, Type: "VEC4"},
+			components: 4,
+			want:       []float32{0, 0.2, 1, 0},
+		},
+		{
+			name:       "normalized byte clamps -128",
+			buffer:     le(int8(-128), int8(-127), int8(0), int8(127)),
+			view:       gltfBufferView{ByteLength: 4},
+			accessor:   gltfAccessor{BufferView: intp(0), ComponentType: gltfByte, Normalized: true, Count: 4, Type: "SCALAR"},
+			components: 1,
+			want:       []float32{-1, -1, 0, 1},
+		},
+		{
+			name:       "normalized unsigned short",
+			buffer:     le(uint16(0), uint16(65535)),
+			view:       gltfBufferView{ByteLength: 4},
+			accessor:   gltfAccessor{BufferView: intp(0), ComponentType: gltfUnsignedShort, Normalized: true, Count: 1, Type: "VEC2"},
+			components: 2,
+			want:       []float32{0, 1},
+		},
+		{
+			name:       "normalized short clamps -32768",
+			buffer:     le(int16(-32768), int16(-32767), int16(32767)),
+			view:       gltfBufferView{ByteLength: 6},
+			accessor:   gltfAccessor{BufferView: intp(0), ComponentType: gltfShort, Normalized: true, Count: 3, Type: "SCALAR"},
+			components: 1,
+			want:       []float32{-1, -1, 1},
+		},
+		{
+			name:       "short",
+			buffer:     le(int16(-300), int16(300)),
+			view:       gltfBufferView{ByteLength: 4},
+			accessor:   gltfAccessor{BufferView: intp(0), ComponentType: gltfShort, Count: 2, Type: "SCALAR"},
+			components: 1,
+			want:       []float32{-300, 300},
+		},
+		{
+			name:       "no buffer view is zeros",
+			accessor:   gltfAccessor{ComponentType: gltfFloat, Count: 2, Type: "VEC2"},
+			components: 2,
+			want:       []float32{0, 0, 0, 0},
+		},
+		{
+			name:     "overruns the view",
+			buffer:   le(float32(1), float32(2), float32(3), float32(4)),
+			view:     gltfBufferView{ByteLength: 12},
+			accessor: gltfAccessor{BufferView: intp(0), ComponentType: gltfFloat, Count: 2, Type: "VEC2"},
+			err:      "accessor 0 overruns its buffer view",
+		},
+		{
+			name:     "stride overruns the view",
+			buffer:   le(float32(1), float32(2), float32(3), float32(4)),
+			view:     gltfBufferView{ByteLength: 16, ByteStride: 12},
+			accessor: gltfAccessor{BufferView: intp(0), ComponentType: gltfFloat, Count: 2, Type: "VEC2"},
+			err:      "accessor 0 overruns its buffer view",
+		},
+		{
+			name:     "view overruns the buffer",
+			buffer:   le(float32(1), float32(2)),
+			view:     gltfBufferView{ByteOffset: 4, ByteLength: 8},
+			accessor: gltfAccessor{BufferView: intp(0), ComponentType: gltfFloat, Count: 1, Type: "SCALAR"},
+			err:      "buffer view 0 is out of range",
+		},
+		{
+			name:     "view of a missing buffer",
+			buffer:   le(float32(1)),
+			view:     gltfBufferView{Buffer: 1, ByteLength: 4},
+			accessor: gltfAccessor{BufferView: intp(0), ComponentType: gltfFloat, Count: 1, Type: "SCALAR"},
+			err:      "buffer view 0 is out of range",
+		},
+		{
+			name:     "missing view",
+			buffer:   le(float32(1)),
+			view:     gltfBufferView{ByteLength: 4},
+			accessor: gltfAccessor{BufferView: intp(1), ComponentType: gltfFloat, Count: 1, Type: "SCALAR"},
+			err:      "buffer view 1 doesn't exist",
+		},
+		{
+			name:     "negative accessor offset",
+			buffer:   le(float32(1), float32(2)),
+			view:     gltfBufferView{ByteOffset: 4, ByteLength: 4},
+			accessor: gltfAccessor{BufferView: intp(0), ByteOffset: -4, ComponentType: gltfFloat, Count: 1, Type: "SCALAR"},
+			err:      "accessor 0 has a negative offset or count",
+		},
+		{
+			name:     "negative count",
+			accessor: gltfAccessor{ComponentType: gltfFloat, Count: -1, Type: "SCALAR"},
+			err:      "accessor 0 has a negative offset or count",
+		},
+		{
+			name:     "negative view offset",
+			buffer:   le(float32(1), float32(2)),
+			view:     gltfBufferView{ByteOffset: -4, ByteLength: 8},
+			accessor: gltfAccessor{BufferView: intp(0), ComponentType: gltfFloat, Count: 1, Type: "SCALAR"},
+			err:      "buffer view 0 has a negative offset, length or stride",
+		},
+		{
+			name:     "negative view stride",
+			buffer:   le(float32(1), float32(2)),
+			view:     gltfBufferView{ByteLength: 8, ByteStride: -4},
+			accessor: gltfAccessor{BufferView: intp(0), ComponentType: gltfFloat, Count: 2, Type: "SCALAR"},
+			err:      "buffer view 0 has a negative offset, length or stride",
+		},
+		{
+			name:     "unsupported type",
+			accessor: gltfAccessor{ComponentType: gltfFloat, Count: 1, Type: "MAT3"},
+			err:      "accessor 0 has unsupported type MAT3",
+		},
+		{
+			name:     "unsupported component type",
+			buffer:   le(float64(1)),
+			view:     gltfBufferView{ByteLength: 8},
+			accessor: gltfAccessor{BufferView: intp(0), ComponentType: 5130, Count: 1, Type: "SCALAR"},
+			err:      "accessor 0 has unsupported component type 5130",
+		},
+	} {
+		got, components, err := accessorLoader(tc.buffer, tc.view, tc.accessor).accessor(0)
+		if tc.err != "" {
+			if err == nil || err.Error() != tc.err {
+				t.Errorf("%s: got error %v, want %q", tc.name, err, tc.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", tc.name, err)
+			continue
+		}
+		if components != tc.components || !equalFloats(got, tc.want) {
+			t.Errorf("%s: got %v with %d components, want %v with %d", tc.name, got, components, tc.want, tc.components)
+		}
+	}
+}
+
+func TestGLTFIndices(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		buffer   []byte
+		view     gltfBufferView
+		accessor gltfAccessor
+		want     []uint32
+		err      string
+	}{
+		{
+			name:     "unsigned byte",
+			buffer:   le(uint8(0), uint8(1), uint8(255)),
+			view:     gltfBufferView{ByteLength: 3},
+			accessor: gltfAccessor{BufferView: intp(0), ComponentType: gltfUnsignedByte, Count: 3, Type: "SCALAR"},
+			want:     []uint32{0, 1, 255},
+		},
+		{
+			name:     "unsigned short",
+			buffer:   le(uint16(0), uint16(1), uint16(65535)),
+			view:     gltfBufferView{ByteLength: 6},
+			accessor: gltfAccessor{BufferView: intp(0), ComponentType: gltfUnsignedShort, Count: 3, Type: "SCALAR"},
+			want:     []uint32{0, 1, 65535},
+		},
+		{
+			name:     "unsigned int from an offset",
+			buffer:   le(uint32(9), uint32(0), uint32(70000)),
+			view:     gltfBufferView{ByteLength: 12},
+			accessor: gltfAccessor{BufferView: intp(0), ByteOffset: 4, ComponentType: gltfUnsignedInt, Count: 2, Type: "SCALAR"},
+			want:     []uint32{0, 70000},
+		},
+		{
+			name:     "strided unsigned short",
+			buffer:   le(uint16(1), uint16(9), uint16(2), uint16(9), uint16(3)),
+			view:     gltfBufferView{ByteLength: 10, ByteStride: 4},
+			accessor: gltfAccessor{BufferView: intp(0), ComponentType: gltfUnsignedShort, Count: 3, Type: "SCALAR"},
+			want:     []uint32{1, 2, 3},
+		},
+		{
+			name:     "overruns the view",
+			buffer:   le(uint16(1), uint16(2)),
+			view:     gltfBufferView{ByteLength: 4},
+			accessor: gltfAccessor{BufferView: intp(0), ComponentType: gltfUnsignedShort, Count: 3, Type: "SCALAR"},
+			err:      "accessor 0 overruns its buffer view",
+		},
+		{
+			name:     "negative offset",
+			buffer:   le(uint16(1), uint16(2)),
+			view:     gltfBufferView{ByteOffset: 2, ByteLength: 2},
+			accessor: gltfAccessor{BufferView: intp(0), ByteOffset: -2, ComponentType: gltfUnsignedShort, Count: 1, Type: "SCALAR"},
+			err:      "accessor 0 has a negative offset or count",
+		},
+		{
+			name:     "negative view offset",
+			buffer:   le(uint16(1), uint16(2)),
+			view:     gltfBufferView{ByteOffset: -2, ByteLength: 4},
+			accessor: gltfAccessor{BufferView: intp(0), ComponentType: gltfUnsignedShort, Count: 1, Type: "SCALAR"},
+			err:      "buffer view 0 has a negative offset, length or stride",
+		},
+		{
+			name:     "not scalar",
+			buffer:   le(uint16(1), uint16(2)),
+			view:     gltfBufferView{ByteLength: 4},
+			accessor: gltfAccessor{BufferView: intp(0), ComponentType: gltfUnsignedShort, Count: 1, Type: "VEC2"},
+			err:      "accessor 0 isn't usable as indices",
+		},
+		{
+			name:     "signed",
+			buffer:   le(int16(1)),
+			view:     gltfBufferView{ByteLength: 2},
+			accessor: gltfAccessor{BufferView: intp(0), ComponentType: gltfShort, Count: 1, Type: "SCALAR"},
+			err:      "accessor 0 has unsupported index type 5122",
+		},
+	} {
+		got, err := accessorLoader(tc.buffer, tc.view, tc.accessor).indices(0)
+		if tc.err != "" {
+			if err == nil || err.Error() != tc.err {
+				t.Errorf("%s: got error %v, want %q", tc.name, err, tc.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", tc.name, err)
+			continue
+		}
+		if !equalIndices(got, tc.want) {
+			t.Errorf("%s: got %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestLoadGLTF(t *testing.T) {
+	model, err := Load("../assets/models/triangle.gltf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(model.Meshes) != 1 || len(model.Materials) != 1 {
+		t.Fatalf("got %d meshes and %d materials, want 1 of each", len(model.Meshes), len(model.Materials))
+	}
+	m := model.Meshes[0]
+	if m.Name != "triangle" || m.Material != model.Materials[0] || !equalIndices(m.Indices, []uint32{0, 1, 2}) {
+		t.Errorf("got mesh %q with material %v and indices %v", m.Name, m.Material, m.Indices)
+	}
+	if c := model.Materials[0].Color; c != (mgl32.Vec4{1, 0.2, 0.2, 1}) {
+		t.Errorf("material color is %v, want red", c)
+	}
+	checkTriangle(t, m)
+}
+
+// glb packs a JSON and an optional binary chunk into a GLB container, padding them to 4 bytes.
+func glb(json string, bin []byte) []byte {
+	chunks := le(uint32(len(json)+3)&^3, uint32(glbChunkJSON), []byte(json+strings.Repeat(" ", -len(json)&3)))
+	if bin != nil {
+		chunks = append(chunks, le(uint32(len(bin)+3)&^3, uint32(glbChunkBIN), bin, make([]byte, -len(bin)&3))...)
+	}
+	return append(le(uint32(glbMagic), uint32(2), uint32(12+len(chunks))), chunks...)
+}
+
+const glbTriangle = `{
+	"meshes": [{"primitives": [{"attributes": {"POSITION": 0, "NORMAL": 1, "TEXCOORD_0": 2}, "indices": 3}]}],
+	"nodes": [{"mesh": 0}],
+	"buffers": [{"byteLength": 102}],
+	"bufferViews": [
+		{"buffer": 0, "byteLength": 96, "byteStride": 32},
+		{"buffer": 0, "byteOffset": 96, "byteLength": 6}
+	],
+	"accessors": [
+		{"bufferView": 0, "componentType": 5126, "count": 3, "type": "VEC3"},
+		{"bufferView": 0, "byteOffset": 12, "componentType": 5126, "count": 3, "type": "VEC3"},
+		{"bufferView": 0, "byteOffset": 24, "componentType": 5126, "count": 3, "type": "VEC2"},
+		{"bufferView": 1, "componentType": 5123, "count": 3, "type": "SCALAR"}
+	]
+}`
+
+// glbTriangleBin is the same triangle as triangle.gltf, interleaved. glTF texture coordinates start at the
+// top left, so V is flipped on load.
+var glbTriangleBin = le(
+	[]float32{0, 0, 0, 0, 0, 1, 0, 1},
+	[]float32{1, 0, 0, 0, 0, 1, 1, 1},
+	[]float32{0, 1, 0, 0, 0, 1, 0, 0},
+	[]uint16{0, 1, 2},
+)
+
+func checkTriangle(t *testing.T, m *Mesh) {
+	want := [][3]mgl32.Vec3{
+		{{0, 0, 0}, {0, 0, 1}, {0, 0, 0}},
+		{{1, 0, 0}, {0, 0, 1}, {1, 0, 0}},
+		{{0, 1, 0}, {0, 0, 1}, {0, 1, 0}},
+	}
+	if len(m.Vertices) != len(want) {
+		t.Fatalf("got %d vertices, want %d", len(m.Vertices), len(want))
+	}
+	for i, v := range m.Vertices {
+		uv := mgl32.Vec3{v.VertTexCoord[0], v.VertTexCoord[1], 0}
+		if v.Vert != want[i][0] || v.VertNormal != want[i][1] || uv != want[i][2] {
+			t.Errorf("vertex %d is %v, want position, normal and uv %v", i, v, want[i])
+		}
+	}
+}
+
+func TestLoadGLB(t *testing.T) {
+	dir, err := ioutil.TempDir("", "glb")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "triangle.glb")
+	if err := ioutil.WriteFile(file, glb(glbTriangle, glbTriangleBin), 0644); err != nil {
+		t.Fatal(err)
+	}
+	model, err := Load(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(model.Meshes) != 1 || !equalIndices(model.Meshes[0].Indices, []uint32{0, 1, 2}) {
+		t.Fatalf("got %d meshes, want the triangle", len(model.Meshes))
+	}
+	checkTriangle(t, model.Meshes[0])
+
+	// The document's buffer needs the binary chunk.
+	if err := ioutil.WriteFile(file, glb(glbTriangle, nil), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(file); err == nil || !strings.HasSuffix(err.Error(), "buffer 0 has no data") {
+		t.Errorf("GLB without a binary chunk loaded with error %v", err)
+	}
+}
+
+func TestSplitGLB(t *testing.T) {
+	valid := glb(`{"a":1}`, []byte{1, 2, 3})
+	json, bin, err := splitGLB(valid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(json) != `{"a":1} ` || !bytes.Equal(bin, []byte{1, 2, 3, 0}) {
+		t.Errorf("split into %q and %v", json, bin)
+	}
+
+	version := append([]byte{}, valid...)
+	binary.LittleEndian.PutUint32(version[4:], 1)
+	truncated := valid[:len(valid)-4]
+	overrun := append([]byte{}, valid...)
+	binary.LittleEndian.PutUint32(overrun[12:], 64)
+	noJSON := le(uint32(glbMagic), uint32(2), uint32(24), uint32(4), uint32(glbChunkBIN), uint32(0))
+	for _, tc := range []struct {
+		name string
+		data []byte
+		err  string
+	}{
+		{"version 1", version, "unsupported GLB version 1"},
+		{"truncated", truncated, "GLB is truncated"},
+		{"chunk overrun", overrun, "GLB chunk overruns the file"},
+		{"no JSON", noJSON, "GLB has no JSON chunk"},
+	} {
+		if _, _, err := splitGLB(tc.data); err == nil || err.Error() != tc.err {
+			t.Errorf("%s: got error %v, want %q", tc.name, err, tc.err)
+		}
+	}
+}
+
+func equalFloats(a, b []float32) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !mgl32.FloatEqual(a[i], b[i]) {
+			return false
+		}
+	}
+	return true
+}
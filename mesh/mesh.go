@@ -0,0 +1,86 @@
+package mesh
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-gl/mathgl/mgl32"
+
+	"github.com/brandonnelson3/GoPlay/animation"
+	"github.com/brandonnelson3/GoPlay/shaders"
+)
+
+// Model is everything loaded from one model file.
+type Model struct {
+	Meshes    []*Mesh
+	Materials []*Material
+	// Skeleton is nil unless some mesh is skinned. Clips are the animations that move it.
+	Skeleton *animation.Skeleton
+	Clips    []*animation.Clip
+}
+
+// Mesh is indexed triangle geometry drawn with a single material.
+type Mesh struct {
+	Name     string
+	Vertices []shaders.LitShader_Vertex
+	Indices  []uint32
+	// Material is nil for geometry without one, which draws plain white.
+	Material *Material
+
+	// Joints and Weights are parallel to Vertices for meshes skinned to the model's Skeleton, and nil
+	// otherwise. Skinned vertices are in the skeleton's bind space.
+	Joints  [][4]uint16
+	Weights []mgl32.Vec4
+}
+
+// Material is how a mesh's surface looks.
+type Material struct {
+	Name string
+	// Color multiplies the texture, if there is one. Alpha is opacity.
+	Color mgl32.Vec4
+	// Texture is the color texture's path relative to the model file, empty when it has none or the image
+	// is embedded in TextureData instead.
+	Texture     string
+	TextureData []byte
+}
+
+func newMaterial(name string) *Material {
+	return &Material{Name: name, Color: mgl32.Vec4{1, 1, 1, 1}}
+}
+
+// Load reads a Wavefront OBJ or glTF 2.0 model, picking the format by extension.
+func Load(file string) (*Model, error) {
+	switch strings.ToLower(filepath.Ext(file)) {
+	case ".obj":
+		return LoadOBJ(file)
+	case ".gltf", ".glb":
+		return LoadGLTF(file)
+	}
+	return nil, fmt.Errorf("unknown model format %v", file)
+}
+
+// computeNormals gives every vertex flagged in missing the area weighted average normal of the triangles
+// using it.
+func computeNormals(m *Mesh, missing []bool) {
+	sums := make([]mgl32.Vec3, len(m.Vertices))
+	for i := 0; i+2 < len(m.Indices); i += 3 {
+		a, b, c := m.Indices[i], m.Indices[i+1], m.Indices[i+2]
+		pa, pb, pc := m.Vertices[a].Vert, m.Vertices[b].Vert, m.Vertices[c].Vert
+		// The cross product's length is twice the triangle's area, which does the weighting.
+		n := pb.Sub(pa).Cross(pc.Sub(pa))
+		sums[a] = sums[a].Add(n)
+		sums[b] = sums[b].Add(n)
+		sums[c] = sums[c].Add(n)
+	}
+	for i := range m.Vertices {
+		if !missing[i] {
+			continue
+		}
+		if sums[i].Len() > 0 {
+			m.Vertices[i].VertNormal = sums[i].Normalize()
+		} else {
+			m.Vertices[i].VertNormal = mgl32.Vec3{0, 1, 0}
+		}
+	}
+}
@@ -0,0 +1,283 @@
+package mesh
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/go-gl/mathgl/mgl32"
+
+	"github.com/brandonnelson3/GoPlay/shaders"
+)
+
+// LoadOBJ reads a Wavefront OBJ file and the MTL libraries it references. Faces are triangulated as fans
+// and each usemtl starts a new mesh. Vertices without normals get smooth ones computed from the faces.
+func LoadOBJ(file string) (*Model, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return parseOBJ(f, file)
+}
+
+// objMesh is a mesh being built, with the vertex deduplication state that goes with it.
+type objMesh struct {
+	*Mesh
+	// index maps position, texture coordinate and normal indices to the vertex made from them.
+	index   map[[3]int]uint32
+	missing []bool
+}
+
+func parseOBJ(r io.Reader, file string) (*Model, error) {
+	var (
+		positions []mgl32.Vec3
+		uvs       []mgl32.Vec2
+		normals   []mgl32.Vec3
+		meshes    []*objMesh
+		current   *objMesh
+	)
+	model := &Model{}
+	materials := map[string]*Material{}
+
+	start := func(name string, material *Material) {
+		if current != nil && len(current.Indices) == 0 {
+			// Nothing was drawn with the previous settings, reuse the mesh.
+			current.Name, current.Material = name, material
+			return
+		}
+		current = &objMesh{Mesh: &Mesh{Name: name, Material: material}, index: map[[3]int]uint32{}}
+		meshes = append(meshes, current)
+	}
+
+	scanner := bufio.NewScanner(r)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := scanner.Text()
+		if i := strings.IndexByte(text, '#'); i >= 0 {
+			text = text[:i]
+		}
+		fields := strings.Fields(text)
+		if len(fields) == 0 {
+			continue
+		}
+		fail := func(format string, args ...interface{}) error {
+			return fmt.Errorf("%v:%d: %v", file, line, fmt.Sprintf(format, args...))
+		}
+
+		switch fields[0] {
+		case "v":
+			v, err := parseFloats(fields[1:], 3)
+			if err != nil {
+				return nil, fail("bad vertex: %v", err)
+			}
+			positions = append(positions, mgl32.Vec3{v[0], v[1], v[2]})
+		case "vt":
+			v, err := parseFloats(fields[1:], 2)
+			if err != nil {
+				return nil, fail("bad texture coordinate: %v", err)
+			}
+			uvs = append(uvs, mgl32.Vec2{v[0], v[1]})
+		case "vn":
+			v, err := parseFloats(fields[1:], 3)
+			if err != nil {
+				return nil, fail("bad normal: %v", err)
+			}
+			normals = append(normals, mgl32.Vec3{v[0], v[1], v[2]})
+		case "f":
+			if len(fields) < 4 {
+				return nil, fail("face needs at least 3 corners")
+			}
+			if current == nil {
+				start("", nil)
+			}
+			corners := make([]uint32, 0, len(fields)-1)
+			for _, ref := range fields[1:] {
+				key, err := parseFaceRef(ref, len(positions), len(uvs), len(normals))
+				if err != nil {
+					return nil, fail("%v", err)
+				}
+				corners = append(corners, current.vertex(key, positions, uvs, normals))
+			}
+			for i := 1; i+1 < len(corners); i++ {
+				current.Indices = append(current.Indices, corners[0], corners[i], corners[i+1])
+			}
+		case "usemtl":
+			if len(fields) < 2 {
+				return nil, fail("usemtl needs a material name")
+			}
+			m, ok := materials[fields[1]]
+			if !ok {
+				// Exporters often reference libraries that weren't shipped, draw those faces plain.
+				m = newMaterial(fields[1])
+				materials[m.Name] = m
+				model.Materials = append(model.Materials, m)
+			}
+			name := ""
+			if current != nil {
+				name = current.Name
+			}
+			start(name, m)
+		case "o", "g":
+			var material *Material
+			if current != nil {
+				material = current.Material
+			}
+			start(strings.Join(fields[1:], " "), material)
+		case "mtllib":
+			for _, lib := range fields[1:] {
+				loaded, err := loadMTL(filepath.Join(filepath.Dir(file), lib))
+				if err != nil {
+					return nil, fail("%v", err)
+				}
+				for _, m := range loaded {
+					materials[m.Name] = m
+					model.Materials = append(model.Materials, m)
+				}
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, m := range meshes {
+		if len(m.Indices) == 0 {
+			continue
+		}
+		computeNormals(m.Mesh, m.missing)
+		model.Meshes = append(model.Meshes, m.Mesh)
+	}
+	return model, nil
+}
+
+// vertex returns the index of the vertex made from key, adding it on first use.
+func (m *objMesh) vertex(key [3]int, positions []mgl32.Vec3, uvs []mgl32.Vec2, normals []mgl32.Vec3) uint32 {
+	if i, ok := m.index[key]; ok {
+		return i
+	}
+	v := shaders.LitShader_Vertex{Vert: positions[key[0]]}
+	if key[1] >= 0 {
+		v.VertTexCoord = uvs[key[1]]
+	}
+	if key[2] >= 0 {
+		v.VertNormal = normals[key[2]]
+	}
+	i := uint32(len(m.Vertices))
+	m.Vertices = append(m.Vertices, v)
+	m.missing = append(m.missing, key[2] < 0)
+	m.index[key] = i
+	return i
+}
+
+// parseFaceRef parses a face corner of the form v, v/vt, v//vn or v/vt/vn into zero based indices, with -1
+// for the parts left out. Negative OBJ indices count back from the latest element.
+func parseFaceRef(ref string, positions, uvs, normals int) ([3]int, error) {
+	key := [3]int{-1, -1, -1}
+	counts := [3]int{positions, uvs, normals}
+	parts := strings.Split(ref, "/")
+	if len(parts) > 3 {
+		return key, fmt.Errorf("bad face corner %q", ref)
+	}
+	for i, p := range parts {
+		if p == "" {
+			if i == 0 {
+				return key, fmt.Errorf("face corner %q has no vertex", ref)
+			}
+			continue
+		}
+		n, err := strconv.Atoi(p)
+		if err != nil {
+			return key, fmt.Errorf("bad face corner %q: %v", ref, err)
+		}
+		if n < 0 {
+			n += counts[i]
+		} else {
+			n--
+		}
+		if n < 0 || n >= counts[i] {
+			return key, fmt.Errorf("face corner %q is out of range", ref)
+		}
+		key[i] = n
+	}
+	return key, nil
+}
+
+func parseFloats(fields []string, n int) ([]float32, error) {
+	if len(fields) < n {
+		return nil, fmt.Errorf("expected %d numbers, got %d", n, len(fields))
+	}
+	v := make([]float32, n)
+	for i := range v {
+		f, err := strconv.ParseFloat(fields[i], 32)
+		if err != nil {
+			return nil, err
+		}
+		v[i] = float32(f)
+	}
+	return v, nil
+}
+
+// loadMTL reads the materials in an MTL library. Only the diffuse color, opacity and diffuse texture are
+// used.
+func loadMTL(file string) ([]*Material, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var materials []*Material
+	var current *Material
+	scanner := bufio.NewScanner(f)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := scanner.Text()
+		if i := strings.IndexByte(text, '#'); i >= 0 {
+			text = text[:i]
+		}
+		fields := strings.Fields(text)
+		if len(fields) == 0 {
+			continue
+		}
+		if fields[0] != "newmtl" && current == nil {
+			return nil, fmt.Errorf("%v:%d: %v before newmtl", file, line, fields[0])
+		}
+		switch fields[0] {
+		case "newmtl":
+			if len(fields) < 2 {
+				return nil, fmt.Errorf("%v:%d: newmtl needs a name", file, line)
+			}
+			current = newMaterial(fields[1])
+			materials = append(materials, current)
+		case "Kd":
+			v, err := parseFloats(fields[1:], 3)
+			if err != nil {
+				return nil, fmt.Errorf("%v:%d: bad Kd: %v", file, line, err)
+			}
+			current.Color = mgl32.Vec4{v[0], v[1], v[2], current.Color[3]}
+		case "d", "Tr":
+			v, err := parseFloats(fields[1:], 1)
+			if err != nil {
+				return nil, fmt.Errorf("%v:%d: bad %v: %v", file, line, fields[0], err)
+			}
+			if fields[0] == "Tr" {
+				v[0] = 1 - v[0]
+			}
+			current.Color[3] = v[0]
+		case "map_Kd":
+			if len(fields) < 2 {
+				return nil, fmt.Errorf("%v:%d: map_Kd needs a file", file, line)
+			}
+			// Options come before the file name, which is always last.
+			current.Texture = filepath.ToSlash(fields[len(fields)-1])
+		}
+	}
+	return materials, scanner.Err()
+}
@@ -0,0 +1,264 @@
+package mesh
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+const triangleVerts = `
+v 0 0 0
+v 1 0 0
+v 0 1 0
+vt 0 0
+vt 1 0
+vt 0 1
+vn 0 0 1
+`
+
+func TestParseFaceRef(t *testing.T) {
+	for _, tc := range []struct {
+		ref  string
+		want [3]int
+		err  bool
+	}{
+		{ref: "2", want: [3]int{1, -1, -1}},
+		{ref: "2/3", want: [3]int{1, 2, -1}},
+		{ref: "2//1", want: [3]int{1, -1, 0}},
+		{ref: "1/2/1", want: [3]int{0, 1, 0}},
+		{ref: "-1/-3/-1", want: [3]int{2, 0, 0}},
+		{ref: "-3", want: [3]int{0, -1, -1}},
+		{ref: "0", err: true},
+		{ref: "4", err: true},
+		{ref: "-4", err: true},
+		{ref: "1/4", err: true},
+		{ref: "1//2", err: true},
+		{ref: "/1/1", err: true},
+		{ref: "1/1/1/1", err: true},
+		{ref: "x", err: true},
+	} {
+		got, err := parseFaceRef(tc.ref, 3, 3, 1)
+		switch {
+		case tc.err && err == nil:
+			t.Errorf("parseFaceRef(%q) = %v, want an error", tc.ref, got)
+		case !tc.err && err != nil:
+			t.Errorf("parseFaceRef(%q) failed: %v", tc.ref, err)
+		case !tc.err && got != tc.want:
+			t.Errorf("parseFaceRef(%q) = %v, want %v", tc.ref, got, tc.want)
+		}
+	}
+}
+
+func TestParseOBJ(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		src      string
+		vertices []int
+		indices  [][]uint32
+		err      string
+	}{
+		{
+			name:     "positions only",
+			src:      triangleVerts + "f 1 2 3",
+			vertices: []int{3},
+			indices:  [][]uint32{{0, 1, 2}},
+		},
+		{
+			name:     "all corner forms",
+			src:      triangleVerts + "f 1/1 2/2 3/3\nf 1//1 2//1 3//1\nf 1/1/1 2/2/1 3/3/1",
+			vertices: []int{9},
+			indices:  [][]uint32{{0, 1, 2, 3, 4, 5, 6, 7, 8}},
+		},
+		{
+			name:     "negative indices share vertices with positive ones",
+			src:      triangleVerts + "f 1/1/1 2/2/1 3/3/1\nf -3/-3/-1 -2/-2/-1 -1/-1/-1",
+			vertices: []int{3},
+			indices:  [][]uint32{{0, 1, 2, 0, 1, 2}},
+		},
+		{
+			name:     "quad fan",
+			src:      triangleVerts + "v 1 1 0\nf 1 2 4 3",
+			vertices: []int{4},
+			indices:  [][]uint32{{0, 1, 2, 0, 2, 3}},
+		},
+		{
+			name:     "usemtl splits meshes",
+			src:      triangleVerts + "usemtl a\nf 1 2 3\nusemtl b\nf 3 2 1\nusemtl a\nf 1 2 3",
+			vertices: []int{3, 3, 3},
+			indices:  [][]uint32{{0, 1, 2}, {0, 1, 2}, {0, 1, 2}},
+		},
+		{
+			name:     "empty usemtl runs are dropped",
+			src:      triangleVerts + "usemtl a\nusemtl b\nf 1 2 3",
+			vertices: []int{3},
+			indices:  [][]uint32{{0, 1, 2}},
+		},
+		{name: "out of range", src: triangleVerts + "f 1 2 4", err: "test.obj:9: face corner \"4\" is out of range"},
+		{name: "reference before definition", src: "f 1 2 3\n" + triangleVerts, err: "test.obj:1:"},
+		{name: "too few corners", src: triangleVerts + "f 1 2", err: "test.obj:9: face needs at least 3 corners"},
+		{name: "bad vertex", src: "v 1 2", err: "test.obj:1: bad vertex"},
+		{name: "missing library", src: "mtllib missing.mtl", err: "test.obj:1:"},
+	} {
+		model, err := parseOBJ(strings.NewReader(tc.src), "test.obj")
+		if tc.err != "" {
+			if err == nil || !strings.HasPrefix(err.Error(), tc.err) {
+				t.Errorf("%s: got error %v, want %q", tc.name, err, tc.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", tc.name, err)
+			continue
+		}
+		if len(model.Meshes) != len(tc.vertices) {
+			t.Errorf("%s: got %d meshes, want %d", tc.name, len(model.Meshes), len(tc.vertices))
+			continue
+		}
+		for i, m := range model.Meshes {
+			if len(m.Vertices) != tc.vertices[i] {
+				t.Errorf("%s: mesh %d has %d vertices, want %d", tc.name, i, len(m.Vertices), tc.vertices[i])
+			}
+			if !equalIndices(m.Indices, tc.indices[i]) {
+				t.Errorf("%s: mesh %d has indices %v, want %v", tc.name, i, m.Indices, tc.indices[i])
+			}
+		}
+	}
+}
+
+func TestParseOBJMaterials(t *testing.T) {
+	src := triangleVerts + "usemtl a\nf 1 2 3\nusemtl b\nf 3 2 1\nusemtl a\nf 1 2 3\nusemtl\n"
+	if _, err := parseOBJ(strings.NewReader(src), "test.obj"); err == nil {
+		t.Errorf("usemtl without a name didn't fail")
+	}
+
+	model, err := parseOBJ(strings.NewReader(strings.TrimSuffix(src, "usemtl\n")), "test.obj")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(model.Materials) != 2 {
+		t.Fatalf("got %d materials, want a and b", len(model.Materials))
+	}
+	a, b := model.Materials[0], model.Materials[1]
+	for i, want := range []*Material{a, b, a} {
+		if got := model.Meshes[i].Material; got != want {
+			t.Errorf("mesh %d has material %v, want %v", i, got.Name, want.Name)
+		}
+	}
+	// Unknown materials draw plain white.
+	if a.Name != "a" || a.Color != (mgl32.Vec4{1, 1, 1, 1}) || a.Texture != "" {
+		t.Errorf("unknown material a is %+v, want plain white", a)
+	}
+}
+
+func TestParseOBJNormals(t *testing.T) {
+	model, err := parseOBJ(strings.NewReader(triangleVerts+"vn 1 0 0\nf 1 2 3\nf 1//2 3//2 2//2"), "test.obj")
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := model.Meshes[0]
+	for i, v := range m.Vertices {
+		want := mgl32.Vec3{0, 0, 1}
+		if i >= 3 {
+			// Given normals are kept as they are.
+			want = mgl32.Vec3{1, 0, 0}
+		}
+		if !v.VertNormal.ApproxEqual(want) {
+			t.Errorf("vertex %d has normal %v, want %v", i, v.VertNormal, want)
+		}
+	}
+}
+
+func TestLoadOBJ(t *testing.T) {
+	model, err := Load("../assets/models/crate.obj")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(model.Meshes) != 1 || len(model.Materials) != 1 {
+		t.Fatalf("got %d meshes and %d materials, want 1 of each", len(model.Meshes), len(model.Materials))
+	}
+	m := model.Meshes[0]
+	// Every corner of the 6 quads differs in normal or texture coordinate from the others at its position.
+	if len(m.Vertices) != 24 || len(m.Indices) != 36 {
+		t.Errorf("crate has %d vertices and %d indices, want 24 and 36", len(m.Vertices), len(m.Indices))
+	}
+	if m.Name != "crate" || m.Material != model.Materials[0] {
+		t.Errorf("crate mesh is %q with material %v", m.Name, m.Material)
+	}
+	want := Material{Name: "crate", Color: mgl32.Vec4{1, 1, 1, 1}, Texture: "../crate.jpg"}
+	if got := *model.Materials[0]; got.Name != want.Name || got.Color != want.Color || got.Texture != want.Texture {
+		t.Errorf("crate material is %+v, want %+v", got, want)
+	}
+}
+
+func TestLoadMTL(t *testing.T) {
+	dir, err := ioutil.TempDir("", "mtl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	for _, tc := range []struct {
+		name string
+		src  string
+		want []Material
+		err  string
+	}{
+		{
+			name: "color and texture",
+			src:  "newmtl red\nKd 1 0 0 # red\nmap_Kd -bm 0.5 textures/red.png\n",
+			want: []Material{{Name: "red", Color: mgl32.Vec4{1, 0, 0, 1}, Texture: "textures/red.png"}},
+		},
+		{
+			name: "opacity",
+			src:  "newmtl d\nd 0.25\nKd 0 1 0\nnewmtl tr\nTr 0.25\n",
+			want: []Material{
+				{Name: "d", Color: mgl32.Vec4{0, 1, 0, 0.25}},
+				{Name: "tr", Color: mgl32.Vec4{1, 1, 1, 0.75}},
+			},
+		},
+		{name: "statement before newmtl", src: "Kd 1 1 1\n", err: "test.mtl:1: Kd before newmtl"},
+		{name: "bad Kd", src: "newmtl a\nKd 1 1\n", err: "test.mtl:2: bad Kd"},
+		{name: "map_Kd without a file", src: "newmtl a\nmap_Kd\n", err: "test.mtl:2: map_Kd needs a file"},
+	} {
+		file := filepath.Join(dir, "test.mtl")
+		if err := ioutil.WriteFile(file, []byte(tc.src), 0644); err != nil {
+			t.Fatal(err)
+		}
+		got, err := loadMTL(file)
+		if tc.err != "" {
+			if err == nil || !strings.HasPrefix(err.Error(), filepath.Join(dir, tc.err)) {
+				t.Errorf("%s: got error %v, want %q", tc.name, err, tc.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", tc.name, err)
+			continue
+		}
+		if len(got) != len(tc.want) {
+			t.Errorf("%s: got %d materials, want %d", tc.name, len(got), len(tc.want))
+			continue
+		}
+		for i, m := range got {
+			if w := tc.want[i]; m.Name != w.Name || m.Color != w.Color || m.Texture != w.Texture {
+				t.Errorf("%s: material %d is %+v, want %+v", tc.name, i, *m, w)
+			}
+		}
+	}
+}
+
+func equalIndices(a, b []uint32) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
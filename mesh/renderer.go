@@ -0,0 +1,192 @@
+package mesh
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"path"
+
+	"github.com/go-gl/gl/v4.1-core/gl"
+	"github.com/go-gl/mathgl/mgl32"
+
+	"github.com/brandonnelson3/GoPlay/animation"
+	"github.com/brandonnelson3/GoPlay/assetmanager"
+	"github.com/brandonnelson3/GoPlay/scene"
+	"github.com/brandonnelson3/GoPlay/shaders"
+	"github.com/brandonnelson3/GoPlay/texture"
+)
+
+// Renderer draws an uploaded Model. It is a scene.Renderable and casts shadows. Models with a skeleton are
+// also a scene.Updater, advancing Player every update and skinning on the GPU.
+type Renderer struct {
+	// Player animates the skeleton, and is nil for models without one. It starts out looping the first
+	// clip, if there is one.
+	Player *animation.Player
+	// Clips are the model's animations, for handing to Player.
+	Clips []*animation.Clip
+
+	parts       []part
+	shader      *shaders.LitShader
+	depthShader *shaders.DepthShader
+
+	skinnedShader      *shaders.SkinnedShader
+	skinnedDepthShader *shaders.DepthShader
+}
+
+// part is one mesh on the GPU.
+type part struct {
+	vbo     *shaders.VertexBuffer
+	skinned bool
+	color   mgl32.Vec4
+	// texture is nil for untextured materials.
+	texture *texture.Texture
+}
+
+// NewRenderer loads the model at the asset path file and uploads it. Textures that fail to load are
+// logged and drawn untextured rather than failing the whole model.
+func NewRenderer(file string) (*Renderer, error) {
+	model, err := Load(assetmanager.M.Path(file))
+	if err != nil {
+		return nil, err
+	}
+	shader, err := assetmanager.M.LitShader()
+	if err != nil {
+		return nil, err
+	}
+	depthShader, err := assetmanager.M.DepthShader()
+	if err != nil {
+		return nil, err
+	}
+	r := &Renderer{shader: shader, depthShader: depthShader, Clips: model.Clips}
+	if s := model.Skeleton; s != nil {
+		if len(s.Joints) > shaders.MaxJoints {
+			return nil, fmt.Errorf("%v has %d joints, at most %d are supported", file, len(s.Joints), shaders.MaxJoints)
+		}
+		if r.skinnedShader, err = assetmanager.M.SkinnedShader(); err != nil {
+			return nil, err
+		}
+		if r.skinnedDepthShader, err = assetmanager.M.SkinnedDepthShader(); err != nil {
+			return nil, err
+		}
+		r.Player = animation.NewPlayer(s)
+		if len(model.Clips) > 0 {
+			r.Player.Play(model.Clips[0])
+		}
+	}
+
+	// Materials are shared between meshes, so only load each texture once.
+	textures := map[*Material]*texture.Texture{}
+	for _, m := range model.Meshes {
+		p := part{color: mgl32.Vec4{1, 1, 1, 1}}
+		if m.Joints != nil {
+			p.skinned = true
+			p.vbo = shaders.NewVertexBuffer(shaders.SkinnedShader_Layout, skinnedVertices(m), m.Indices)
+		} else {
+			p.vbo = shaders.NewVertexBuffer(shaders.LitShader_Layout, m.Vertices, m.Indices)
+		}
+		if mat := m.Material; mat != nil {
+			p.color = mat.Color
+			t, ok := textures[mat]
+			if !ok {
+				t = loadTexture(file, mat)
+				textures[mat] = t
+			}
+			p.texture = t
+		}
+		r.parts = append(r.parts, p)
+	}
+	return r, nil
+}
+
+func skinnedVertices(m *Mesh) []shaders.SkinnedShader_Vertex {
+	verts := make([]shaders.SkinnedShader_Vertex, len(m.Vertices))
+	for i, v := range m.Vertices {
+		verts[i] = shaders.SkinnedShader_Vertex{
+			Vert:         v.Vert,
+			VertTexCoord: v.VertTexCoord,
+			VertNormal:   v.VertNormal,
+			VertJoints:   m.Joints[i],
+			VertWeights:  m.Weights[i],
+		}
+	}
+	return verts
+}
+
+// loadTexture returns the texture of a material of the model at file, or nil if it has none or it fails
+// to load.
+func loadTexture(file string, mat *Material) *texture.Texture {
+	switch {
+	case mat.TextureData != nil:
+		img, err := texture.Decode(bytes.NewReader(mat.TextureData))
+		if err == nil {
+			var t texture.Texture
+			if t, err = img.Upload(); err == nil {
+				return &t
+			}
+		}
+		log.Printf("Failed to load the embedded texture of %v material %v: %v", file, mat.Name, err)
+	case mat.Texture != "":
+		t, err := assetmanager.M.Texture(path.Join(path.Dir(file), mat.Texture))
+		if err == nil {
+			return &t.Texture
+		}
+		log.Printf("Failed to load %v material %v texture: %v", file, mat.Name, err)
+	}
+	return nil
+}
+
+func (r *Renderer) Update(_ *scene.Node, elapsed float64) {
+	if r.Player != nil {
+		r.Player.Update(elapsed)
+	}
+}
+
+func (r *Renderer) Render(v *scene.View, world mgl32.Mat4) {
+	r.renderParts(r.shader, false, v, world)
+	if r.skinnedShader != nil {
+		r.skinnedShader.Activate()
+		r.skinnedShader.SetJoints(r.Player.Matrices())
+		r.renderParts(r.skinnedShader.LitShader, true, v, world)
+	}
+}
+
+// renderParts draws the parts that are or aren't skinned with shader.
+func (r *Renderer) renderParts(shader *shaders.LitShader, skinned bool, v *scene.View, world mgl32.Mat4) {
+	shader.Activate()
+	shader.SetProjection(v.Projection)
+	shader.SetView(v.View)
+	shader.SetModel(world)
+	for i := range r.parts {
+		p := &r.parts[i]
+		if p.skinned != skinned {
+			continue
+		}
+		shader.SetMaterial(p.color, p.texture != nil)
+		if p.texture != nil {
+			p.texture.Bind(gl.TEXTURE0)
+		}
+		p.vbo.Draw(gl.TRIANGLES)
+	}
+	// Leave the shared program as other users expect it.
+	shader.SetMaterial(mgl32.Vec4{1, 1, 1, 1}, true)
+}
+
+func (r *Renderer) RenderShadow(lightSpace, world mgl32.Mat4) {
+	r.renderShadowParts(r.depthShader, false, lightSpace, world)
+	if r.skinnedDepthShader != nil {
+		r.skinnedDepthShader.Activate()
+		r.skinnedDepthShader.SetJoints(r.Player.Matrices())
+		r.renderShadowParts(r.skinnedDepthShader, true, lightSpace, world)
+	}
+}
+
+func (r *Renderer) renderShadowParts(shader *shaders.DepthShader, skinned bool, lightSpace, world mgl32.Mat4) {
+	shader.Activate()
+	shader.SetLightSpace(lightSpace)
+	shader.SetModel(world)
+	for i := range r.parts {
+		if r.parts[i].skinned == skinned {
+			r.parts[i].vbo.Draw(gl.TRIANGLES)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package physics
+
+import (
+	"github.com/go-gl/mathgl/mgl32"
+
+	"github.com/brandonnelson3/GoPlay/spatial"
+)
+
+// Body is a rigid body. Bodies only translate, their shapes never rotate.
+type Body struct {
+	Shape Shape
+	// Position is the center of the shape. A sleeping body moved by hand must be woken to notice.
+	Position mgl32.Vec3
+	Velocity mgl32.Vec3
+	// Mass is in kilograms. Bodies with no mass are static and never move.
+	Mass float32
+	// Restitution is how bouncy the body is, from 0 for no bounce to 1 for a perfectly elastic one.
+	Restitution float32
+	// Friction is the Coulomb friction coefficient against other bodies and the terrain.
+	Friction float32
+
+	// Sleeping bodies have come to rest and are skipped until something hits them or Wake is called.
+	Sleeping bool
+	still    float32
+	// previous is the position at the start of the last step.
+	previous mgl32.Vec3
+
+	// item is the body's entry in its World's Index, and order the position it was added in.
+	item  *spatial.Item
+	order int
+}
+
+// NewBody returns a body with typical restitution and friction.
+func NewBody(shape Shape, position mgl32.Vec3, mass float32) *Body {
+	return &Body{Shape: shape, Position: position, Mass: mass, Restitution: 0.2, Friction: 0.5}
+}
+
+func (b *Body) Static() bool {
+	return b.Mass <= 0
+}
+
+// awake reports whether the body is being simulated.
+func (b *Body) awake() bool {
+	return !b.Static() && !b.Sleeping
+}
+
+// inverseMass is 0 for static bodies, and for sleeping ones, which nothing moves until they are woken.
+func (b *Body) inverseMass() float32 {
+	if b.Static() || b.Sleeping {
+		return 0
+	}
+	return 1 / b.Mass
+}
+
+func (b *Body) Bounds() AABB {
+	return b.Shape.Bounds(b.Position)
+}
+
+// Wake makes a sleeping body simulate again, such as after the terrain under it changed.
+func (b *Body) Wake() {
+	b.Sleeping = false
+	b.still = 0
+}
+
+// ApplyImpulse changes the body's momentum by impulse, waking it.
+func (b *Body) ApplyImpulse(impulse mgl32.Vec3) {
+	if b.Static() {
+		return
+	}
+	b.Wake()
+	b.Velocity = b.Velocity.Add(impulse.Mul(b.inverseMass()))
+}
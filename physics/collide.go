@@ -0,0 +1,127 @@
+package physics
+
+import (
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// contact is how far and in which direction to move one shape to separate it from another.
+type contact struct {
+	// normal is a unit vector pointing out of the other shape.
+	normal mgl32.Vec3
+	depth  float32
+}
+
+// exposedFunc reports whether the face of a box on axis, on the side given by sign, may be pushed out
+// through. Voxel faces against another solid voxel are internal, and pushing out through them makes
+// shapes catch on the seams between voxels.
+type exposedFunc func(axis int, sign float32) bool
+
+func allExposed(int, float32) bool { return true }
+
+// collide returns the contact pushing shape a at pa out of shape b at pb.
+func collide(a Shape, pa mgl32.Vec3, b Shape, pb mgl32.Vec3) (contact, bool) {
+	switch {
+	case a.Kind == Box && b.Kind == Box:
+		return boxBox(a.Bounds(pa), b.Bounds(pb), allExposed)
+	case b.Kind == Box:
+		lo, hi := a.segment(pa)
+		return roundBox(lo, hi, a.Radius, b.Bounds(pb), allExposed)
+	case a.Kind == Box:
+		lo, hi := b.segment(pb)
+		c, ok := roundBox(lo, hi, b.Radius, a.Bounds(pa), allExposed)
+		c.normal = c.normal.Mul(-1)
+		return c, ok
+	}
+	aLo, aHi := a.segment(pa)
+	bLo, bHi := b.segment(pb)
+	return roundRound(aLo, aHi, a.Radius, bLo, bHi, b.Radius)
+}
+
+// boxBox separates box a from box b along the axis of least overlap whose face of b is exposed.
+func boxBox(a, b AABB, exposed exposedFunc) (contact, bool) {
+	if !a.Overlaps(b) {
+		return contact{}, false
+	}
+	ca, cb := a.Center(), b.Center()
+	best := contact{depth: -1}
+	for axis := 0; axis < 3; axis++ {
+		var sign float32 = 1
+		depth := b.Max[axis] - a.Min[axis]
+		if ca[axis] < cb[axis] {
+			sign = -1
+			depth = a.Max[axis] - b.Min[axis]
+		}
+		if !exposed(axis, sign) || (best.depth >= 0 && depth >= best.depth) {
+			continue
+		}
+		best.depth = depth
+		best.normal = mgl32.Vec3{}
+		best.normal[axis] = sign
+	}
+	if best.depth < 0 {
+		// Buried with every face internal, push it upwards out of the ground.
+		return contact{normal: mgl32.Vec3{0, 1, 0}, depth: b.Max[1] - a.Min[1]}, true
+	}
+	return best, best.depth > 0
+}
+
+// closestOnSegment returns the point of the vertical segment lo, hi whose height is nearest y.
+func closestOnSegment(lo, hi mgl32.Vec3, y float32) mgl32.Vec3 {
+	p := lo
+	p[1] = mgl32.Clamp(y, lo[1], hi[1])
+	return p
+}
+
+// roundBox separates a sphere swept along the vertical segment lo, hi from box b.
+func roundBox(lo, hi mgl32.Vec3, radius float32, b AABB, exposed exposedFunc) (contact, bool) {
+	// The segment point nearest the box: with overlapping heights the horizontal distance is the same all
+	// along the overlap, so any point in it will do.
+	p := closestOnSegment(lo, hi, (b.Min[1]+b.Max[1])/2)
+	var q mgl32.Vec3
+	inside := true
+	for i := 0; i < 3; i++ {
+		q[i] = mgl32.Clamp(p[i], b.Min[i], b.Max[i])
+		if q[i] != p[i] {
+			inside = false
+		}
+	}
+	if !inside {
+		d := p.Sub(q)
+		dist := d.Len()
+		if dist >= radius {
+			return contact{}, false
+		}
+		n := d.Mul(1 / dist)
+		// Contacts square on to a face the shape can't be pushed through belong to the neighbour.
+		for axis := 0; axis < 3; axis++ {
+			if n[axis] > 0.999 || n[axis] < -0.999 {
+				if !exposed(axis, n[axis]) {
+					return contact{}, false
+				}
+			}
+		}
+		return contact{normal: n, depth: radius - dist}, true
+	}
+	// The center is inside the box, push it out through the nearest exposed face.
+	c := AABB{p.Sub(mgl32.Vec3{radius, radius, radius}), p.Add(mgl32.Vec3{radius, radius, radius})}
+	return boxBox(c, b, exposed)
+}
+
+// roundRound separates two spheres swept along vertical segments.
+func roundRound(aLo, aHi mgl32.Vec3, aRadius float32, bLo, bHi mgl32.Vec3, bRadius float32) (contact, bool) {
+	// The nearest heights are in the overlap of the two segments, or at their closest ends.
+	y := (mgl32.Clamp(aLo[1], bLo[1], bHi[1]) + mgl32.Clamp(aHi[1], bLo[1], bHi[1])) / 2
+	pa := closestOnSegment(aLo, aHi, y)
+	pb := closestOnSegment(bLo, bHi, pa[1])
+	d := pa.Sub(pb)
+	dist := d.Len()
+	radius := aRadius + bRadius
+	if dist >= radius {
+		return contact{}, false
+	}
+	n := mgl32.Vec3{0, 1, 0}
+	if dist > 1e-6 {
+		n = d.Mul(1 / dist)
+	}
+	return contact{normal: n, depth: radius - dist}, true
+}
@@ -0,0 +1,83 @@
+package physics
+
+import (
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// AABB is an axis aligned box.
+type AABB struct {
+	Min, Max mgl32.Vec3
+}
+
+// Overlaps reports whether a and b intersect, touching counts.
+func (a AABB) Overlaps(b AABB) bool {
+	return a.Min[0] <= b.Max[0] && a.Max[0] >= b.Min[0] &&
+		a.Min[1] <= b.Max[1] && a.Max[1] >= b.Min[1] &&
+		a.Min[2] <= b.Max[2] && a.Max[2] >= b.Min[2]
+}
+
+func (a AABB) Center() mgl32.Vec3 {
+	return a.Min.Add(a.Max).Mul(0.5)
+}
+
+// ShapeKind is the kind of a Shape.
+type ShapeKind int
+
+const (
+	Box ShapeKind = iota
+	Sphere
+	Capsule
+)
+
+// Shape is a collision shape centered on its body. Shapes don't rotate with anything: boxes stay axis
+// aligned and capsules stay upright.
+type Shape struct {
+	Kind ShapeKind
+	// HalfExtents is the half size of a Box.
+	HalfExtents mgl32.Vec3
+	// Radius is the radius of a Sphere or of the ends of a Capsule.
+	Radius float32
+	// HalfHeight is half the length of the vertical segment between a Capsule's end centers.
+	HalfHeight float32
+}
+
+func NewBox(halfExtents mgl32.Vec3) Shape {
+	return Shape{Kind: Box, HalfExtents: halfExtents}
+}
+
+func NewSphere(radius float32) Shape {
+	return Shape{Kind: Sphere, Radius: radius}
+}
+
+// NewCapsule returns an upright capsule of the given total height, which includes both rounded ends.
+func NewCapsule(radius, height float32) Shape {
+	half := height/2 - radius
+	if half < 0 {
+		half = 0
+	}
+	return Shape{Kind: Capsule, Radius: radius, HalfHeight: half}
+}
+
+// Bounds returns the box around the shape centered at position.
+func (s Shape) Bounds(position mgl32.Vec3) AABB {
+	var half mgl32.Vec3
+	switch s.Kind {
+	case Box:
+		half = s.HalfExtents
+	case Sphere:
+		half = mgl32.Vec3{s.Radius, s.Radius, s.Radius}
+	case Capsule:
+		half = mgl32.Vec3{s.Radius, s.Radius + s.HalfHeight, s.Radius}
+	}
+	return AABB{position.Sub(half), position.Add(half)}
+}
+
+// segment returns the ends of the segment a rounded shape is swept along, the same point twice for a
+// sphere.
+func (s Shape) segment(position mgl32.Vec3) (lo, hi mgl32.Vec3) {
+	offset := mgl32.Vec3{0, s.HalfHeight, 0}
+	if s.Kind != Capsule {
+		offset = mgl32.Vec3{}
+	}
+	return position.Sub(offset), position.Add(offset)
+}
@@ -0,0 +1,303 @@
+package physics
+
+import (
+	"math"
+
+	"github.com/go-gl/mathgl/mgl32"
+
+	"github.com/brandonnelson3/GoPlay/spatial"
+)
+
+// Voxels is a grid of unit voxels, voxel x, y, z filling [x, x+1] on each axis.
+type Voxels interface {
+	// Solid reports whether a voxel blocks bodies, and whether it is loaded at all.
+	Solid(x, y, z int32) (solid, loaded bool)
+}
+
+// Tuning shared by every World.
+const (
+	// Overlap allowed before positions are corrected, which stops resting contacts from jittering.
+	slop = 0.005
+	// Fraction of the remaining overlap corrected each step.
+	correction = 0.8
+	// Bodies approaching slower than this don't bounce, so resting bodies settle instead of buzzing.
+	bounceSpeed = 0.5
+	// Passes over every contact each step. One pass leaves stacks sinking into each other, since pushing
+	// one body out of another can push it into a third.
+	solverIterations = 4
+	// Contacts resolved per body against the terrain in each pass.
+	terrainContacts = 2
+	// A sleeping body is only woken by a contact approaching faster than wakeSpeed or overlapping deeper
+	// than wakeDepth. Gentler contacts, like a body resting on it, treat it as static, so stacks can fall
+	// asleep from the bottom up.
+	wakeSpeed = 0.5
+	wakeDepth = 0.05
+)
+
+// World simulates bodies on a fixed timestep.
+type World struct {
+	Gravity mgl32.Vec3
+	// Step is the fixed timestep in seconds.
+	Step float32
+	// MaxSteps caps the steps taken by one Update, so a long frame slows the simulation down instead of
+	// taking ever longer to catch up.
+	MaxSteps int
+	// SleepSpeed and SleepTime control sleeping: a body moving slower than SleepSpeed for SleepTime seconds
+	// falls asleep.
+	SleepSpeed float32
+	SleepTime  float32
+
+	// Terrain is collided with if set. Bodies over voxels that aren't loaded hold still until they are.
+	Terrain Voxels
+
+	Bodies []*Body
+	// Index holds the bounds of every body, with the *Body as the item data. Bodies are only tested
+	// against those they share cells with, and it answers proximity queries for gameplay.
+	Index *spatial.Grid
+
+	accumulator float64
+	added       int
+}
+
+func NewWorld() *World {
+	return &World{
+		Gravity:    mgl32.Vec3{0, -9.81, 0},
+		Step:       1.0 / 60,
+		MaxSteps:   5,
+		SleepSpeed: 0.05,
+		SleepTime:  0.5,
+		// Cells a few times the size of a typical body.
+		Index: spatial.NewGrid(4),
+	}
+}
+
+func (w *World) Add(b *Body) {
+	w.Bodies = append(w.Bodies, b)
+	b.item = w.Index.Insert(spatial.AABB(b.Bounds()), b)
+	b.order = w.added
+	w.added++
+}
+
+func (w *World) Remove(b *Body) {
+	for i, o := range w.Bodies {
+		if o == b {
+			w.Bodies = append(w.Bodies[:i], w.Bodies[i+1:]...)
+			w.Index.Remove(b.item)
+			b.item = nil
+			return
+		}
+	}
+}
+
+// Update advances the simulation by as many fixed steps as fit in elapsed seconds plus the time left over
+// from previous updates.
+func (w *World) Update(elapsed float64) {
+	w.accumulator += elapsed
+	step := float64(w.Step)
+	for n := 0; w.accumulator >= step; n++ {
+		if n == w.MaxSteps {
+			w.accumulator = 0
+			break
+		}
+		w.Simulate(w.Step)
+		w.accumulator -= step
+	}
+}
+
+// Simulate advances the simulation by a single step of dt seconds.
+func (w *World) Simulate(dt float32) {
+	for _, b := range w.Bodies {
+		b.previous = b.Position
+		if b.Static() || b.Sleeping || !w.loaded(b) {
+			continue
+		}
+		b.Velocity = b.Velocity.Add(w.Gravity.Mul(dt))
+		b.Position = b.Position.Add(b.Velocity.Mul(dt))
+	}
+
+	for n := 0; n < solverIterations; n++ {
+		for _, b := range w.Bodies {
+			if !b.Sleeping {
+				w.Index.Move(b.item, spatial.AABB(b.Bounds()))
+			}
+		}
+		for _, a := range w.Bodies {
+			if !a.awake() {
+				continue
+			}
+			w.Index.QueryAABB(spatial.AABB(a.Bounds()), func(it *spatial.Item) bool {
+				// Pairs of awake bodies are found from both sides, only take them from the first added.
+				if b := it.Data.(*Body); b != a && (!b.awake() || a.order < b.order) {
+					w.collideBodies(a, b)
+				}
+				return true
+			})
+		}
+		if w.Terrain != nil {
+			for _, b := range w.Bodies {
+				if b.awake() {
+					w.collideTerrain(b)
+				}
+			}
+		}
+	}
+
+	for _, b := range w.Bodies {
+		if b.Static() || b.Sleeping {
+			continue
+		}
+		// Resting contacts leave some velocity behind every step that the next step's contacts cancel, so
+		// how far the body actually moved is what tells whether it is at rest.
+		if b.Position.Sub(b.previous).Len() < w.SleepSpeed*dt {
+			b.still += dt
+			if b.still >= w.SleepTime {
+				b.Sleeping = true
+				b.Velocity = mgl32.Vec3{}
+			}
+		} else {
+			b.still = 0
+		}
+	}
+}
+
+// loaded reports whether the terrain around b's center is loaded, or there is no terrain.
+func (w *World) loaded(b *Body) bool {
+	if w.Terrain == nil {
+		return true
+	}
+	p := b.Position
+	_, loaded := w.Terrain.Solid(floor(p[0]), floor(p[1]), floor(p[2]))
+	return loaded
+}
+
+func floor(f float32) int32 {
+	return int32(math.Floor(float64(f)))
+}
+
+func (w *World) collideBodies(a, b *Body) {
+	if !a.awake() && !b.awake() {
+		return
+	}
+	c, ok := collide(a.Shape, a.Position, b.Shape, b.Position)
+	if !ok {
+		return
+	}
+	if a.Sleeping || b.Sleeping {
+		if approach := b.Velocity.Sub(a.Velocity).Dot(c.normal); approach > wakeSpeed || c.depth > wakeDepth {
+			w.wake(a)
+			w.wake(b)
+		}
+	}
+	resolve(a, b, c)
+}
+
+// wake wakes b if it is asleep, along with every sleeping body touching it, so a stack that is hit wakes
+// as a whole instead of leaving bodies asleep in the air.
+func (w *World) wake(b *Body) {
+	if !b.Sleeping {
+		return
+	}
+	b.Wake()
+	bounds := b.Bounds()
+	margin := mgl32.Vec3{slop, slop, slop}
+	bounds.Min, bounds.Max = bounds.Min.Sub(margin), bounds.Max.Add(margin)
+	w.Index.QueryAABB(spatial.AABB(bounds), func(it *spatial.Item) bool {
+		w.wake(it.Data.(*Body))
+		return true
+	})
+}
+
+// collideTerrain pushes b out of the solid voxels it overlaps, deepest first.
+func (w *World) collideTerrain(b *Body) {
+	for i := 0; i < terrainContacts; i++ {
+		bounds := b.Bounds()
+		var deepest contact
+		found := false
+		for x := floor(bounds.Min[0]); float32(x) < bounds.Max[0]; x++ {
+			for y := floor(bounds.Min[1]); float32(y) < bounds.Max[1]; y++ {
+				for z := floor(bounds.Min[2]); float32(z) < bounds.Max[2]; z++ {
+					if solid, _ := w.Terrain.Solid(x, y, z); !solid {
+						continue
+					}
+					c, ok := w.voxelContact(b, x, y, z)
+					if ok && (!found || c.depth > deepest.depth) {
+						deepest, found = c, true
+					}
+				}
+			}
+		}
+		if !found {
+			return
+		}
+		resolve(b, nil, deepest)
+	}
+}
+
+func (w *World) voxelContact(b *Body, x, y, z int32) (contact, bool) {
+	voxel := AABB{mgl32.Vec3{float32(x), float32(y), float32(z)}, mgl32.Vec3{float32(x + 1), float32(y + 1), float32(z + 1)}}
+	exposed := func(axis int, sign float32) bool {
+		n := [3]int32{x, y, z}
+		if sign > 0 {
+			n[axis]++
+		} else {
+			n[axis]--
+		}
+		solid, _ := w.Terrain.Solid(n[0], n[1], n[2])
+		return !solid
+	}
+	if b.Shape.Kind == Box {
+		return boxBox(b.Bounds(), voxel, exposed)
+	}
+	lo, hi := b.Shape.segment(b.Position)
+	return roundBox(lo, hi, b.Shape.Radius, voxel, exposed)
+}
+
+// resolve separates a from b, which is nil for the terrain, and applies the collision impulse with
+// restitution and friction.
+func resolve(a, b *Body, c contact) {
+	invA, invB := a.inverseMass(), float32(0)
+	restitution, friction := a.Restitution, a.Friction
+	velocity := a.Velocity
+	if b != nil {
+		invB = b.inverseMass()
+		restitution = float32(math.Max(float64(restitution), float64(b.Restitution)))
+		friction = float32(math.Sqrt(float64(friction * b.Friction)))
+		velocity = velocity.Sub(b.Velocity)
+	}
+	invSum := invA + invB
+	if invSum == 0 {
+		return
+	}
+
+	if depth := c.depth - slop; depth > 0 {
+		move := c.normal.Mul(depth * correction / invSum)
+		a.Position = a.Position.Add(move.Mul(invA))
+		if b != nil {
+			b.Position = b.Position.Sub(move.Mul(invB))
+		}
+	}
+
+	vn := velocity.Dot(c.normal)
+	if vn >= 0 {
+		// Already separating.
+		return
+	}
+	if -vn < bounceSpeed {
+		restitution = 0
+	}
+	j := -(1 + restitution) * vn / invSum
+	impulse := c.normal.Mul(j)
+
+	// Friction opposes sliding, up to friction times the normal impulse.
+	tangent := velocity.Sub(c.normal.Mul(vn))
+	if speed := tangent.Len(); speed > 1e-6 {
+		tangent = tangent.Mul(1 / speed)
+		jt := mgl32.Clamp(speed/invSum, 0, friction*j)
+		impulse = impulse.Sub(tangent.Mul(jt))
+	}
+
+	a.Velocity = a.Velocity.Add(impulse.Mul(invA))
+	if b != nil {
+		b.Velocity = b.Velocity.Sub(impulse.Mul(invB))
+	}
+}
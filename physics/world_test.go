@@ -0,0 +1,168 @@
+package physics
+
+import (
+	"math"
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// ground is loaded terrain that is solid below y = 0 and, when wall is set, from x = wall up.
+type ground struct {
+	wall *int32
+}
+
+func (f ground) Solid(x, y, z int32) (solid, loaded bool) {
+	return y < 0 || f.wall != nil && x >= *f.wall, true
+}
+
+// newGround returns a world with a static box whose top is at y = 0.
+func newGround() *World {
+	w := NewWorld()
+	w.Add(NewBody(NewBox(mgl32.Vec3{50, 1, 50}), mgl32.Vec3{0, -1, 0}, 0))
+	return w
+}
+
+// run simulates w for seconds of fixed steps.
+func run(w *World, seconds float32) {
+	for t := float32(0); t < seconds; t += w.Step {
+		w.Simulate(w.Step)
+	}
+}
+
+func near(a, b, tolerance float32) bool {
+	return float32(math.Abs(float64(a-b))) <= tolerance
+}
+
+func TestBoxLandsAndSleeps(t *testing.T) {
+	w := newGround()
+	box := NewBody(NewBox(mgl32.Vec3{0.5, 0.5, 0.5}), mgl32.Vec3{0, 3, 0}, 10)
+	w.Add(box)
+
+	run(w, 3)
+	if !box.Sleeping {
+		t.Fatalf("box still awake after 3s, velocity %v", box.Velocity)
+	}
+	if !near(box.Position.Y(), 0.5, 2*slop) {
+		t.Errorf("box rests at y = %v, want 0.5", box.Position.Y())
+	}
+}
+
+func TestStackSleeps(t *testing.T) {
+	w := newGround()
+	var boxes []*Body
+	for i := 0; i < 4; i++ {
+		box := NewBody(NewBox(mgl32.Vec3{0.5, 0.5, 0.5}), mgl32.Vec3{0, 0.5 + float32(i), 0}, 10)
+		w.Add(box)
+		boxes = append(boxes, box)
+	}
+
+	run(w, 10)
+	for i, box := range boxes {
+		if !box.Sleeping {
+			t.Errorf("box %d of the stack still awake after 10s, velocity %v", i, box.Velocity)
+		}
+		if want := 0.5 + float32(i); !near(box.Position.Y(), want, 0.05) {
+			t.Errorf("box %d rests at y = %v, want %v", i, box.Position.Y(), want)
+		}
+	}
+
+	// A fast hit on the top box wakes the whole stack.
+	ball := NewBody(NewSphere(0.25), mgl32.Vec3{-2, 3.5, 0}, 5)
+	ball.Velocity = mgl32.Vec3{10, 0, 0}
+	w.Add(ball)
+	run(w, 0.25)
+	for i, box := range boxes {
+		if box.Sleeping {
+			t.Errorf("box %d of the stack still asleep after being hit", i)
+		}
+	}
+}
+
+func TestRestitutionBounceHeight(t *testing.T) {
+	for _, restitution := range []float32{0, 0.5, 0.8} {
+		w := newGround()
+		w.Bodies[0].Restitution = 0
+		ball := NewBody(NewSphere(0.5), mgl32.Vec3{0, 5.5, 0}, 1)
+		ball.Restitution = restitution
+		w.Add(ball)
+
+		// Fall onto the ground, then track the top of the first bounce.
+		landed := false
+		top := float32(0)
+		for t := float32(0); t < 4; t += w.Step {
+			w.Simulate(w.Step)
+			height := ball.Position.Y() - 0.5
+			if !landed {
+				landed = height < 0.1
+				continue
+			}
+			if height > top {
+				top = height
+			}
+			if ball.Velocity.Y() < 0 && top > 0 {
+				break
+			}
+		}
+
+		// Bounce height goes with the square of the rebound speed.
+		if want := 5 * restitution * restitution; !near(top, want, 0.1+0.1*want) {
+			t.Errorf("restitution %v bounced %v high, want %v", restitution, top, want)
+		}
+	}
+}
+
+func TestFrictionStopsSlide(t *testing.T) {
+	w := newGround()
+	box := NewBody(NewBox(mgl32.Vec3{0.5, 0.5, 0.5}), mgl32.Vec3{0, 0.5, 0}, 10)
+	box.Velocity = mgl32.Vec3{5, 0, 0}
+	w.Add(box)
+
+	run(w, 3)
+	if speed := box.Velocity.Len(); speed > w.SleepSpeed {
+		t.Fatalf("box still sliding at %v after 3s", speed)
+	}
+	// Both friction coefficients are 0.5, so it decelerates at 0.5 g and slides v² / g.
+	if want := float32(5 * 5 / 9.81); !near(box.Position.X(), want, 0.1*want) {
+		t.Errorf("box slid %v, want %v", box.Position.X(), want)
+	}
+
+	// Without friction it keeps going.
+	w = newGround()
+	w.Bodies[0].Friction = 0
+	box = NewBody(NewBox(mgl32.Vec3{0.5, 0.5, 0.5}), mgl32.Vec3{0, 0.5, 0}, 10)
+	box.Friction = 0
+	box.Velocity = mgl32.Vec3{5, 0, 0}
+	w.Add(box)
+	run(w, 1)
+	if !near(box.Velocity.X(), 5, 0.01) {
+		t.Errorf("frictionless box slowed to %v", box.Velocity.X())
+	}
+}
+
+func TestTerrainPushOut(t *testing.T) {
+	wall := int32(3)
+	w := NewWorld()
+	w.Terrain = ground{wall: &wall}
+
+	// Sunk into the floor.
+	box := NewBody(NewBox(mgl32.Vec3{0.5, 0.5, 0.5}), mgl32.Vec3{0.2, 0.2, 0.5}, 10)
+	// Sunk into the wall, and resting on the floor.
+	ball := NewBody(NewSphere(0.5), mgl32.Vec3{2.8, 0.5, 0.5}, 10)
+	// A capsule sunk into the corner of both.
+	capsule := NewBody(NewCapsule(0.4, 2), mgl32.Vec3{2.8, 0.8, 5.5}, 10)
+	w.Add(box)
+	w.Add(ball)
+	w.Add(capsule)
+
+	run(w, 2)
+	if !near(box.Position.Y(), 0.5, 2*slop) {
+		t.Errorf("box pushed out to y = %v, want 0.5", box.Position.Y())
+	}
+	if !near(ball.Position.X(), 2.5, 2*slop) || !near(ball.Position.Y(), 0.5, 2*slop) {
+		t.Errorf("ball pushed out to %v, want x = 2.5, y = 0.5", ball.Position)
+	}
+	if !near(capsule.Position.X(), 2.6, 2*slop) || !near(capsule.Position.Y(), 1, 2*slop) {
+		t.Errorf("capsule pushed out to %v, want x = 2.6, y = 1", capsule.Position)
+	}
+}
@@ -0,0 +1,88 @@
+package postprocess
+
+import (
+	"github.com/go-gl/gl/v4.1-core/gl"
+	"github.com/go-gl/mathgl/mgl32"
+
+	"github.com/brandonnelson3/GoPlay/assetmanager"
+	"github.com/brandonnelson3/GoPlay/framebuffer"
+	"github.com/brandonnelson3/GoPlay/shaders"
+	"github.com/brandonnelson3/GoPlay/texture"
+)
+
+// Bloom makes bright areas glow by blurring everything above Threshold at half resolution and adding it
+// back on top. It needs HDR input, so it runs before tone mapping.
+type Bloom struct {
+	// Threshold is the brightness above which pixels start to bloom.
+	Threshold float32
+	// Intensity scales the glow added back to the scene.
+	Intensity float32
+	// Iterations is how many times the horizontal and vertical blur run, each widening the glow.
+	Iterations int
+
+	extract, blur, combine *shaders.PostShader
+	// Half resolution targets the blur ping-pongs between.
+	a, b *framebuffer.Framebuffer
+}
+
+func (b *Bloom) Init() (err error) {
+	if b.extract, err = assetmanager.M.PostShader("bloom_extract"); err != nil {
+		return err
+	}
+	if b.blur, err = assetmanager.M.PostShader("blur"); err != nil {
+		return err
+	}
+	if b.combine, err = assetmanager.M.PostShader("bloom_combine"); err != nil {
+		return err
+	}
+	b.combine.Activate()
+	b.combine.SetSampler("bloom", 1)
+	return nil
+}
+
+func (b *Bloom) allocate(width, height int32) {
+	if b.a.Matches(width, height, 0) {
+		return
+	}
+	if b.a != nil {
+		b.a.Delete()
+		b.b.Delete()
+	}
+	c := framebuffer.Config{Width: width, Height: height, Color: []uint32{hdrFormat}}
+	b.a = mustNew(c)
+	b.b = mustNew(c)
+}
+
+func (b *Bloom) Apply(in texture.Texture, out *framebuffer.Framebuffer) {
+	b.allocate(M.scene.Width/2, M.scene.Height/2)
+
+	b.a.Bind()
+	b.extract.Activate()
+	b.extract.SetFloat("threshold", b.Threshold)
+	in.Bind(gl.TEXTURE0)
+	shaders.DrawFullscreen()
+
+	texel := mgl32.Vec2{1 / float32(b.a.Width), 1 / float32(b.a.Height)}
+	b.blur.Activate()
+	for i := 0; i < b.Iterations; i++ {
+		b.b.Bind()
+		b.blur.SetVec2("direction", mgl32.Vec2{texel.X(), 0})
+		t := b.a.Color(0)
+		t.Bind(gl.TEXTURE0)
+		shaders.DrawFullscreen()
+
+		b.a.Bind()
+		b.blur.SetVec2("direction", mgl32.Vec2{0, texel.Y()})
+		t = b.b.Color(0)
+		t.Bind(gl.TEXTURE0)
+		shaders.DrawFullscreen()
+	}
+
+	bind(out)
+	b.combine.Activate()
+	b.combine.SetFloat("intensity", b.Intensity)
+	in.Bind(gl.TEXTURE0)
+	bloom := b.a.Color(0)
+	bloom.Bind(gl.TEXTURE1)
+	shaders.DrawFullscreen()
+}
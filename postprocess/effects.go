@@ -0,0 +1,123 @@
+package postprocess
+
+import (
+	"log"
+
+	"github.com/go-gl/gl/v4.1-core/gl"
+
+	"github.com/brandonnelson3/GoPlay/assetmanager"
+	"github.com/brandonnelson3/GoPlay/framebuffer"
+	"github.com/brandonnelson3/GoPlay/shaders"
+	"github.com/brandonnelson3/GoPlay/texture"
+)
+
+// Tonemap maps HDR color into 0..1 with a filmic curve.
+type Tonemap struct {
+	// Exposure scales color before the curve.
+	Exposure float32
+
+	shader *shaders.PostShader
+}
+
+func (t *Tonemap) Init() (err error) {
+	t.shader, err = assetmanager.M.PostShader("tonemap")
+	return err
+}
+
+func (t *Tonemap) Apply(in texture.Texture, out *framebuffer.Framebuffer) {
+	bind(out)
+	t.shader.Activate()
+	t.shader.SetFloat("exposure", t.Exposure)
+	in.Bind(gl.TEXTURE0)
+	shaders.DrawFullscreen()
+}
+
+// Gamma encodes linear color for display.
+type Gamma struct {
+	Gamma float32
+
+	shader *shaders.PostShader
+}
+
+func (g *Gamma) Init() (err error) {
+	g.shader, err = assetmanager.M.PostShader("gamma")
+	return err
+}
+
+func (g *Gamma) Apply(in texture.Texture, out *framebuffer.Framebuffer) {
+	bind(out)
+	g.shader.Activate()
+	g.shader.SetFloat("gamma", g.Gamma)
+	in.Bind(gl.TEXTURE0)
+	shaders.DrawFullscreen()
+}
+
+// ColorGrading remaps color through a lookup table. See assets/shaders/post/grade.frag for the layout.
+type ColorGrading struct {
+	// LUT is the asset path of the lookup table. It may be changed at any time.
+	LUT string
+	// Strength blends between the original color at 0 and the graded one at 1.
+	Strength float32
+
+	shader *shaders.PostShader
+	lut    *assetmanager.Texture
+	loaded string
+}
+
+func (c *ColorGrading) Init() (err error) {
+	if c.shader, err = assetmanager.M.PostShader("grade"); err != nil {
+		return err
+	}
+	c.shader.Activate()
+	c.shader.SetSampler("lut", 1)
+	return nil
+}
+
+// load swaps in the lookup table named by LUT if it changed, keeping the previous one if it fails.
+func (c *ColorGrading) load() {
+	if c.LUT == c.loaded {
+		return
+	}
+	lut, err := assetmanager.M.Texture(c.LUT)
+	if err != nil {
+		log.Printf("Failed to load color grading LUT %v: %v", c.LUT, err)
+		c.LUT = c.loaded
+		return
+	}
+	if c.lut != nil {
+		assetmanager.M.Release(c.lut)
+	}
+	c.lut, c.loaded = lut, c.LUT
+}
+
+func (c *ColorGrading) Apply(in texture.Texture, out *framebuffer.Framebuffer) {
+	c.load()
+	bind(out)
+	c.shader.Activate()
+	strength := c.Strength
+	if c.lut == nil {
+		strength = 0
+	} else {
+		c.lut.Bind(gl.TEXTURE1)
+	}
+	c.shader.SetFloat("strength", strength)
+	in.Bind(gl.TEXTURE0)
+	shaders.DrawFullscreen()
+}
+
+// FXAA smooths aliased edges. It expects tone mapped input.
+type FXAA struct {
+	shader *shaders.PostShader
+}
+
+func (f *FXAA) Init() (err error) {
+	f.shader, err = assetmanager.M.PostShader("fxaa")
+	return err
+}
+
+func (f *FXAA) Apply(in texture.Texture, out *framebuffer.Framebuffer) {
+	bind(out)
+	f.shader.Activate()
+	in.Bind(gl.TEXTURE0)
+	shaders.DrawFullscreen()
+}
@@ -0,0 +1,194 @@
+package postprocess
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/go-gl/gl/v4.1-core/gl"
+	"github.com/go-gl/glfw/v3.1/glfw"
+
+	"github.com/brandonnelson3/GoPlay/framebuffer"
+	"github.com/brandonnelson3/GoPlay/input"
+	"github.com/brandonnelson3/GoPlay/texture"
+	"github.com/brandonnelson3/GoPlay/window"
+)
+
+// Global post-processing manager
+var M manager
+
+// Effect is one step of the post-processing chain.
+type Effect interface {
+	// Init loads the effect's shaders. Called once on the render thread.
+	Init() error
+	// Apply draws in through the effect into out, or the window if out is nil.
+	Apply(in texture.Texture, out *framebuffer.Framebuffer)
+}
+
+// Pass is an Effect in the chain that can be switched on and off at runtime.
+type Pass struct {
+	Name    string
+	Enabled bool
+	Effect  Effect
+}
+
+// hdrFormat is used for the scene and every intermediate target, so bright values survive until tone
+// mapping.
+const hdrFormat = gl.RGBA16F
+
+type manager struct {
+	// Samples is the MSAA sample count of the scene. 0 or 1 turns MSAA off.
+	Samples int32
+
+	Bloom        *Bloom
+	Tonemap      *Tonemap
+	Gamma        *Gamma
+	ColorGrading *ColorGrading
+	FXAA         *FXAA
+
+	// Passes run in order, each reading the previous one's output.
+	Passes []*Pass
+
+	scene    *framebuffer.Framebuffer
+	resolved *framebuffer.Framebuffer
+	ping     *framebuffer.Framebuffer
+	pong     *framebuffer.Framebuffer
+}
+
+func init() {
+	M = manager{
+		Samples:      4,
+		Bloom:        &Bloom{Threshold: 1, Intensity: 0.6, Iterations: 3},
+		Tonemap:      &Tonemap{Exposure: 1},
+		Gamma:        &Gamma{Gamma: 2.2},
+		ColorGrading: &ColorGrading{LUT: "luts/identity.png", Strength: 1},
+		FXAA:         &FXAA{},
+	}
+	M.Passes = []*Pass{
+		{Name: "bloom", Enabled: true, Effect: M.Bloom},
+		{Name: "tonemap", Enabled: true, Effect: M.Tonemap},
+		// Textures and colors are still authored in display space, so lit results already are too.
+		{Name: "gamma", Enabled: false, Effect: M.Gamma},
+		{Name: "color grading", Enabled: false, Effect: M.ColorGrading},
+		{Name: "fxaa", Enabled: false, Effect: M.FXAA},
+	}
+	// F6 onwards toggle each pass in order.
+	for i, p := range M.Passes {
+		input.M.Register(glfw.KeyF6+glfw.Key(i), toggle(p))
+	}
+}
+
+func toggle(p *Pass) func(bool, float32) {
+	return func(repeat bool, _ float32) {
+		if repeat {
+			return
+		}
+		p.Enabled = !p.Enabled
+		log.Printf("Post-processing %v: %v", p.Name, p.Enabled)
+	}
+}
+
+// Init loads every pass's shaders. Must be called on the render thread.
+func (m *manager) Init() error {
+	for _, p := range m.Passes {
+		if err := p.Effect.Init(); err != nil {
+			return fmt.Errorf("failed to initialise %v pass: %v", p.Name, err)
+		}
+	}
+	return nil
+}
+
+// allocate (re)creates the scene and intermediate targets whenever the window size or sample count
+// changes.
+func (m *manager) allocate() {
+	width, height := int32(window.M.Width), int32(window.M.Height)
+	samples := m.Samples
+	if samples < 2 {
+		samples = 0
+	}
+	if m.scene.Matches(width, height, samples) {
+		return
+	}
+	for _, f := range []*framebuffer.Framebuffer{m.scene, m.resolved, m.ping, m.pong} {
+		if f != nil {
+			f.Delete()
+		}
+	}
+	m.resolved = nil
+
+	var err error
+	m.scene, err = framebuffer.New(framebuffer.Config{Width: width, Height: height, Samples: samples, Color: []uint32{hdrFormat}, Depth: true})
+	if err != nil && samples > 0 {
+		log.Printf("Falling back to no MSAA: %v", err)
+		m.Samples, samples = 0, 0
+		m.scene, err = framebuffer.New(framebuffer.Config{Width: width, Height: height, Color: []uint32{hdrFormat}, Depth: true})
+	}
+	if err != nil {
+		log.Fatalf("Failed to create the scene framebuffer: %v", err)
+	}
+	single := framebuffer.Config{Width: width, Height: height, Color: []uint32{hdrFormat}}
+	if samples > 0 {
+		m.resolved = mustNew(single)
+	}
+	m.ping = mustNew(single)
+	m.pong = mustNew(single)
+}
+
+func mustNew(c framebuffer.Config) *framebuffer.Framebuffer {
+	f, err := framebuffer.New(c)
+	if err != nil {
+		log.Fatalf("Failed to create a post-processing framebuffer: %v", err)
+	}
+	return f
+}
+
+// Begin binds and clears the HDR scene target. Everything drawn until End goes through the chain.
+func (m *manager) Begin() {
+	m.allocate()
+	m.scene.Bind()
+	gl.Clear(gl.COLOR_BUFFER_BIT | gl.DEPTH_BUFFER_BIT)
+}
+
+// End resolves the scene and runs each enabled pass, with the last one drawing to the window.
+func (m *manager) End() {
+	source := m.scene
+	if m.resolved != nil {
+		m.scene.Resolve(m.resolved)
+		source = m.resolved
+	}
+
+	var enabled []*Pass
+	for _, p := range m.Passes {
+		if p.Enabled {
+			enabled = append(enabled, p)
+		}
+	}
+	if len(enabled) == 0 {
+		source.Resolve(nil)
+		framebuffer.BindDefault()
+		return
+	}
+
+	gl.Disable(gl.DEPTH_TEST)
+	in := source.Color(0)
+	targets := [2]*framebuffer.Framebuffer{m.ping, m.pong}
+	for i, p := range enabled {
+		var out *framebuffer.Framebuffer
+		if i < len(enabled)-1 {
+			out = targets[i%2]
+		}
+		p.Effect.Apply(in, out)
+		if out != nil {
+			in = out.Color(0)
+		}
+	}
+	gl.Enable(gl.DEPTH_TEST)
+}
+
+// bind makes out, or the window if it is nil, the render target.
+func bind(out *framebuffer.Framebuffer) {
+	if out == nil {
+		framebuffer.BindDefault()
+		return
+	}
+	out.Bind()
+}
@@ -0,0 +1,131 @@
+package scene
+
+import (
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// Node is a point in the scene graph. Its transform is a translation, rotation and scale relative to its
+// parent, and it draws its Renderable, if any, with the combined world transform.
+type Node struct {
+	Name       string
+	Renderable Renderable
+
+	position mgl32.Vec3
+	rotation mgl32.Quat
+	scale    mgl32.Vec3
+
+	parent   *Node
+	children []*Node
+
+	// world caches the world transform. dirty is set on a node and everything below it whenever a
+	// transform above changes, so it is only rebuilt when read.
+	world mgl32.Mat4
+	dirty bool
+}
+
+// NewNode returns a node at its parent's origin, drawing r. r may be nil for nodes that only group others.
+func NewNode(name string, r Renderable) *Node {
+	return &Node{
+		Name:       name,
+		Renderable: r,
+		rotation:   mgl32.QuatIdent(),
+		scale:      mgl32.Vec3{1, 1, 1},
+		dirty:      true,
+	}
+}
+
+func (n *Node) Position() mgl32.Vec3 {
+	return n.position
+}
+
+func (n *Node) SetPosition(p mgl32.Vec3) {
+	n.position = p
+	n.markDirty()
+}
+
+func (n *Node) Rotation() mgl32.Quat {
+	return n.rotation
+}
+
+func (n *Node) SetRotation(q mgl32.Quat) {
+	n.rotation = q.Normalize()
+	n.markDirty()
+}
+
+func (n *Node) Scale() mgl32.Vec3 {
+	return n.scale
+}
+
+func (n *Node) SetScale(s mgl32.Vec3) {
+	n.scale = s
+	n.markDirty()
+}
+
+func (n *Node) markDirty() {
+	if n.dirty {
+		// Everything below is already dirty.
+		return
+	}
+	n.dirty = true
+	for _, c := range n.children {
+		c.markDirty()
+	}
+}
+
+// Local returns the transform relative to the parent.
+func (n *Node) Local() mgl32.Mat4 {
+	return mgl32.Translate3D(n.position.Elem()).Mul4(n.rotation.Mat4()).Mul4(mgl32.Scale3D(n.scale.Elem()))
+}
+
+// World returns the transform from the node's space to world space.
+func (n *Node) World() mgl32.Mat4 {
+	if n.dirty {
+		n.world = n.Local()
+		if n.parent != nil {
+			n.world = n.parent.World().Mul4(n.world)
+		}
+		n.dirty = false
+	}
+	return n.world
+}
+
+func (n *Node) Parent() *Node {
+	return n.parent
+}
+
+func (n *Node) Children() []*Node {
+	return n.children
+}
+
+// AddChild attaches c below n, detaching it from its previous parent first. Its local transform is kept,
+// so it moves with its new parent.
+func (n *Node) AddChild(c *Node) {
+	if c.parent != nil {
+		c.parent.RemoveChild(c)
+	}
+	c.parent = n
+	n.children = append(n.children, c)
+	c.dirty = false
+	c.markDirty()
+}
+
+// RemoveChild detaches c from n. It does nothing if c isn't a child of n.
+func (n *Node) RemoveChild(c *Node) {
+	for i, child := range n.children {
+		if child == c {
+			n.children = append(n.children[:i], n.children[i+1:]...)
+			c.parent = nil
+			c.dirty = false
+			c.markDirty()
+			return
+		}
+	}
+}
+
+// walk calls f on n and everything below it, parents before children.
+func (n *Node) walk(f func(*Node)) {
+	f(n)
+	for _, c := range n.children {
+		c.walk(f)
+	}
+}
@@ -0,0 +1,170 @@
+package scene
+
+import (
+	"github.com/go-gl/mathgl/mgl32"
+
+	"github.com/brandonnelson3/GoPlay/camera"
+	"github.com/brandonnelson3/GoPlay/debugdraw"
+	"github.com/brandonnelson3/GoPlay/postprocess"
+	"github.com/brandonnelson3/GoPlay/shaders"
+	"github.com/brandonnelson3/GoPlay/shadows"
+	"github.com/brandonnelson3/GoPlay/sky"
+)
+
+// View is the camera a frame is drawn from.
+type View struct {
+	Projection mgl32.Mat4
+	View       mgl32.Mat4
+	Position   mgl32.Vec3
+}
+
+// Renderable draws something with the world transform of the node it is attached to.
+type Renderable interface {
+	Render(v *View, world mgl32.Mat4)
+}
+
+// ShadowCaster is a Renderable that also draws into the sun's shadow map.
+type ShadowCaster interface {
+	RenderShadow(lightSpace, world mgl32.Mat4)
+}
+
+// TranslucentRenderable is a Renderable with blended parts, drawn after all opaque geometry and the sky.
+type TranslucentRenderable interface {
+	RenderTranslucent(v *View, world mgl32.Mat4)
+}
+
+// Updater is a Renderable that changes over time, such as by moving its node.
+type Updater interface {
+	Update(n *Node, elapsed float64)
+}
+
+// Instanced is a Renderable that is drawn together with every other one sharing its BatchKey, in a single
+// instanced draw. Scenes batch these automatically, Render is only used for drawing one on its own.
+type Instanced interface {
+	Renderable
+	// BatchKey identifies the mesh and material. Renderables with equal keys must draw the same apart from
+	// their instance data, as only one of them draws the whole batch.
+	BatchKey() interface{}
+	// InstanceColor is multiplied into the material color of this copy.
+	InstanceColor() mgl32.Vec4
+	// RenderInstances draws a copy per instance.
+	RenderInstances(v *View, instances []shaders.Instance)
+}
+
+// InstancedShadowCaster is an Instanced renderable that is also batched into the shadow map.
+type InstancedShadowCaster interface {
+	RenderShadowInstances(lightSpace mgl32.Mat4, instances []shaders.Instance)
+}
+
+// Scene is a graph of nodes drawn together.
+type Scene struct {
+	Root *Node
+
+	batches batcher
+}
+
+// batcher collects the instances of each batch key during a walk. Its slices are reused from frame to
+// frame.
+type batcher struct {
+	index   map[interface{}]int
+	batches []batch
+}
+
+type batch struct {
+	first     Instanced
+	instances []shaders.Instance
+}
+
+func (b *batcher) reset() {
+	for i := range b.batches {
+		b.batches[i].first = nil
+		b.batches[i].instances = b.batches[i].instances[:0]
+	}
+}
+
+func (b *batcher) add(r Instanced, world mgl32.Mat4) {
+	if b.index == nil {
+		b.index = make(map[interface{}]int)
+	}
+	key := r.BatchKey()
+	i, ok := b.index[key]
+	if !ok {
+		i = len(b.batches)
+		b.index[key] = i
+		b.batches = append(b.batches, batch{})
+	}
+	bt := &b.batches[i]
+	if bt.first == nil {
+		bt.first = r
+	}
+	bt.instances = append(bt.instances, shaders.Instance{Model: world, Color: r.InstanceColor()})
+}
+
+func New() *Scene {
+	return &Scene{Root: NewNode("root", nil)}
+}
+
+// Update lets every Updater in the graph advance by elapsed seconds.
+func (s *Scene) Update(elapsed float64) {
+	s.Root.walk(func(n *Node) {
+		if u, ok := n.Renderable.(Updater); ok {
+			u.Update(n, elapsed)
+		}
+	})
+}
+
+// RenderShadow draws every ShadowCaster in the graph, so the scene can be passed to shadows.M.Render.
+func (s *Scene) RenderShadow(lightSpace mgl32.Mat4) {
+	s.batches.reset()
+	s.Root.walk(func(n *Node) {
+		if i, ok := n.Renderable.(Instanced); ok {
+			if _, ok := i.(InstancedShadowCaster); ok {
+				s.batches.add(i, n.World())
+				return
+			}
+		}
+		if c, ok := n.Renderable.(ShadowCaster); ok {
+			c.RenderShadow(lightSpace, n.World())
+		}
+	})
+	for _, b := range s.batches.batches {
+		if b.first != nil {
+			b.first.(InstancedShadowCaster).RenderShadowInstances(lightSpace, b.instances)
+		}
+	}
+}
+
+// Render draws a whole frame from c: shadow maps, opaque geometry, the sky, translucent geometry, debug
+// shapes and finally post-processing to the window.
+func (s *Scene) Render(c *camera.FPS) {
+	v := &View{
+		Projection: c.GetProjectionMatrix(),
+		View:       c.GetViewMatrix(),
+		Position:   c.GetPosition(),
+	}
+
+	shadows.M.Render(s)
+
+	postprocess.M.Begin()
+	s.batches.reset()
+	s.Root.walk(func(n *Node) {
+		if i, ok := n.Renderable.(Instanced); ok {
+			s.batches.add(i, n.World())
+		} else if n.Renderable != nil {
+			n.Renderable.Render(v, n.World())
+		}
+	})
+	for _, b := range s.batches.batches {
+		if b.first != nil {
+			b.first.RenderInstances(v, b.instances)
+		}
+	}
+	sky.M.Render()
+	s.Root.walk(func(n *Node) {
+		if t, ok := n.Renderable.(TranslucentRenderable); ok {
+			t.RenderTranslucent(v, n.World())
+		}
+	})
+	debugdraw.M.Flush(v.Projection, v.View)
+	postprocess.M.End()
+}
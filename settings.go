@@ -0,0 +1,72 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/go-gl/mathgl/mgl32"
+
+	"github.com/brandonnelson3/GoPlay/camera"
+	"github.com/brandonnelson3/GoPlay/debugdraw"
+	"github.com/brandonnelson3/GoPlay/fog"
+	"github.com/brandonnelson3/GoPlay/postprocess"
+	"github.com/brandonnelson3/GoPlay/shadows"
+	"github.com/brandonnelson3/GoPlay/sky"
+	"github.com/brandonnelson3/GoPlay/ui"
+)
+
+// worldSizer is the part of the terrain the settings panel changes.
+type worldSizer interface {
+	WorldSize() int32
+	SetWorldSize(worldSize int32)
+}
+
+// teleport is what has been typed into the settings panel's position box.
+var teleport string
+
+// drawSettings shows the settings panel while the UI is open.
+func drawSettings(terrain worldSizer, showCells *bool) {
+	if !ui.Begin("Settings", 20, 100, 360) {
+		return
+	}
+
+	ui.Label("Camera")
+	ui.SliderFloat("Speed", &camera.C.Speed, 1, 200)
+	ui.SliderFloat("Field of view", &camera.C.FOVDegrees, 20, 120)
+	ui.TextInput("Position", &teleport)
+	if ui.Button("Teleport") {
+		var p mgl32.Vec3
+		if _, err := fmt.Sscan(strings.Replace(teleport, ",", " ", -1), &p[0], &p[1], &p[2]); err != nil {
+			log.Printf("Can't teleport to %q: %v", teleport, err)
+		} else {
+			camera.C.SetPosition(p)
+		}
+	}
+
+	ui.Label("Terrain")
+	worldSize := int(terrain.WorldSize())
+	if ui.SliderInt("World size", &worldSize, 2, 10) {
+		terrain.SetWorldSize(int32(worldSize))
+	}
+	ui.Checkbox("Show cells", showCells)
+
+	ui.Label("Lighting")
+	ui.SliderFloat("Time of day", &sky.M.TimeOfDay, 0, 24)
+	ui.Checkbox("Pause time", &sky.M.Paused)
+	ui.SliderFloat("Exposure", &postprocess.M.Tonemap.Exposure, 0.1, 4)
+	ui.SliderFloat("Bloom", &postprocess.M.Bloom.Intensity, 0, 2)
+
+	ui.Label("Renderer")
+	if ui.Button("Fog: " + fog.M.Mode.String()) {
+		fog.M.NextMode()
+	}
+	ui.SliderFloat("Fog distance (0 is auto)", &fog.M.Distance, 0, 2000)
+	ui.Label(fmt.Sprintf("Fog solid at %.0f", fog.M.CurrentDistance()))
+	ui.Checkbox("Shadow cascades", &shadows.M.Debug)
+	ui.Checkbox("Debug drawing", &debugdraw.M.Enabled)
+	for _, p := range postprocess.M.Passes {
+		ui.Checkbox(p.Name, &p.Enabled)
+	}
+	ui.End()
+}
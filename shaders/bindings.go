@@ -0,0 +1,13 @@
+package shaders
+
+// Uniform buffer binding points shared by every program.
+const (
+	LightsBlockBinding  = 0
+	ShadowsBlockBinding = 1
+	FogBlockBinding     = 2
+)
+
+// Texture units reserved for engine wide textures. Material textures start at unit 0.
+const (
+	ShadowMapUnit = 4
+)
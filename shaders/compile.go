@@ -0,0 +1,159 @@
+package shaders
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/go-gl/gl/v4.1-core/gl"
+)
+
+// sourceLine records where a line of preprocessed source came from.
+type sourceLine struct {
+	file string
+	line int
+	text string
+}
+
+// source is GLSL with its #include directives expanded.
+type source struct {
+	lines []sourceLine
+	// Every file read to build the source, starting with the stage's own file.
+	files []string
+}
+
+var includeDirective = regexp.MustCompile(`^\s*#include\s+"([^"]+)"\s*$`)
+
+// readSource reads a GLSL file, expanding `#include "file"` directives relative to the including file.
+func readSource(file string) (source, error) {
+	var src source
+	err := src.read(file, nil)
+	return src, err
+}
+
+func (src *source) read(file string, stack []string) error {
+	for _, f := range stack {
+		if f == file {
+			return fmt.Errorf("%v includes itself via %v", file, strings.Join(stack, " -> "))
+		}
+	}
+	b, err := ioutil.ReadFile(file)
+	if err != nil {
+		return err
+	}
+	src.files = append(src.files, file)
+	for i, l := range strings.Split(string(b), "\n") {
+		if m := includeDirective.FindStringSubmatch(l); m != nil {
+			if err := src.read(filepath.Join(filepath.Dir(file), m[1]), append(stack, file)); err != nil {
+				return fmt.Errorf("%v:%d: %v", file, i+1, err)
+			}
+			continue
+		}
+		src.lines = append(src.lines, sourceLine{file, i + 1, l})
+	}
+	return nil
+}
+
+// inlineSource wraps a source string that didn't come from a file.
+func inlineSource(file, text string) source {
+	var src source
+	for i, l := range strings.Split(strings.TrimSuffix(text, "\x00"), "\n") {
+		src.lines = append(src.lines, sourceLine{file, i + 1, l})
+	}
+	return src
+}
+
+// String returns the expanded source, null terminated for gl.Strs.
+func (src source) String() string {
+	text := make([]string, len(src.lines))
+	for i, l := range src.lines {
+		text[i] = l.text
+	}
+	return strings.Join(text, "\n") + "\x00"
+}
+
+func compileShader(kind uint32, src source) (uint32, error) {
+	id := gl.CreateShader(kind)
+	cSrc, free := gl.Strs(src.String())
+	defer free()
+	gl.ShaderSource(id, 1, cSrc, nil)
+	gl.CompileShader(id)
+	var status int32
+	gl.GetShaderiv(id, gl.COMPILE_STATUS, &status)
+	if status == gl.FALSE {
+		var logLength int32
+		gl.GetShaderiv(id, gl.INFO_LOG_LENGTH, &logLength)
+		log := strings.Repeat("\x00", int(logLength+1))
+		gl.GetShaderInfoLog(id, logLength, nil, gl.Str(log))
+		gl.DeleteShader(id)
+		return 0, fmt.Errorf("failed to compile %v:\n%v", src.lines[0].file, formatLog(src, log))
+	}
+	return id, nil
+}
+
+// attribute binds a vertex attribute name to a fixed location so programs can be relinked without
+// invalidating existing vertex arrays.
+type attribute struct {
+	name     string
+	location uint32
+}
+
+func linkProgram(shaderIDs []uint32, attributes []attribute, outputs []string) (uint32, error) {
+	pID := gl.CreateProgram()
+	for _, id := range shaderIDs {
+		gl.AttachShader(pID, id)
+	}
+	for _, a := range attributes {
+		gl.BindAttribLocation(pID, a.location, gl.Str(a.name+"\x00"))
+	}
+	for i, o := range outputs {
+		gl.BindFragDataLocation(pID, uint32(i), gl.Str(o+"\x00"))
+	}
+	gl.LinkProgram(pID)
+	for _, id := range shaderIDs {
+		gl.DetachShader(pID, id)
+		gl.DeleteShader(id)
+	}
+	var status int32
+	gl.GetProgramiv(pID, gl.LINK_STATUS, &status)
+	if status == gl.FALSE {
+		var logLength int32
+		gl.GetProgramiv(pID, gl.INFO_LOG_LENGTH, &logLength)
+		log := strings.Repeat("\x00", int(logLength+1))
+		gl.GetProgramInfoLog(pID, logLength, nil, gl.Str(log))
+		gl.DeleteProgram(pID)
+		return 0, fmt.Errorf("failed to link program: %v", log)
+	}
+	return pID, nil
+}
+
+// logLine matches the location prefix drivers put on each info log line, for example "0:12(5): error"
+// (Mesa), "0(12) : error" (NVIDIA) or "ERROR: 0:12: " (AMD and Intel).
+var logLine = regexp.MustCompile(`^(?:(?:ERROR|WARNING): )?\d+[:(](\d+)\)?(?:\(\d+\))?\s*:\s*(.*)$`)
+
+// formatLog rewrites a compile log so every message is prefixed with the file and line it came from,
+// following includes, and is followed by the offending source line.
+func formatLog(src source, log string) string {
+	var out []string
+	for _, l := range strings.Split(strings.TrimRight(log, "\x00\n"), "\n") {
+		m := logLine.FindStringSubmatch(l)
+		if m == nil {
+			if l != "" {
+				out = append(out, l)
+			}
+			continue
+		}
+		n, _ := strconv.Atoi(m[1])
+		if n < 1 || n > len(src.lines) {
+			out = append(out, fmt.Sprintf("%v:%d: %v", src.lines[0].file, n, m[2]))
+			continue
+		}
+		line := src.lines[n-1]
+		out = append(out, fmt.Sprintf("%v:%d: %v", line.file, line.line, m[2]))
+		out = append(out, "    > "+strings.TrimSpace(line.text))
+	}
+	return strings.Join(out, "\n")
+}
@@ -0,0 +1,43 @@
+package shaders
+
+import (
+	"github.com/go-gl/gl/v4.1-core/gl"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+const attribLocation_color = 1
+
+// DebugShader draws unlit, vertex colored lines for debug drawing.
+type DebugShader struct {
+	*Program
+}
+
+func NewDebugShader(vertFile, fragFile string) (*DebugShader, error) {
+	p, err := NewProgram(ProgramConfig{
+		Stages: []Stage{
+			{Type: gl.VERTEX_SHADER, File: vertFile},
+			{Type: gl.FRAGMENT_SHADER, File: fragFile},
+		},
+		Attributes: map[string]uint32{
+			"vert":      attribLocation_vert,
+			"vertColor": attribLocation_color,
+		},
+		Outputs: []string{"outputColor"},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &DebugShader{p}, nil
+}
+
+// SetViewProjection sets the camera's combined projection and view matrix.
+func (s *DebugShader) SetViewProjection(d mgl32.Mat4) {
+	s.SetMat4("viewProjection", d)
+}
+
+type DebugShader_Vertex struct {
+	Vert      mgl32.Vec3 `vertex:"0"`
+	VertColor mgl32.Vec4 `vertex:"1"`
+}
+
+var DebugShader_Layout = MustLayoutOf(DebugShader_Vertex{})
@@ -1,160 +1,57 @@
 package shaders
 
 import (
-	"fmt"
-	"strings"
-
-	"unsafe"
-
 	"github.com/go-gl/gl/v4.1-core/gl"
 	"github.com/go-gl/mathgl/mgl32"
 )
 
-const vsSrc = `#version 330
-uniform mat4 projection;
-uniform mat4 view;
-uniform mat4 model;
-
-in vec3 vert;
-in vec2 vertTexCoord;
-
-out vec2 fragTexCoord;
-
-void main() {
-    fragTexCoord = vertTexCoord;
-    gl_Position = projection * view * model * vec4(vert, 1);
-}` + "\x00"
-
-const fsSrc = `#version 330
-uniform sampler2D tex;
-
-in vec2 fragTexCoord;
-
-out vec4 outputColor;
-
-void main() {
-    outputColor = texture(tex, fragTexCoord);
-}` + "\x00"
+// Fixed attribute locations, bound before linking so reloads keep existing vertex arrays valid. These must
+// match the vertex tags on DefaultShader_Vertex.
+const (
+	attribLocation_vert         = 0
+	attribLocation_vertTexCoord = 1
+)
 
+// DefaultShader is an unlit textured program with projection, view and model matrices.
 type DefaultShader struct {
-	id uint32
-
-	uniformLocation_projection int32
-	uniformLocation_view       int32
-	uniformLocation_model      int32
-	uniformLocation_tex        int32
-}
-
-func NewDefaultShader() (*DefaultShader, error) {
-	pID := gl.CreateProgram()
-
-	// Vertex Shader
-	vsID := gl.CreateShader(gl.VERTEX_SHADER)
-	cSrc := gl.Str(string(vsSrc))
-	gl.ShaderSource(vsID, 1, &cSrc, nil)
-	gl.CompileShader(vsID)
-	var status int32
-	gl.GetShaderiv(vsID, gl.COMPILE_STATUS, &status)
-	if status == gl.FALSE {
-		var logLength int32
-		gl.GetShaderiv(vsID, gl.INFO_LOG_LENGTH, &logLength)
-		log := strings.Repeat("\x00", int(logLength+1))
-		gl.GetShaderInfoLog(vsID, logLength, nil, gl.Str(log))
-		return nil, fmt.Errorf("failed to compile vertex shader: %v", log)
+	*Program
+}
+
+func NewDefaultShader(vertFile, fragFile string) (*DefaultShader, error) {
+	p, err := NewProgram(ProgramConfig{
+		Stages: []Stage{
+			{Type: gl.VERTEX_SHADER, File: vertFile},
+			{Type: gl.FRAGMENT_SHADER, File: fragFile},
+		},
+		Attributes: map[string]uint32{
+			"vert":         attribLocation_vert,
+			"vertTexCoord": attribLocation_vertTexCoord,
+		},
+		Outputs: []string{"outputColor"},
+	})
+	if err != nil {
+		return nil, err
 	}
-	gl.AttachShader(pID, vsID)
-
-	// Fragment Shader
-	fsID := gl.CreateShader(gl.FRAGMENT_SHADER)
-	cSrc = gl.Str(string(fsSrc))
-	gl.ShaderSource(fsID, 1, &cSrc, nil)
-	gl.CompileShader(fsID)
-	gl.GetShaderiv(fsID, gl.COMPILE_STATUS, &status)
-	if status == gl.FALSE {
-		var logLength int32
-		gl.GetShaderiv(fsID, gl.INFO_LOG_LENGTH, &logLength)
-		log := strings.Repeat("\x00", int(logLength+1))
-		gl.GetShaderInfoLog(fsID, logLength, nil, gl.Str(log))
-		return nil, fmt.Errorf("failed to compile fragment shader: %v", log)
-	}
-	gl.AttachShader(pID, fsID)
-
-	// Program
-	gl.LinkProgram(pID)
-	gl.GetProgramiv(pID, gl.LINK_STATUS, &status)
-	if status == gl.FALSE {
-		var logLength int32
-		gl.GetProgramiv(pID, gl.INFO_LOG_LENGTH, &logLength)
-		log := strings.Repeat("\x00", int(logLength+1))
-		gl.GetProgramInfoLog(pID, logLength, nil, gl.Str(log))
-
-		return nil, fmt.Errorf("failed to link program: %v", log)
-	}
-
-	// Fragment Outputs
-	gl.BindFragDataLocation(pID, 0, gl.Str("outputColor\x00"))
-
-	result := &DefaultShader{
-		id: pID,
-		uniformLocation_projection: gl.GetUniformLocation(pID, gl.Str("projection\x00")),
-		uniformLocation_view:       gl.GetUniformLocation(pID, gl.Str("view\x00")),
-		uniformLocation_model:      gl.GetUniformLocation(pID, gl.Str("model\x00")),
-		uniformLocation_tex:        gl.GetUniformLocation(pID, gl.Str("tex\x00")),
-	}
-
 	// Set Texture to slot 0
-	gl.Uniform1i(result.uniformLocation_tex, 0)
-
-	return result, nil
-}
-
-func (s *DefaultShader) Activate() {
-	gl.UseProgram(s.id)
+	p.SetSampler("tex", 0)
+	return &DefaultShader{p}, nil
 }
 
 func (s *DefaultShader) SetProjection(d mgl32.Mat4) {
-	gl.UniformMatrix4fv(s.uniformLocation_projection, 1, false, &d[0])
+	s.SetMat4("projection", d)
 }
 
 func (s *DefaultShader) SetView(d mgl32.Mat4) {
-	gl.UniformMatrix4fv(s.uniformLocation_view, 1, false, &d[0])
+	s.SetMat4("view", d)
 }
 
 func (s *DefaultShader) SetModel(d mgl32.Mat4) {
-	gl.UniformMatrix4fv(s.uniformLocation_model, 1, false, &d[0])
+	s.SetMat4("model", d)
 }
 
 type DefaultShader_Vertex struct {
-	Vert         mgl32.Vec3
-	VertTexCoord mgl32.Vec2
+	Vert         mgl32.Vec3 `vertex:"0"`
+	VertTexCoord mgl32.Vec2 `vertex:"1"`
 }
 
-type DefaultShader_VertexBuffer struct {
-	id   uint32
-	Size int32
-}
-
-func NewDefaultShader_VertexBuffer(s *DefaultShader, d []DefaultShader_Vertex) *DefaultShader_VertexBuffer {
-	var vao uint32
-	gl.GenVertexArrays(1, &vao)
-	gl.BindVertexArray(vao)
-
-	var vbo uint32
-	gl.GenBuffers(1, &vbo)
-	gl.BindBuffer(gl.ARRAY_BUFFER, vbo)
-	gl.BufferData(gl.ARRAY_BUFFER, len(d)*20, gl.Ptr(d), gl.STATIC_DRAW)
-
-	vertAttrib := uint32(gl.GetAttribLocation(s.id, gl.Str("vert\x00")))
-	gl.EnableVertexAttribArray(vertAttrib)
-	gl.VertexAttribPointer(vertAttrib, 3, gl.FLOAT, false, int32(unsafe.Sizeof(DefaultShader_Vertex{})), gl.PtrOffset(0))
-
-	texCoordAttrib := uint32(gl.GetAttribLocation(s.id, gl.Str("vertTexCoord\x00")))
-	gl.EnableVertexAttribArray(texCoordAttrib)
-	gl.VertexAttribPointer(texCoordAttrib, 2, gl.FLOAT, false, int32(unsafe.Sizeof(DefaultShader_Vertex{})), gl.PtrOffset(3*4))
-
-	return &DefaultShader_VertexBuffer{id: vao, Size: int32(len(d))}
-}
-
-func (vbo *DefaultShader_VertexBuffer) Activate() {
-	gl.BindVertexArray(vbo.id)
-}
+var DefaultShader_Layout = MustLayoutOf(DefaultShader_Vertex{})
@@ -0,0 +1,57 @@
+package shaders
+
+import (
+	"github.com/go-gl/gl/v4.1-core/gl"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// DepthShader renders depth only, for shadow maps. The vertex stage decides which vertex format it reads;
+// both the float "vert" and the terrain "packed" attributes live at location 0, and skinned vertices add
+// their joints and weights at the same locations as SkinnedShader. Instanced vertices read their model
+// matrix from the same location as InstancedShader. Alpha tested programs read their texture from unit 0.
+type DepthShader struct {
+	*Program
+}
+
+func NewDepthShader(vertFile, fragFile string) (*DepthShader, error) {
+	p, err := NewProgram(ProgramConfig{
+		Stages: []Stage{
+			{Type: gl.VERTEX_SHADER, File: vertFile},
+			{Type: gl.FRAGMENT_SHADER, File: fragFile},
+		},
+		Attributes: map[string]uint32{
+			"vert":          attribLocation_vert,
+			"packed":        attribLocation_packed,
+			"vertJoints":    attribLocation_joints,
+			"vertWeights":   attribLocation_weights,
+			"instanceModel": attribLocation_instanceModel,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	p.SetSampler("blocks", 0)
+	return &DepthShader{p}, nil
+}
+
+// SetLightSpace sets the light's combined view and projection matrix.
+func (s *DepthShader) SetLightSpace(d mgl32.Mat4) {
+	s.SetMat4("lightSpace", d)
+}
+
+func (s *DepthShader) SetModel(d mgl32.Mat4) {
+	s.SetMat4("model", d)
+}
+
+// SetAlphaCutoff discards texels with alpha below cutoff in alpha tested programs.
+func (s *DepthShader) SetAlphaCutoff(cutoff float32) {
+	s.SetFloat("alphaCutoff", cutoff)
+}
+
+// SetJoints sets the skinning matrices of depth programs built from skinned_depth.vert.
+func (s *DepthShader) SetJoints(d []mgl32.Mat4) {
+	if len(d) > MaxJoints {
+		d = d[:MaxJoints]
+	}
+	s.SetMat4s("joints", d)
+}
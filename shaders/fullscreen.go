@@ -0,0 +1,18 @@
+package shaders
+
+import (
+	"github.com/go-gl/gl/v4.1-core/gl"
+)
+
+// Core profile needs a vertex array bound to draw, even with no attributes.
+var fullscreenVAO uint32
+
+// DrawFullscreen draws a single triangle covering the screen. The vertex shader builds the positions from
+// gl_VertexID, see assets/shaders/post/post.vert.
+func DrawFullscreen() {
+	if fullscreenVAO == 0 {
+		gl.GenVertexArrays(1, &fullscreenVAO)
+	}
+	gl.BindVertexArray(fullscreenVAO)
+	gl.DrawArrays(gl.TRIANGLES, 0, 3)
+}
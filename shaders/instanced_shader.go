@@ -0,0 +1,53 @@
+package shaders
+
+import (
+	"github.com/go-gl/gl/v4.1-core/gl"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+const (
+	// instanceModel is a mat4, so it takes locations 5 through 8.
+	attribLocation_instanceModel = 5
+	attribLocation_instanceColor = 9
+)
+
+// InstancedShader is LitShader for drawing many copies of a mesh in one call. The model matrix and a color
+// come from each Instance rather than from uniforms.
+type InstancedShader struct {
+	*LitShader
+}
+
+func NewInstancedShader(vertFile, fragFile string) (*InstancedShader, error) {
+	p, err := NewProgram(ProgramConfig{
+		Stages: []Stage{
+			{Type: gl.VERTEX_SHADER, File: vertFile},
+			{Type: gl.FRAGMENT_SHADER, File: fragFile},
+		},
+		Attributes: map[string]uint32{
+			"vert":          attribLocation_vert,
+			"vertTexCoord":  attribLocation_vertTexCoord,
+			"vertNormal":    attribLocation_normal,
+			"instanceModel": attribLocation_instanceModel,
+			"instanceColor": attribLocation_instanceColor,
+		},
+		Outputs: []string{"outputColor"},
+	})
+	if err != nil {
+		return nil, err
+	}
+	p.SetSampler("tex", 0)
+	p.BindUniformBlock("Lights", LightsBlockBinding)
+	p.BindUniformBlock("Shadows", ShadowsBlockBinding)
+	p.BindUniformBlock("Fog", FogBlockBinding)
+	p.SetSampler("shadowMap", ShadowMapUnit)
+	return &InstancedShader{&LitShader{p}}, nil
+}
+
+// Instance is the per-instance data of InstancedShader and instanced_depth.vert.
+type Instance struct {
+	Model mgl32.Mat4 `vertex:"5"`
+	// Color multiplies the material color.
+	Color mgl32.Vec4 `vertex:"9"`
+}
+
+var Instance_Layout = MustLayoutOf(Instance{}).PerInstance()
@@ -0,0 +1,64 @@
+package shaders
+
+import (
+	"github.com/go-gl/gl/v4.1-core/gl"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+const attribLocation_normal = 2
+
+// LitShader is the lit variant of DefaultShader, shading with the lights in the Lights uniform block.
+type LitShader struct {
+	*Program
+}
+
+func NewLitShader(vertFile, fragFile string) (*LitShader, error) {
+	p, err := NewProgram(ProgramConfig{
+		Stages: []Stage{
+			{Type: gl.VERTEX_SHADER, File: vertFile},
+			{Type: gl.FRAGMENT_SHADER, File: fragFile},
+		},
+		Attributes: map[string]uint32{
+			"vert":         attribLocation_vert,
+			"vertTexCoord": attribLocation_vertTexCoord,
+			"vertNormal":   attribLocation_normal,
+		},
+		Outputs: []string{"outputColor"},
+	})
+	if err != nil {
+		return nil, err
+	}
+	p.SetSampler("tex", 0)
+	p.BindUniformBlock("Lights", LightsBlockBinding)
+	p.BindUniformBlock("Shadows", ShadowsBlockBinding)
+	p.BindUniformBlock("Fog", FogBlockBinding)
+	p.SetSampler("shadowMap", ShadowMapUnit)
+	return &LitShader{p}, nil
+}
+
+func (s *LitShader) SetProjection(d mgl32.Mat4) {
+	s.SetMat4("projection", d)
+}
+
+func (s *LitShader) SetView(d mgl32.Mat4) {
+	s.SetMat4("view", d)
+}
+
+func (s *LitShader) SetModel(d mgl32.Mat4) {
+	s.SetMat4("model", d)
+}
+
+// SetMaterial sets the base color the texture is multiplied by. Untextured materials use the color alone
+// and need no texture bound.
+func (s *LitShader) SetMaterial(color mgl32.Vec4, textured bool) {
+	s.SetVec4("baseColor", color)
+	s.SetBool("textured", textured)
+}
+
+type LitShader_Vertex struct {
+	Vert         mgl32.Vec3 `vertex:"0"`
+	VertTexCoord mgl32.Vec2 `vertex:"1"`
+	VertNormal   mgl32.Vec3 `vertex:"2"`
+}
+
+var LitShader_Layout = MustLayoutOf(LitShader_Vertex{})
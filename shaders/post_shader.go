@@ -0,0 +1,26 @@
+package shaders
+
+import (
+	"github.com/go-gl/gl/v4.1-core/gl"
+)
+
+// PostShader runs one fullscreen post-processing pass. Every pass reads its input from the source sampler
+// on unit 0.
+type PostShader struct {
+	*Program
+}
+
+func NewPostShader(vertFile, fragFile string) (*PostShader, error) {
+	p, err := NewProgram(ProgramConfig{
+		Stages: []Stage{
+			{Type: gl.VERTEX_SHADER, File: vertFile},
+			{Type: gl.FRAGMENT_SHADER, File: fragFile},
+		},
+		Outputs: []string{"outputColor"},
+	})
+	if err != nil {
+		return nil, err
+	}
+	p.SetSampler("source", 0)
+	return &PostShader{p}, nil
+}
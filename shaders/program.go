@@ -0,0 +1,281 @@
+package shaders
+
+import (
+	"log"
+	"strings"
+
+	"github.com/go-gl/gl/v4.1-core/gl"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// Stage is a single shader stage. If Source is empty it is read from File, and the stage is recompiled
+// from File on every Reload. File is also used to label compile errors.
+type Stage struct {
+	Type   uint32
+	File   string
+	Source string
+}
+
+type ProgramConfig struct {
+	Stages []Stage
+
+	// Attributes pins vertex attributes to fixed locations so vertex arrays survive a Reload.
+	Attributes map[string]uint32
+
+	// Outputs names the fragment outputs, bound to color attachments in order.
+	Outputs []string
+}
+
+// Variable describes an active uniform or attribute as reported by the driver.
+type Variable struct {
+	Name     string
+	Location int32
+	Type     uint32
+	Size     int32
+}
+
+type Program struct {
+	id     uint32
+	config ProgramConfig
+
+	// Uniforms and Attributes are the active variables of the linked program, keyed by name. Arrays are
+	// listed under their base name.
+	Uniforms   map[string]Variable
+	Attributes map[string]Variable
+
+	// Locations looked up by name, including array elements not listed in Uniforms.
+	locations map[string]int32
+
+	// Sampler units and uniform block bindings are remembered so they can be restored after a Reload.
+	samplers map[string]int32
+	blocks   map[string]uint32
+
+	// Files pulled in by #include in the last successful compile.
+	includes []string
+}
+
+func NewProgram(config ProgramConfig) (*Program, error) {
+	p := &Program{config: config, samplers: make(map[string]int32), blocks: make(map[string]uint32)}
+	if err := p.Reload(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// Files returns the source files this program is read from, including any they #include.
+func (p *Program) Files() []string {
+	var files []string
+	for _, s := range p.config.Stages {
+		if s.Source == "" {
+			files = append(files, s.File)
+		}
+	}
+	for _, f := range p.includes {
+		files = append(files, f)
+	}
+	return files
+}
+
+// Reload recompiles and relinks the program. On failure the previous program is left in place.
+func (p *Program) Reload() error {
+	var ids []uint32
+	deleteAll := func() {
+		for _, id := range ids {
+			gl.DeleteShader(id)
+		}
+	}
+	var includes []string
+	for _, s := range p.config.Stages {
+		src := inlineSource(s.File, s.Source)
+		if s.Source == "" {
+			var err error
+			if src, err = readSource(s.File); err != nil {
+				deleteAll()
+				return err
+			}
+			for _, f := range src.files[1:] {
+				if !contains(includes, f) {
+					includes = append(includes, f)
+				}
+			}
+		}
+		id, err := compileShader(s.Type, src)
+		if err != nil {
+			deleteAll()
+			return err
+		}
+		ids = append(ids, id)
+	}
+
+	var attributes []attribute
+	for name, location := range p.config.Attributes {
+		attributes = append(attributes, attribute{name, location})
+	}
+	pID, err := linkProgram(ids, attributes, p.config.Outputs)
+	if err != nil {
+		return err
+	}
+
+	if p.id != 0 {
+		gl.DeleteProgram(p.id)
+	}
+	p.id = pID
+	p.includes = includes
+	p.reflect()
+
+	gl.UseProgram(pID)
+	for name, unit := range p.samplers {
+		gl.Uniform1i(p.Location(name), unit)
+	}
+	for name, binding := range p.blocks {
+		p.bindBlock(name, binding)
+	}
+	return nil
+}
+
+func contains(list []string, s string) bool {
+	for _, l := range list {
+		if l == s {
+			return true
+		}
+	}
+	return false
+}
+
+// reflect enumerates the active uniforms and attributes of the linked program.
+func (p *Program) reflect() {
+	p.Uniforms = make(map[string]Variable)
+	p.Attributes = make(map[string]Variable)
+	p.locations = make(map[string]int32)
+
+	var count, maxLength int32
+	gl.GetProgramiv(p.id, gl.ACTIVE_UNIFORMS, &count)
+	gl.GetProgramiv(p.id, gl.ACTIVE_UNIFORM_MAX_LENGTH, &maxLength)
+	for i := uint32(0); i < uint32(count); i++ {
+		v := p.activeVariable(i, maxLength, gl.GetActiveUniform)
+		v.Location = gl.GetUniformLocation(p.id, gl.Str(v.Name+"\x00"))
+		p.Uniforms[v.Name] = v
+		p.locations[v.Name] = v.Location
+	}
+
+	gl.GetProgramiv(p.id, gl.ACTIVE_ATTRIBUTES, &count)
+	gl.GetProgramiv(p.id, gl.ACTIVE_ATTRIBUTE_MAX_LENGTH, &maxLength)
+	for i := uint32(0); i < uint32(count); i++ {
+		v := p.activeVariable(i, maxLength, gl.GetActiveAttrib)
+		v.Location = gl.GetAttribLocation(p.id, gl.Str(v.Name+"\x00"))
+		p.Attributes[v.Name] = v
+	}
+}
+
+type activeFunc func(program, index uint32, bufSize int32, length *int32, size *int32, xtype *uint32, name *uint8)
+
+func (p *Program) activeVariable(i uint32, maxLength int32, f activeFunc) Variable {
+	buf := make([]uint8, maxLength+1)
+	var length, size int32
+	var xtype uint32
+	f(p.id, i, maxLength+1, &length, &size, &xtype, &buf[0])
+	// Arrays are reported as "name[0]".
+	name := strings.TrimSuffix(string(buf[:length]), "[0]")
+	return Variable{Name: name, Type: xtype, Size: size}
+}
+
+// Location returns the location of a uniform, caching the result. Unknown names return -1, which GL
+// silently ignores.
+func (p *Program) Location(name string) int32 {
+	if l, ok := p.locations[name]; ok {
+		return l
+	}
+	l := gl.GetUniformLocation(p.id, gl.Str(name+"\x00"))
+	if l < 0 {
+		log.Printf("Program %v has no active uniform %q", p.Files(), name)
+	}
+	p.locations[name] = l
+	return l
+}
+
+func (p *Program) Activate() {
+	gl.UseProgram(p.id)
+}
+
+// Delete frees the GL program. The program must not be used afterwards.
+func (p *Program) Delete() {
+	gl.DeleteProgram(p.id)
+	p.id = 0
+}
+
+// The setters below apply to the program, which must be active.
+
+// SetSampler points a sampler uniform at a texture unit. The assignment survives Reload.
+func (p *Program) SetSampler(name string, unit int32) {
+	p.samplers[name] = unit
+	gl.Uniform1i(p.Location(name), unit)
+}
+
+// BindUniformBlock attaches a uniform block to a buffer binding point. Programs without the block ignore
+// it. The binding survives Reload.
+func (p *Program) BindUniformBlock(name string, binding uint32) {
+	p.blocks[name] = binding
+	p.bindBlock(name, binding)
+}
+
+func (p *Program) bindBlock(name string, binding uint32) {
+	index := gl.GetUniformBlockIndex(p.id, gl.Str(name+"\x00"))
+	if index == gl.INVALID_INDEX {
+		return
+	}
+	gl.UniformBlockBinding(p.id, index, binding)
+}
+
+func (p *Program) SetInt(name string, v int32) {
+	gl.Uniform1i(p.Location(name), v)
+}
+
+func (p *Program) SetUint(name string, v uint32) {
+	gl.Uniform1ui(p.Location(name), v)
+}
+
+func (p *Program) SetBool(name string, v bool) {
+	var i int32
+	if v {
+		i = 1
+	}
+	gl.Uniform1i(p.Location(name), i)
+}
+
+func (p *Program) SetFloat(name string, v float32) {
+	gl.Uniform1f(p.Location(name), v)
+}
+
+func (p *Program) SetFloats(name string, v []float32) {
+	if len(v) == 0 {
+		return
+	}
+	gl.Uniform1fv(p.Location(name), int32(len(v)), &v[0])
+}
+
+func (p *Program) SetVec2(name string, v mgl32.Vec2) {
+	gl.Uniform2fv(p.Location(name), 1, &v[0])
+}
+
+func (p *Program) SetVec3(name string, v mgl32.Vec3) {
+	gl.Uniform3fv(p.Location(name), 1, &v[0])
+}
+
+func (p *Program) SetVec4(name string, v mgl32.Vec4) {
+	gl.Uniform4fv(p.Location(name), 1, &v[0])
+}
+
+func (p *Program) SetMat3(name string, v mgl32.Mat3) {
+	gl.UniformMatrix3fv(p.Location(name), 1, false, &v[0])
+}
+
+func (p *Program) SetMat4(name string, v mgl32.Mat4) {
+	gl.UniformMatrix4fv(p.Location(name), 1, false, &v[0])
+}
+
+func (p *Program) SetMat4s(name string, v []mgl32.Mat4) {
+	if len(v) == 0 {
+		return
+	}
+	gl.UniformMatrix4fv(p.Location(name), int32(len(v)), false, &v[0][0])
+}
@@ -0,0 +1,64 @@
+package shaders
+
+import (
+	"github.com/go-gl/gl/v4.1-core/gl"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+const (
+	attribLocation_joints  = 3
+	attribLocation_weights = 4
+)
+
+// MaxJoints is the most joints a skinned mesh may have, matching MAX_JOINTS in skinning.glsl.
+const MaxJoints = 64
+
+// SkinnedShader is LitShader for meshes deformed by a skeleton on the GPU. Each vertex is moved by up to
+// four joint matrices, mixed by its weights.
+type SkinnedShader struct {
+	*LitShader
+}
+
+func NewSkinnedShader(vertFile, fragFile string) (*SkinnedShader, error) {
+	p, err := NewProgram(ProgramConfig{
+		Stages: []Stage{
+			{Type: gl.VERTEX_SHADER, File: vertFile},
+			{Type: gl.FRAGMENT_SHADER, File: fragFile},
+		},
+		Attributes: map[string]uint32{
+			"vert":         attribLocation_vert,
+			"vertTexCoord": attribLocation_vertTexCoord,
+			"vertNormal":   attribLocation_normal,
+			"vertJoints":   attribLocation_joints,
+			"vertWeights":  attribLocation_weights,
+		},
+		Outputs: []string{"outputColor"},
+	})
+	if err != nil {
+		return nil, err
+	}
+	p.SetSampler("tex", 0)
+	p.BindUniformBlock("Lights", LightsBlockBinding)
+	p.BindUniformBlock("Shadows", ShadowsBlockBinding)
+	p.BindUniformBlock("Fog", FogBlockBinding)
+	p.SetSampler("shadowMap", ShadowMapUnit)
+	return &SkinnedShader{&LitShader{p}}, nil
+}
+
+// SetJoints sets the skinning matrices, at most MaxJoints of them.
+func (s *SkinnedShader) SetJoints(d []mgl32.Mat4) {
+	if len(d) > MaxJoints {
+		d = d[:MaxJoints]
+	}
+	s.SetMat4s("joints", d)
+}
+
+type SkinnedShader_Vertex struct {
+	Vert         mgl32.Vec3 `vertex:"0"`
+	VertTexCoord mgl32.Vec2 `vertex:"1"`
+	VertNormal   mgl32.Vec3 `vertex:"2"`
+	VertJoints   [4]uint16  `vertex:"3,integer"`
+	VertWeights  mgl32.Vec4 `vertex:"4"`
+}
+
+var SkinnedShader_Layout = MustLayoutOf(SkinnedShader_Vertex{})
@@ -0,0 +1,34 @@
+package shaders
+
+import (
+	"github.com/go-gl/gl/v4.1-core/gl"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// SkyShader draws the sky as a fullscreen triangle at the far plane. It needs no vertex attributes, only
+// an empty vertex array.
+type SkyShader struct {
+	*Program
+}
+
+func NewSkyShader(vertFile, fragFile string) (*SkyShader, error) {
+	p, err := NewProgram(ProgramConfig{
+		Stages: []Stage{
+			{Type: gl.VERTEX_SHADER, File: vertFile},
+			{Type: gl.FRAGMENT_SHADER, File: fragFile},
+		},
+		Outputs: []string{"outputColor"},
+	})
+	if err != nil {
+		return nil, err
+	}
+	p.SetSampler("skybox", 0)
+	return &SkyShader{p}, nil
+}
+
+// SetViewProjection takes the camera's projection and view matrices. The view's translation is dropped so
+// the sky stays infinitely far away.
+func (s *SkyShader) SetViewProjection(projection, view mgl32.Mat4) {
+	view.SetCol(3, mgl32.Vec4{0, 0, 0, 1})
+	s.SetMat4("invViewProjection", projection.Mul4(view).Inv())
+}
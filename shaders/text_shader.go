@@ -0,0 +1,46 @@
+package shaders
+
+import (
+	"github.com/go-gl/gl/v4.1-core/gl"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+const attribLocation_textColor = 2
+
+// TextShader draws colored glyphs from a font atlas in screen space. The atlas is read from unit 0.
+type TextShader struct {
+	*Program
+}
+
+func NewTextShader(vertFile, fragFile string) (*TextShader, error) {
+	p, err := NewProgram(ProgramConfig{
+		Stages: []Stage{
+			{Type: gl.VERTEX_SHADER, File: vertFile},
+			{Type: gl.FRAGMENT_SHADER, File: fragFile},
+		},
+		Attributes: map[string]uint32{
+			"vert":         attribLocation_vert,
+			"vertTexCoord": attribLocation_vertTexCoord,
+			"vertColor":    attribLocation_textColor,
+		},
+		Outputs: []string{"outputColor"},
+	})
+	if err != nil {
+		return nil, err
+	}
+	p.SetSampler("atlas", 0)
+	return &TextShader{p}, nil
+}
+
+// SetProjection sets the matrix taking pixel coordinates to clip space.
+func (s *TextShader) SetProjection(p mgl32.Mat4) {
+	s.SetMat4("projection", p)
+}
+
+type TextShader_Vertex struct {
+	Vert         mgl32.Vec2 `vertex:"0"`
+	VertTexCoord mgl32.Vec2 `vertex:"1"`
+	VertColor    mgl32.Vec4 `vertex:"2"`
+}
+
+var TextShader_Layout = MustLayoutOf(TextShader_Vertex{})
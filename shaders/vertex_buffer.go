@@ -0,0 +1,175 @@
+package shaders
+
+import (
+	"fmt"
+	"reflect"
+	"unsafe"
+
+	"github.com/go-gl/gl/v4.1-core/gl"
+)
+
+// VertexBuffer is a vertex array with its vertex buffer, an optional element buffer and an optional
+// per-instance buffer.
+type VertexBuffer struct {
+	vao, vbo, ebo uint32
+	layout        VertexLayout
+	bytes         int
+	// indexType is the GL type of the indices, 16 bit whenever every vertex can be reached with them.
+	indexType uint32
+
+	// Size is the number of indices when indexed, otherwise the number of vertices.
+	Size int32
+
+	instanceVBO    uint32
+	instanceLayout VertexLayout
+	instanceBytes  int
+
+	// Instances is the number of instances DrawInstanced draws, set by SetInstances.
+	Instances int32
+}
+
+// NewVertexBuffer uploads vertices, a slice of structs matching layout, and indices. Pass nil indices for
+// a non-indexed buffer. Indices are stored as 16 bit when there are at most 65536 vertices.
+func NewVertexBuffer(layout VertexLayout, vertices interface{}, indices []uint32) *VertexBuffer {
+	b := &VertexBuffer{layout: layout}
+	gl.GenVertexArrays(1, &b.vao)
+	gl.BindVertexArray(b.vao)
+
+	gl.GenBuffers(1, &b.vbo)
+	gl.BindBuffer(gl.ARRAY_BUFFER, b.vbo)
+	layout.apply()
+
+	if indices != nil {
+		gl.GenBuffers(1, &b.ebo)
+		gl.BindBuffer(gl.ELEMENT_ARRAY_BUFFER, b.ebo)
+	}
+	b.Update(vertices, indices)
+	return b
+}
+
+// Update replaces the contents of the buffer. indices must be nil exactly when the buffer was created
+// without them.
+func (b *VertexBuffer) Update(vertices interface{}, indices []uint32) {
+	v := checkSlice(vertices, b.layout)
+
+	gl.BindVertexArray(b.vao)
+	gl.BindBuffer(gl.ARRAY_BUFFER, b.vbo)
+	var ptr unsafe.Pointer
+	if v.Len() > 0 {
+		ptr = gl.Ptr(vertices)
+	}
+	b.bytes = v.Len() * int(b.layout.Stride)
+	gl.BufferData(gl.ARRAY_BUFFER, b.bytes, ptr, gl.STATIC_DRAW)
+	b.Size = int32(v.Len())
+
+	if b.ebo != 0 {
+		var data interface{}
+		var size int
+		data, b.indexType, size = packIndices(indices, v.Len())
+		ptr = nil
+		if len(indices) > 0 {
+			ptr = gl.Ptr(data)
+		}
+		gl.BufferData(gl.ELEMENT_ARRAY_BUFFER, len(indices)*size, ptr, gl.STATIC_DRAW)
+		b.bytes += len(indices) * size
+		b.Size = int32(len(indices))
+	}
+}
+
+// packIndices returns indices into a buffer of the given number of vertices as they should be uploaded,
+// with their GL type and size in bytes.
+func packIndices(indices []uint32, vertices int) (data interface{}, indexType uint32, size int) {
+	if vertices > 1<<16 {
+		return indices, gl.UNSIGNED_INT, 4
+	}
+	short := make([]uint16, len(indices))
+	for i, index := range indices {
+		short[i] = uint16(index)
+	}
+	return short, gl.UNSIGNED_SHORT, 2
+}
+
+// checkSlice panics unless data is a slice of structs matching layout.
+func checkSlice(data interface{}, layout VertexLayout) reflect.Value {
+	v := reflect.ValueOf(data)
+	if v.Kind() != reflect.Slice {
+		panic(fmt.Sprintf("vertices must be a slice, got %v", v.Type()))
+	}
+	if stride := int32(v.Type().Elem().Size()); stride != layout.Stride {
+		panic(fmt.Sprintf("vertex %v is %d bytes, layout expects %d", v.Type().Elem(), stride, layout.Stride))
+	}
+	return v
+}
+
+// SetInstances replaces the per-instance data, a slice of structs matching layout, which must be a
+// PerInstance layout at locations the vertices don't use. The instance buffer is created on first use,
+// and every later call must pass the same layout.
+func (b *VertexBuffer) SetInstances(layout VertexLayout, instances interface{}) {
+	v := checkSlice(instances, layout)
+	gl.BindVertexArray(b.vao)
+	if b.instanceVBO == 0 {
+		gl.GenBuffers(1, &b.instanceVBO)
+		gl.BindBuffer(gl.ARRAY_BUFFER, b.instanceVBO)
+		layout.apply()
+		b.instanceLayout = layout
+	} else {
+		if layout.Stride != b.instanceLayout.Stride {
+			panic(fmt.Sprintf("instance layout changed from %d to %d bytes", b.instanceLayout.Stride, layout.Stride))
+		}
+		gl.BindBuffer(gl.ARRAY_BUFFER, b.instanceVBO)
+	}
+	var ptr unsafe.Pointer
+	if v.Len() > 0 {
+		ptr = gl.Ptr(instances)
+	}
+	// Instances typically change every frame, so the buffer is respecified rather than updated in place
+	// to avoid waiting on draws still reading it.
+	b.instanceBytes = v.Len() * int(layout.Stride)
+	gl.BufferData(gl.ARRAY_BUFFER, b.instanceBytes, ptr, gl.STREAM_DRAW)
+	b.Instances = int32(v.Len())
+}
+
+// Bytes returns the GPU memory used by the vertex, index and instance data.
+func (b *VertexBuffer) Bytes() int {
+	return b.bytes + b.instanceBytes
+}
+
+func (b *VertexBuffer) Activate() {
+	gl.BindVertexArray(b.vao)
+}
+
+// Draw activates the buffer and draws it with the given primitive mode.
+func (b *VertexBuffer) Draw(mode uint32) {
+	gl.BindVertexArray(b.vao)
+	if b.ebo != 0 {
+		gl.DrawElements(mode, b.Size, b.indexType, gl.PtrOffset(0))
+	} else {
+		gl.DrawArrays(mode, 0, b.Size)
+	}
+}
+
+// DrawInstanced draws Instances copies of the buffer with the given primitive mode, each reading its own
+// element of the instance data.
+func (b *VertexBuffer) DrawInstanced(mode uint32) {
+	if b.Instances == 0 {
+		return
+	}
+	gl.BindVertexArray(b.vao)
+	if b.ebo != 0 {
+		gl.DrawElementsInstanced(mode, b.Size, b.indexType, gl.PtrOffset(0), b.Instances)
+	} else {
+		gl.DrawArraysInstanced(mode, 0, b.Size, b.Instances)
+	}
+}
+
+// Delete frees the GL objects. The buffer must not be used afterwards.
+func (b *VertexBuffer) Delete() {
+	gl.DeleteVertexArrays(1, &b.vao)
+	gl.DeleteBuffers(1, &b.vbo)
+	if b.ebo != 0 {
+		gl.DeleteBuffers(1, &b.ebo)
+	}
+	if b.instanceVBO != 0 {
+		gl.DeleteBuffers(1, &b.instanceVBO)
+	}
+}
@@ -0,0 +1,150 @@
+package shaders
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/go-gl/gl/v4.1-core/gl"
+)
+
+// VertexAttribute describes one attribute inside an interleaved vertex.
+type VertexAttribute struct {
+	Location   uint32
+	Components int32
+	Type       uint32
+	Offset     uintptr
+
+	// Normalized maps integer data into [0, 1] or [-1, 1] when read as a float.
+	Normalized bool
+	// Integer keeps integer data as ivec/uvec in the shader, using VertexAttribIPointer.
+	Integer bool
+}
+
+// VertexLayout describes an interleaved vertex struct.
+type VertexLayout struct {
+	Stride     int32
+	Attributes []VertexAttribute
+
+	// Divisor is 0 for per-vertex data. Otherwise the attributes advance once every Divisor instances.
+	Divisor uint32
+}
+
+// LayoutOf builds a layout from the tags on a vertex struct. Every field that is fed to the shader is
+// tagged with its attribute location, optionally followed by "normalized" or "integer":
+//
+//	type vertex struct {
+//		Position mgl32.Vec3 `vertex:"0"`
+//		Color    [4]uint8   `vertex:"1,normalized"`
+//		Packed   uint32     `vertex:"2,integer"`
+//	}
+//
+// Fields may be scalars or arrays of one to four float32, int8, uint8, int16, uint16, int32 or uint32.
+// An mgl32.Mat4 takes four consecutive locations starting at its tag, one per column, matching a mat4 in
+// the shader. Untagged fields are skipped but still count towards the stride.
+func LayoutOf(vertex interface{}) (VertexLayout, error) {
+	t := reflect.TypeOf(vertex)
+	if t.Kind() != reflect.Struct {
+		return VertexLayout{}, fmt.Errorf("vertex must be a struct, got %v", t)
+	}
+	layout := VertexLayout{Stride: int32(t.Size())}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag, ok := f.Tag.Lookup("vertex")
+		if !ok {
+			continue
+		}
+		parts := strings.Split(tag, ",")
+		location, err := strconv.ParseUint(parts[0], 10, 32)
+		if err != nil {
+			return VertexLayout{}, fmt.Errorf("field %v has bad location %q", f.Name, parts[0])
+		}
+		a := VertexAttribute{Location: uint32(location), Offset: f.Offset, Components: 1}
+		for _, opt := range parts[1:] {
+			switch opt {
+			case "normalized":
+				a.Normalized = true
+			case "integer":
+				a.Integer = true
+			default:
+				return VertexLayout{}, fmt.Errorf("field %v has unknown option %q", f.Name, opt)
+			}
+		}
+
+		elem := f.Type
+		if elem.Kind() == reflect.Array && elem.Len() == 16 && elem.Elem().Kind() == reflect.Float32 {
+			if a.Integer || a.Normalized {
+				return VertexLayout{}, fmt.Errorf("field %v: matrices can't be integer or normalized", f.Name)
+			}
+			for c := 0; c < 4; c++ {
+				layout.Attributes = append(layout.Attributes, VertexAttribute{
+					Location:   a.Location + uint32(c),
+					Components: 4,
+					Type:       gl.FLOAT,
+					Offset:     a.Offset + uintptr(c*16),
+				})
+			}
+			continue
+		}
+		if elem.Kind() == reflect.Array {
+			a.Components = int32(elem.Len())
+			elem = elem.Elem()
+		}
+		if a.Components < 1 || a.Components > 4 {
+			return VertexLayout{}, fmt.Errorf("field %v has %d components, must be 1 to 4", f.Name, a.Components)
+		}
+		switch elem.Kind() {
+		case reflect.Float32:
+			a.Type = gl.FLOAT
+		case reflect.Int8:
+			a.Type = gl.BYTE
+		case reflect.Uint8:
+			a.Type = gl.UNSIGNED_BYTE
+		case reflect.Int16:
+			a.Type = gl.SHORT
+		case reflect.Uint16:
+			a.Type = gl.UNSIGNED_SHORT
+		case reflect.Int32:
+			a.Type = gl.INT
+		case reflect.Uint32:
+			a.Type = gl.UNSIGNED_INT
+		default:
+			return VertexLayout{}, fmt.Errorf("field %v has unsupported type %v", f.Name, f.Type)
+		}
+		if a.Integer && (a.Type == gl.FLOAT || a.Normalized) {
+			return VertexLayout{}, fmt.Errorf("field %v: integer attributes must be non-normalized integers", f.Name)
+		}
+		layout.Attributes = append(layout.Attributes, a)
+	}
+	return layout, nil
+}
+
+// MustLayoutOf is like LayoutOf but panics on error, for use in package level variables.
+func MustLayoutOf(vertex interface{}) VertexLayout {
+	l, err := LayoutOf(vertex)
+	if err != nil {
+		panic(err)
+	}
+	return l
+}
+
+// apply issues the attribute pointer calls for the currently bound vertex array and buffer.
+func (l VertexLayout) apply() {
+	for _, a := range l.Attributes {
+		gl.EnableVertexAttribArray(a.Location)
+		if a.Integer {
+			gl.VertexAttribIPointer(a.Location, a.Components, a.Type, l.Stride, gl.PtrOffset(int(a.Offset)))
+		} else {
+			gl.VertexAttribPointer(a.Location, a.Components, a.Type, a.Normalized, l.Stride, gl.PtrOffset(int(a.Offset)))
+		}
+		gl.VertexAttribDivisor(a.Location, l.Divisor)
+	}
+}
+
+// PerInstance returns a copy of the layout whose attributes advance once per instance instead of per
+// vertex, for use with VertexBuffer.SetInstances.
+func (l VertexLayout) PerInstance() VertexLayout {
+	l.Divisor = 1
+	return l
+}
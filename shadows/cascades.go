@@ -0,0 +1,88 @@
+package shadows
+
+import (
+	"math"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// cascadeSplits returns the far distance of each of n cascades covering [near, far]. lambda blends between
+// uniform splits at 0 and logarithmic splits at 1, which keep texel density even across the cascades.
+func cascadeSplits(near, far float32, n int, lambda float32) []float32 {
+	splits := make([]float32, n)
+	for i := range splits {
+		p := float64(i+1) / float64(n)
+		log := float64(near) * math.Pow(float64(far/near), p)
+		uniform := float64(near) + float64(far-near)*p
+		splits[i] = float32(float64(lambda)*log + (1-float64(lambda))*uniform)
+	}
+	return splits
+}
+
+// frustumCorners returns the eight world space corners of the part of the view frustum between near and
+// far.
+func frustumCorners(position, forward mgl32.Vec3, fovY, aspect, near, far float32) [8]mgl32.Vec3 {
+	right := forward.Cross(mgl32.Vec3{0, 1, 0}).Normalize()
+	up := right.Cross(forward).Normalize()
+	tanY := float32(math.Tan(float64(fovY) / 2))
+	tanX := tanY * aspect
+
+	var corners [8]mgl32.Vec3
+	for i, d := range []float32{near, far} {
+		center := position.Add(forward.Mul(d))
+		x := right.Mul(d * tanX)
+		y := up.Mul(d * tanY)
+		corners[i*4+0] = center.Sub(x).Sub(y)
+		corners[i*4+1] = center.Add(x).Sub(y)
+		corners[i*4+2] = center.Sub(x).Add(y)
+		corners[i*4+3] = center.Add(x).Add(y)
+	}
+	return corners
+}
+
+// casterDistance is how far behind a cascade's bounds the light's near plane sits, so tall objects
+// outside the view still cast shadows into it.
+const casterDistance = 200
+
+// fitCascade builds an orthographic light space matrix that encloses corners. The bounds are a sphere
+// rather than a box, and snapped to whole shadow map texels, so the shadow edges don't shimmer as the
+// camera turns and moves.
+func fitCascade(corners [8]mgl32.Vec3, lightDir mgl32.Vec3, resolution int32) mgl32.Mat4 {
+	var center mgl32.Vec3
+	for _, c := range corners {
+		center = center.Add(c)
+	}
+	center = center.Mul(1.0 / float32(len(corners)))
+	var radius float32
+	for _, c := range corners {
+		if d := c.Sub(center).Len(); d > radius {
+			radius = d
+		}
+	}
+	// Quantize the radius so it doesn't jitter with floating point error either.
+	radius = float32(math.Ceil(float64(radius)*16) / 16)
+
+	up := mgl32.Vec3{0, 1, 0}
+	if abs(lightDir.Y()) > 0.99 {
+		up = mgl32.Vec3{0, 0, 1}
+	}
+	eye := center.Sub(lightDir.Mul(radius + casterDistance))
+	view := mgl32.LookAtV(eye, center, up)
+	proj := mgl32.Ortho(-radius, radius, -radius, radius, 0, 2*radius+casterDistance)
+
+	// Snap the world origin onto a texel so the whole projection moves in texel sized steps.
+	lightSpace := proj.Mul4(view)
+	origin := lightSpace.Mul4x1(mgl32.Vec4{0, 0, 0, 1}).Mul(float32(resolution) / 2)
+	offsetX := (float32(math.Floor(float64(origin.X())+0.5)) - origin.X()) * 2 / float32(resolution)
+	offsetY := (float32(math.Floor(float64(origin.Y())+0.5)) - origin.Y()) * 2 / float32(resolution)
+	proj[12] += offsetX
+	proj[13] += offsetY
+	return proj.Mul4(view)
+}
+
+func abs(f float32) float32 {
+	if f < 0 {
+		return -f
+	}
+	return f
+}
@@ -0,0 +1,166 @@
+package shadows
+
+import (
+	"log"
+	"unsafe"
+
+	"github.com/go-gl/gl/v4.1-core/gl"
+	"github.com/go-gl/glfw/v3.1/glfw"
+	"github.com/go-gl/mathgl/mgl32"
+
+	"github.com/brandonnelson3/GoPlay/camera"
+	"github.com/brandonnelson3/GoPlay/input"
+	"github.com/brandonnelson3/GoPlay/lighting"
+	"github.com/brandonnelson3/GoPlay/shaders"
+	"github.com/brandonnelson3/GoPlay/texture"
+	"github.com/brandonnelson3/GoPlay/window"
+)
+
+// MaxCascades must match MAX_CASCADES in assets/shaders/shadows.glsl.
+const MaxCascades = 4
+
+// Global shadow map manager
+var M manager
+
+// Caster is anything that draws itself into the shadow map.
+type Caster interface {
+	// RenderShadow draws depth only with the given light space matrix. The target framebuffer and
+	// viewport are already set up.
+	RenderShadow(lightSpace mgl32.Mat4)
+}
+
+// shadowBlock mirrors the std140 layout of the Shadows uniform block.
+type shadowBlock struct {
+	lightSpace [MaxCascades]mgl32.Mat4
+	splits     [4]float32
+	position   [4]float32
+	forward    [4]float32
+	params     [4]int32
+}
+
+var shadowBlockSize = int(unsafe.Sizeof(shadowBlock{}))
+
+type manager struct {
+	// Cascades is the number of cascades, from 0 (shadows off) to MaxCascades.
+	Cascades int
+	// Resolution is the width and height of each cascade's depth map.
+	Resolution int32
+	// MaxDistance is how far from the camera shadows are drawn.
+	MaxDistance float32
+	// SplitLambda blends cascade splits between uniform at 0 and logarithmic at 1.
+	SplitLambda float32
+	// PCFRadius is the filter kernel radius in texels, on top of the hardware 2x2 filter.
+	PCFRadius int32
+	// Debug tints each cascade a different color.
+	Debug bool
+
+	// The allocation matching the settings above, rebuilt when they change.
+	cascades   int
+	resolution int32
+	depth      texture.Texture
+	fbo        uint32
+	ubo        uint32
+	block      shadowBlock
+}
+
+func init() {
+	M = manager{
+		Cascades:    3,
+		Resolution:  2048,
+		MaxDistance: 200,
+		SplitLambda: 0.75,
+		PCFRadius:   1,
+	}
+	input.M.Register(glfw.KeyF4, M.toggleDebug)
+}
+
+func (m *manager) toggleDebug(repeat bool, _ float32) {
+	if repeat {
+		return
+	}
+	m.Debug = !m.Debug
+}
+
+func (m *manager) allocate() {
+	if m.Cascades < 0 {
+		m.Cascades = 0
+	}
+	if m.Cascades > MaxCascades {
+		log.Printf("Clamping %d shadow cascades to %d", m.Cascades, MaxCascades)
+		m.Cascades = MaxCascades
+	}
+	if m.fbo != 0 && m.cascades == m.Cascades && m.resolution == m.Resolution {
+		return
+	}
+	if m.fbo == 0 {
+		gl.GenFramebuffers(1, &m.fbo)
+		gl.GenBuffers(1, &m.ubo)
+		gl.BindBuffer(gl.UNIFORM_BUFFER, m.ubo)
+		gl.BufferData(gl.UNIFORM_BUFFER, shadowBlockSize, nil, gl.DYNAMIC_DRAW)
+		gl.BindBufferBase(gl.UNIFORM_BUFFER, shaders.ShadowsBlockBinding, m.ubo)
+	} else {
+		m.depth.Delete()
+	}
+	// Always keep at least one layer so the sampler has something valid bound.
+	layers := int32(m.Cascades)
+	if layers == 0 {
+		layers = 1
+	}
+	m.depth = texture.NewDepthArray(m.Resolution, layers)
+	m.cascades = m.Cascades
+	m.resolution = m.Resolution
+}
+
+// Render draws every caster into each cascade, then binds the shadow map and uploads the Shadows uniform
+// block for the main pass. Must be called once per frame on the render thread after lighting.M.Update.
+func (m *manager) Render(casters ...Caster) {
+	m.allocate()
+
+	position := camera.C.GetPosition()
+	forward := camera.C.GetForward().Normalize()
+	fov := mgl32.DegToRad(camera.C.FOVDegrees)
+	aspect := float32(window.M.Width) / float32(window.M.Height)
+	near := camera.C.NearPlaneDist
+	lightDir := lighting.M.Sun.Direction.Normalize()
+
+	b := &m.block
+	b.params = [4]int32{int32(m.cascades), 0, m.PCFRadius, 0}
+	if m.Debug {
+		b.params[1] = 1
+	}
+	b.position = [4]float32{position[0], position[1], position[2], 1}
+	b.forward = [4]float32{forward[0], forward[1], forward[2], 0}
+
+	if m.cascades > 0 {
+		gl.BindFramebuffer(gl.FRAMEBUFFER, m.fbo)
+		gl.Viewport(0, 0, m.resolution, m.resolution)
+		// Casters are not consistently wound, so offset depth rather than culling front faces.
+		gl.Enable(gl.POLYGON_OFFSET_FILL)
+		gl.PolygonOffset(2, 4)
+
+		splits := cascadeSplits(near, m.MaxDistance, m.cascades, m.SplitLambda)
+		start := near
+		for i, end := range splits {
+			corners := frustumCorners(position, forward, fov, aspect, start, end)
+			b.lightSpace[i] = fitCascade(corners, lightDir, m.resolution)
+			b.splits[i] = end
+			start = end
+
+			gl.FramebufferTextureLayer(gl.FRAMEBUFFER, gl.DEPTH_ATTACHMENT, m.depth.ID(), 0, int32(i))
+			gl.DrawBuffer(gl.NONE)
+			gl.ReadBuffer(gl.NONE)
+			gl.Clear(gl.DEPTH_BUFFER_BIT)
+			for _, c := range casters {
+				c.RenderShadow(b.lightSpace[i])
+			}
+		}
+
+		gl.Disable(gl.POLYGON_OFFSET_FILL)
+		gl.BindFramebuffer(gl.FRAMEBUFFER, 0)
+		gl.Viewport(0, 0, int32(window.M.Width), int32(window.M.Height))
+	}
+
+	gl.BindBuffer(gl.UNIFORM_BUFFER, m.ubo)
+	gl.BufferSubData(gl.UNIFORM_BUFFER, 0, shadowBlockSize, gl.Ptr(b))
+	m.depth.Bind(gl.TEXTURE0 + shaders.ShadowMapUnit)
+}
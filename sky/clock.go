@@ -0,0 +1,67 @@
+package sky
+
+import (
+	"math"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// Sky and sun colors at midday, sunset and midnight. Everything in between is blended from these by sun
+// height.
+var (
+	dayZenith     = mgl32.Vec3{0.25, 0.45, 0.85}
+	dayHorizon    = mgl32.Vec3{0.7, 0.8, 0.95}
+	sunsetZenith  = mgl32.Vec3{0.2, 0.25, 0.5}
+	sunsetHorizon = mgl32.Vec3{0.95, 0.5, 0.25}
+	nightZenith   = mgl32.Vec3{0.01, 0.01, 0.04}
+	nightHorizon  = mgl32.Vec3{0.03, 0.04, 0.08}
+
+	daySun    = mgl32.Vec3{1, 0.95, 0.85}
+	sunsetSun = mgl32.Vec3{1, 0.55, 0.3}
+
+	dayGround   = mgl32.Vec3{0.3, 0.25, 0.2}
+	nightGround = mgl32.Vec3{0.02, 0.02, 0.03}
+)
+
+// state is everything the time of day decides.
+type state struct {
+	// toSun points from the world towards the sun.
+	toSun        mgl32.Vec3
+	sunColor     mgl32.Vec3
+	sunIntensity float32
+	zenith       mgl32.Vec3
+	horizon      mgl32.Vec3
+	ground       mgl32.Vec3
+	ambient      float32
+}
+
+func smoothstep(edge0, edge1, x float32) float32 {
+	t := mgl32.Clamp((x-edge0)/(edge1-edge0), 0, 1)
+	return t * t * (3 - 2*t)
+}
+
+func mix(a, b mgl32.Vec3, t float32) mgl32.Vec3 {
+	return a.Mul(1 - t).Add(b.Mul(t))
+}
+
+// stateAt works out the sun and sky for hours in 0..24. The sun rises in the east at 6, is highest at 12
+// and sets in the west at 18.
+func stateAt(hours float32) state {
+	angle := float64(hours/24)*2*math.Pi - math.Pi/2
+	// Tilt the sun's path a little so it never passes straight overhead, which keeps shadows readable.
+	toSun := mgl32.Vec3{float32(math.Cos(angle)), float32(math.Sin(angle)), 0.3}.Normalize()
+	height := toSun.Y()
+
+	// day is 1 with the sun well up and 0 once it is below the horizon, sunset peaks as it crosses it.
+	day := smoothstep(-0.1, 0.25, height)
+	sunset := 1 - mgl32.Clamp(float32(math.Abs(float64(height)))/0.3, 0, 1)
+
+	s := state{toSun: toSun}
+	s.zenith = mix(mix(nightZenith, dayZenith, day), sunsetZenith, sunset*0.5)
+	s.horizon = mix(mix(nightHorizon, dayHorizon, day), sunsetHorizon, sunset*day)
+	s.ground = mix(nightGround, dayGround, day)
+	s.sunColor = mix(daySun, sunsetSun, sunset)
+	s.sunIntensity = 0.8 * smoothstep(-0.05, 0.15, height)
+	s.ambient = 0.08 + 0.32*day
+	return s
+}
@@ -0,0 +1,119 @@
+package sky
+
+import (
+	"log"
+
+	"github.com/go-gl/gl/v4.1-core/gl"
+	"github.com/go-gl/glfw/v3.1/glfw"
+	"github.com/go-gl/mathgl/mgl32"
+
+	"github.com/brandonnelson3/GoPlay/assetmanager"
+	"github.com/brandonnelson3/GoPlay/camera"
+	"github.com/brandonnelson3/GoPlay/fog"
+	"github.com/brandonnelson3/GoPlay/input"
+	"github.com/brandonnelson3/GoPlay/lighting"
+	"github.com/brandonnelson3/GoPlay/shaders"
+	"github.com/brandonnelson3/GoPlay/texture"
+	"github.com/brandonnelson3/GoPlay/window"
+)
+
+// Global sky and time of day manager
+var M manager
+
+type manager struct {
+	// TimeOfDay is the clock in hours, 0..24.
+	TimeOfDay float32
+	// DayLength is how many real seconds a full day takes.
+	DayLength float32
+	// Paused stops the clock.
+	Paused bool
+	// Skybox is drawn instead of the procedural gradient when set.
+	Skybox *texture.Texture
+
+	state  state
+	shader *shaders.SkyShader
+}
+
+func init() {
+	M = manager{
+		TimeOfDay: 10,
+		DayLength: 20 * 60,
+	}
+	input.M.Register(glfw.KeyT, M.fastForward)
+	input.M.Register(glfw.KeyY, M.togglePause)
+}
+
+// fastForward runs the clock at two hours per second while held.
+func (m *manager) fastForward(_ bool, d float32) {
+	m.advance(2 * d)
+}
+
+func (m *manager) togglePause(repeat bool, _ float32) {
+	if repeat {
+		return
+	}
+	m.Paused = !m.Paused
+	log.Printf("Time of day %.1f, paused: %v", m.TimeOfDay, m.Paused)
+}
+
+func (m *manager) advance(hours float32) {
+	m.TimeOfDay += hours
+	for m.TimeOfDay >= 24 {
+		m.TimeOfDay -= 24
+	}
+	for m.TimeOfDay < 0 {
+		m.TimeOfDay += 24
+	}
+}
+
+// Init compiles the sky shader. Must be called on the render thread.
+func (m *manager) Init() error {
+	shader, err := assetmanager.M.SkyShader()
+	if err != nil {
+		return err
+	}
+	m.shader = shader
+	return nil
+}
+
+// Update advances the clock and drives the sun, ambient light and fog color from it. Call it before
+// lighting.M.Update and fog.M.Update.
+func (m *manager) Update(elapsed float64) {
+	if !m.Paused && m.DayLength > 0 {
+		m.advance(float32(elapsed) * 24 / m.DayLength)
+	}
+	m.state = stateAt(m.TimeOfDay)
+	s := &m.state
+
+	lighting.M.Sun.Direction = s.toSun.Mul(-1)
+	lighting.M.Sun.Color = s.sunColor
+	lighting.M.Sun.Intensity = s.sunIntensity
+	lighting.M.Ambient.SkyColor = s.zenith.Add(s.horizon).Mul(0.5)
+	lighting.M.Ambient.GroundColor = s.ground
+	lighting.M.Ambient.Intensity = s.ambient
+	// Fog matches the horizon so distant geometry blends into the sky.
+	fog.M.Color = s.horizon
+}
+
+// Render draws the sky behind everything already in the depth buffer. Call it after opaque geometry so
+// covered pixels are rejected by the depth test.
+func (m *manager) Render() {
+	s := &m.state
+	m.shader.Activate()
+	m.shader.SetViewProjection(mgl32.Perspective(mgl32.DegToRad(camera.C.FOVDegrees), float32(window.M.Width)/float32(window.M.Height), camera.C.NearPlaneDist, camera.C.FarPlaneDist), camera.C.GetViewMatrix())
+	m.shader.SetBool("useSkybox", m.Skybox != nil)
+	if m.Skybox != nil {
+		m.Skybox.Bind(gl.TEXTURE0)
+	}
+	m.shader.SetVec3("zenithColor", s.zenith)
+	m.shader.SetVec3("horizonColor", s.horizon)
+	m.shader.SetVec3("toSun", s.toSun)
+	m.shader.SetVec3("sunColor", s.sunColor.Mul(s.sunIntensity))
+
+	// The sky sits exactly on the far plane, so it needs LEQUAL to pass against the cleared depth.
+	gl.DepthFunc(gl.LEQUAL)
+	gl.DepthMask(false)
+	shaders.DrawFullscreen()
+	gl.DepthMask(true)
+	gl.DepthFunc(gl.LESS)
+}
@@ -0,0 +1,54 @@
+package spatial
+
+import (
+	"math"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// AABB is an axis aligned box. It has the same layout as physics.AABB, so the two convert directly.
+type AABB struct {
+	Min, Max mgl32.Vec3
+}
+
+// Overlaps reports whether a and b intersect, touching counts.
+func (a AABB) Overlaps(b AABB) bool {
+	return a.Min[0] <= b.Max[0] && a.Max[0] >= b.Min[0] &&
+		a.Min[1] <= b.Max[1] && a.Max[1] >= b.Min[1] &&
+		a.Min[2] <= b.Max[2] && a.Max[2] >= b.Min[2]
+}
+
+// OverlapsSphere reports whether a and the sphere intersect, touching counts.
+func (a AABB) OverlapsSphere(center mgl32.Vec3, radius float32) bool {
+	var d2 float32
+	for i := 0; i < 3; i++ {
+		d := center[i] - mgl32.Clamp(center[i], a.Min[i], a.Max[i])
+		d2 += d * d
+	}
+	return d2 <= radius*radius
+}
+
+// Ray returns the distance along the ray from origin in direction, a unit vector, to where it enters the
+// box, 0 if origin is inside. It reports false if the ray misses or enters further than maxDistance.
+func (a AABB) Ray(origin, direction mgl32.Vec3, maxDistance float32) (float32, bool) {
+	near, far := float32(0), maxDistance
+	for i := 0; i < 3; i++ {
+		if direction[i] == 0 {
+			if origin[i] < a.Min[i] || origin[i] > a.Max[i] {
+				return 0, false
+			}
+			continue
+		}
+		inv := 1 / direction[i]
+		t0, t1 := (a.Min[i]-origin[i])*inv, (a.Max[i]-origin[i])*inv
+		if t0 > t1 {
+			t0, t1 = t1, t0
+		}
+		near = float32(math.Max(float64(near), float64(t0)))
+		far = float32(math.Min(float64(far), float64(t1)))
+		if near > far {
+			return 0, false
+		}
+	}
+	return near, true
+}
@@ -0,0 +1,231 @@
+package spatial
+
+import (
+	"math"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// Item is something stored in a Grid.
+type Item struct {
+	Bounds AABB
+	// Data is whatever the item stands for, such as a *physics.Body.
+	Data interface{}
+
+	// lo and hi are the range of cells the item is bucketed in.
+	lo, hi cellKey
+	// stamp is the last query that visited the item, so items spanning several cells are only reported
+	// once.
+	stamp uint32
+	grid  *Grid
+}
+
+type cellKey [3]int32
+
+// Grid is a uniform grid of cubic cells, each listing the items overlapping it. Only occupied cells take
+// any memory, so the grid is unbounded. It suits many similarly sized objects; items much bigger than a
+// cell are listed in every cell they cover.
+type Grid struct {
+	// CellSize is the edge length of a cell. It must not change once items are inserted.
+	CellSize float32
+
+	cells map[cellKey][]*Item
+	count int
+	stamp uint32
+}
+
+// DefaultCellSize matches the voxel terrain's cell size, so grid cells line up with terrain cells.
+const DefaultCellSize = 32
+
+func NewGrid(cellSize float32) *Grid {
+	return &Grid{CellSize: cellSize, cells: make(map[cellKey][]*Item)}
+}
+
+// Len returns the number of items in the grid.
+func (g *Grid) Len() int {
+	return g.count
+}
+
+func (g *Grid) cellOf(p mgl32.Vec3) cellKey {
+	return cellKey{
+		int32(math.Floor(float64(p[0] / g.CellSize))),
+		int32(math.Floor(float64(p[1] / g.CellSize))),
+		int32(math.Floor(float64(p[2] / g.CellSize))),
+	}
+}
+
+// Insert adds an item covering bounds and returns it, for passing to Move and Remove.
+func (g *Grid) Insert(bounds AABB, data interface{}) *Item {
+	it := &Item{Bounds: bounds, Data: data, grid: g}
+	it.lo, it.hi = g.cellOf(bounds.Min), g.cellOf(bounds.Max)
+	g.link(it)
+	g.count++
+	return it
+}
+
+// Move changes an item's bounds. It is cheap when the item stays within the same cells. It panics if it
+// isn't in g, which would otherwise corrupt both grids.
+func (g *Grid) Move(it *Item, bounds AABB) {
+	if it.grid != g {
+		panic("spatial: Move of an item not in this grid")
+	}
+	it.Bounds = bounds
+	lo, hi := g.cellOf(bounds.Min), g.cellOf(bounds.Max)
+	if lo == it.lo && hi == it.hi {
+		return
+	}
+	g.unlink(it)
+	it.lo, it.hi = lo, hi
+	g.link(it)
+}
+
+// Remove takes an item out of the grid. Removing an item twice does nothing.
+func (g *Grid) Remove(it *Item) {
+	if it.grid != g {
+		return
+	}
+	g.unlink(it)
+	it.grid = nil
+	g.count--
+}
+
+func (g *Grid) link(it *Item) {
+	for x := it.lo[0]; x <= it.hi[0]; x++ {
+		for y := it.lo[1]; y <= it.hi[1]; y++ {
+			for z := it.lo[2]; z <= it.hi[2]; z++ {
+				k := cellKey{x, y, z}
+				g.cells[k] = append(g.cells[k], it)
+			}
+		}
+	}
+}
+
+func (g *Grid) unlink(it *Item) {
+	for x := it.lo[0]; x <= it.hi[0]; x++ {
+		for y := it.lo[1]; y <= it.hi[1]; y++ {
+			for z := it.lo[2]; z <= it.hi[2]; z++ {
+				k := cellKey{x, y, z}
+				items := g.cells[k]
+				for i, o := range items {
+					if o == it {
+						items[i] = items[len(items)-1]
+						items[len(items)-1] = nil
+						items = items[:len(items)-1]
+						break
+					}
+				}
+				if len(items) == 0 {
+					delete(g.cells, k)
+				} else {
+					g.cells[k] = items
+				}
+			}
+		}
+	}
+}
+
+// visit calls f once for every item listed in the cells from lo to hi, until f returns false. It reports
+// whether f asked to stop.
+func (g *Grid) visit(lo, hi cellKey, f func(it *Item) bool) bool {
+	g.stamp++
+	for x := lo[0]; x <= hi[0]; x++ {
+		for y := lo[1]; y <= hi[1]; y++ {
+			for z := lo[2]; z <= hi[2]; z++ {
+				for _, it := range g.cells[cellKey{x, y, z}] {
+					if it.stamp == g.stamp {
+						continue
+					}
+					it.stamp = g.stamp
+					if !f(it) {
+						return true
+					}
+				}
+			}
+		}
+	}
+	return false
+}
+
+// QueryAABB calls f for every item overlapping box, in no particular order, until f returns false. f must
+// not change the grid.
+func (g *Grid) QueryAABB(box AABB, f func(it *Item) bool) {
+	g.visit(g.cellOf(box.Min), g.cellOf(box.Max), func(it *Item) bool {
+		if it.Bounds.Overlaps(box) {
+			return f(it)
+		}
+		return true
+	})
+}
+
+// QuerySphere calls f for every item whose bounds overlap the sphere, in no particular order, until f
+// returns false. f must not change the grid.
+func (g *Grid) QuerySphere(center mgl32.Vec3, radius float32, f func(it *Item) bool) {
+	r := mgl32.Vec3{radius, radius, radius}
+	g.visit(g.cellOf(center.Sub(r)), g.cellOf(center.Add(r)), func(it *Item) bool {
+		if it.Bounds.OverlapsSphere(center, radius) {
+			return f(it)
+		}
+		return true
+	})
+}
+
+// Raycast returns the nearest item whose bounds the ray from origin along direction, a unit vector, hits
+// within maxDistance, which must be finite, and the distance to it. Items for which accept returns false are
+// passed through; accept may be nil.
+func (g *Grid) Raycast(origin, direction mgl32.Vec3, maxDistance float32, accept func(it *Item) bool) (*Item, float32, bool) {
+	var best *Item
+	bestDistance := maxDistance
+
+	// Walk the cells the ray passes through in order, using the grid traversal of Amanatides and Woo.
+	cell := g.cellOf(origin)
+	var step cellKey
+	var next, delta mgl32.Vec3
+	for i := 0; i < 3; i++ {
+		switch {
+		case direction[i] > 0:
+			step[i] = 1
+			next[i] = (float32(cell[i]+1)*g.CellSize - origin[i]) / direction[i]
+			delta[i] = g.CellSize / direction[i]
+		case direction[i] < 0:
+			step[i] = -1
+			next[i] = (float32(cell[i])*g.CellSize - origin[i]) / direction[i]
+			delta[i] = -g.CellSize / direction[i]
+		default:
+			next[i] = float32(math.Inf(1))
+			delta[i] = float32(math.Inf(1))
+		}
+	}
+
+	g.stamp++
+	var entered float32
+	for entered <= bestDistance {
+		for _, it := range g.cells[cell] {
+			if it.stamp == g.stamp {
+				continue
+			}
+			// Items are only tested once, even when the hit lies in a later cell: the nearest hit on an item
+			// doesn't depend on which cell found it.
+			it.stamp = g.stamp
+			if accept != nil && !accept(it) {
+				continue
+			}
+			if d, ok := it.Bounds.Ray(origin, direction, bestDistance); ok && (best == nil || d < bestDistance) {
+				best, bestDistance = it, d
+			}
+		}
+		axis := 0
+		if next[1] < next[axis] {
+			axis = 1
+		}
+		if next[2] < next[axis] {
+			axis = 2
+		}
+		entered = next[axis]
+		if entered > maxDistance {
+			break
+		}
+		cell[axis] += step[axis]
+		next[axis] += delta[axis]
+	}
+	return best, bestDistance, best != nil
+}
@@ -0,0 +1,237 @@
+package spatial
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+func randomVec(r *rand.Rand, min, max float32) mgl32.Vec3 {
+	return mgl32.Vec3{
+		min + r.Float32()*(max-min),
+		min + r.Float32()*(max-min),
+		min + r.Float32()*(max-min),
+	}
+}
+
+// randomBox returns a box starting in [-20, 20], mostly smaller than a cell of 4 but sometimes spanning
+// many.
+func randomBox(r *rand.Rand) AABB {
+	min := randomVec(r, -20, 20)
+	size := randomVec(r, 0, 3)
+	if r.Intn(10) == 0 {
+		size = randomVec(r, 0, 20)
+	}
+	return AABB{min, min.Add(size)}
+}
+
+func randomDirection(r *rand.Rand) mgl32.Vec3 {
+	d := randomVec(r, -1, 1)
+	// Rays along an axis or a plane take the traversal's special cases.
+	for i := range d {
+		if r.Intn(4) == 0 {
+			d[i] = 0
+		}
+	}
+	if d.Len() == 0 {
+		d[r.Intn(3)] = 1
+	}
+	return d.Normalize()
+}
+
+// collect returns the items a query reports, failing if any is reported twice.
+func collect(t *testing.T, query func(f func(it *Item) bool)) map[*Item]bool {
+	found := map[*Item]bool{}
+	query(func(it *Item) bool {
+		if found[it] {
+			t.Fatalf("item %v reported twice", it.Data)
+		}
+		found[it] = true
+		return true
+	})
+	return found
+}
+
+func sameItems(t *testing.T, query string, got, want map[*Item]bool) {
+	for it := range want {
+		if !got[it] {
+			t.Errorf("%s missed item %v at %v", query, it.Data, it.Bounds)
+		}
+	}
+	for it := range got {
+		if !want[it] {
+			t.Errorf("%s reported item %v at %v that doesn't match", query, it.Data, it.Bounds)
+		}
+	}
+}
+
+func TestGridMatchesLinearScan(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	g := NewGrid(4)
+	var items []*Item
+	for i := 0; i < 500; i++ {
+		items = append(items, g.Insert(randomBox(r), -i))
+	}
+
+	for step := 0; step < 2000; step++ {
+		switch op := r.Intn(10); {
+		case op < 4 || len(items) == 0:
+			items = append(items, g.Insert(randomBox(r), step))
+		case op < 8:
+			it := items[r.Intn(len(items))]
+			if r.Intn(2) == 0 {
+				// Small moves mostly stay within the same cells.
+				offset := randomVec(r, -0.5, 0.5)
+				g.Move(it, AABB{it.Bounds.Min.Add(offset), it.Bounds.Max.Add(offset)})
+			} else {
+				g.Move(it, randomBox(r))
+			}
+		default:
+			i := r.Intn(len(items))
+			g.Remove(items[i])
+			items = append(items[:i], items[i+1:]...)
+		}
+
+		if g.Len() != len(items) {
+			t.Fatalf("step %d: Len is %d, want %d", step, g.Len(), len(items))
+		}
+		min := randomVec(r, -25, 25)
+		box := AABB{min, min.Add(randomVec(r, 0, 12))}
+		want := map[*Item]bool{}
+		for _, it := range items {
+			if it.Bounds.Overlaps(box) {
+				want[it] = true
+			}
+		}
+		sameItems(t, "QueryAABB", collect(t, func(f func(it *Item) bool) { g.QueryAABB(box, f) }), want)
+
+		center, radius := randomVec(r, -25, 25), r.Float32()*8
+		want = map[*Item]bool{}
+		for _, it := range items {
+			if it.Bounds.OverlapsSphere(center, radius) {
+				want[it] = true
+			}
+		}
+		sameItems(t, "QuerySphere", collect(t, func(f func(it *Item) bool) { g.QuerySphere(center, radius, f) }), want)
+
+		origin, direction, maxDistance := randomVec(r, -30, 30), randomDirection(r), r.Float32()*60
+		// Every third item is passed through.
+		accept := func(it *Item) bool { return it.Data.(int)%3 != 0 }
+		var wantItem *Item
+		wantDistance := float32(math.Inf(1))
+		for _, it := range items {
+			if !accept(it) {
+				continue
+			}
+			if d, ok := it.Bounds.Ray(origin, direction, maxDistance); ok && d < wantDistance {
+				wantItem, wantDistance = it, d
+			}
+		}
+		gotItem, gotDistance, ok := g.Raycast(origin, direction, maxDistance, accept)
+		switch {
+		case ok != (wantItem != nil):
+			t.Errorf("step %d: Raycast from %v along %v hit %v, want %v", step, origin, direction, ok, wantItem != nil)
+		case ok && gotDistance != wantDistance:
+			t.Errorf("step %d: Raycast from %v along %v hit %v at %v, want %v at %v",
+				step, origin, direction, gotItem.Bounds, gotDistance, wantItem.Bounds, wantDistance)
+		}
+	}
+}
+
+func TestGridQueryStops(t *testing.T) {
+	g := NewGrid(4)
+	for i := 0; i < 10; i++ {
+		g.Insert(AABB{mgl32.Vec3{float32(i), 0, 0}, mgl32.Vec3{float32(i) + 1, 1, 1}}, i)
+	}
+	calls := 0
+	g.QueryAABB(AABB{mgl32.Vec3{-10, -10, -10}, mgl32.Vec3{20, 20, 20}}, func(it *Item) bool {
+		calls++
+		return calls < 3
+	})
+	if calls != 3 {
+		t.Errorf("query made %d calls after being told to stop at 3", calls)
+	}
+}
+
+func TestGridMoveForeignItemPanics(t *testing.T) {
+	box := AABB{mgl32.Vec3{0, 0, 0}, mgl32.Vec3{1, 1, 1}}
+	a, b := NewGrid(4), NewGrid(4)
+	it := a.Insert(box, nil)
+
+	for _, tc := range []struct {
+		name string
+		move func()
+	}{
+		{"another grid's item", func() { b.Move(it, box) }},
+		{"a removed item", func() { a.Remove(it); a.Move(it, box) }},
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("Move of %s didn't panic", tc.name)
+				}
+			}()
+			tc.move()
+		}()
+	}
+	if a.Len() != 0 || b.Len() != 0 {
+		t.Errorf("grids hold %d and %d items, want none", a.Len(), b.Len())
+	}
+}
+
+// benchmarkGrid returns a grid of 10000 small items spread over a 200 unit cube, like bodies in a world.
+func benchmarkGrid() (*Grid, *rand.Rand) {
+	r := rand.New(rand.NewSource(1))
+	g := NewGrid(4)
+	for i := 0; i < 10000; i++ {
+		min := randomVec(r, -100, 100)
+		g.Insert(AABB{min, min.Add(randomVec(r, 0.5, 2))}, i)
+	}
+	return g, r
+}
+
+func BenchmarkQueryAABB(b *testing.B) {
+	g, r := benchmarkGrid()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		min := randomVec(r, -100, 100)
+		g.QueryAABB(AABB{min, min.Add(mgl32.Vec3{8, 8, 8})}, func(it *Item) bool { return true })
+	}
+}
+
+func BenchmarkQuerySphere(b *testing.B) {
+	g, r := benchmarkGrid()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		g.QuerySphere(randomVec(r, -100, 100), 4, func(it *Item) bool { return true })
+	}
+}
+
+func BenchmarkRaycast(b *testing.B) {
+	g, r := benchmarkGrid()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		g.Raycast(randomVec(r, -100, 100), randomDirection(r), 50, nil)
+	}
+}
+
+func BenchmarkMove(b *testing.B) {
+	g, r := benchmarkGrid()
+	var items []*Item
+	g.QueryAABB(AABB{mgl32.Vec3{-200, -200, -200}, mgl32.Vec3{200, 200, 200}}, func(it *Item) bool {
+		items = append(items, it)
+		return true
+	})
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		it := items[i%len(items)]
+		offset := randomVec(r, -0.5, 0.5)
+		g.Move(it, AABB{it.Bounds.Min.Add(offset), it.Bounds.Max.Add(offset)})
+	}
+}
@@ -0,0 +1,90 @@
+package texture
+
+import (
+	"fmt"
+	"image"
+
+	"github.com/go-gl/gl/v4.1-core/gl"
+)
+
+// NewArray builds a TEXTURE_2D_ARRAY with one layer per file. Every image must have the same dimensions.
+func NewArray(files []string) (Texture, error) {
+	if len(files) == 0 {
+		return Texture{}, fmt.Errorf("texture array needs at least one layer")
+	}
+	layers := make([]*image.RGBA, 0, len(files))
+	for _, file := range files {
+		rgba, err := loadRGBA(file)
+		if err != nil {
+			return Texture{}, err
+		}
+		layers = append(layers, rgba)
+	}
+	return newArrayFromLayers(layers)
+}
+
+// NewArrayFromAtlas slices an atlas made of columns x rows equally sized tiles into a TEXTURE_2D_ARRAY.
+// Tiles are read left to right, top to bottom. Each tile becomes its own layer so sampling never bleeds
+// into a neighbouring tile, even with mipmapping.
+func NewArrayFromAtlas(file string, columns, rows int) (Texture, error) {
+	if columns <= 0 || rows <= 0 {
+		return Texture{}, fmt.Errorf("invalid atlas layout %dx%d", columns, rows)
+	}
+	atlas, err := loadRGBA(file)
+	if err != nil {
+		return Texture{}, err
+	}
+	size := atlas.Rect.Size()
+	if size.X%columns != 0 || size.Y%rows != 0 {
+		return Texture{}, fmt.Errorf("atlas %v of size %v is not divisible into %dx%d tiles", file, size, columns, rows)
+	}
+	tileW, tileH := size.X/columns, size.Y/rows
+	layers := make([]*image.RGBA, 0, columns*rows)
+	for r := 0; r < rows; r++ {
+		for c := 0; c < columns; c++ {
+			tile, err := toRGBA(atlas.SubImage(image.Rect(c*tileW, r*tileH, (c+1)*tileW, (r+1)*tileH)))
+			if err != nil {
+				return Texture{}, err
+			}
+			layers = append(layers, tile)
+		}
+	}
+	return newArrayFromLayers(layers)
+}
+
+func newArrayFromLayers(layers []*image.RGBA) (Texture, error) {
+	size := layers[0].Rect.Size()
+	for i, l := range layers {
+		if l.Rect.Size() != size {
+			return Texture{}, fmt.Errorf("texture array layer %d is %v, expected %v", i, l.Rect.Size(), size)
+		}
+		if FlipVertically {
+			flipRGBA(l)
+		}
+	}
+
+	var id uint32
+	gl.GenTextures(1, &id)
+	gl.ActiveTexture(gl.TEXTURE0)
+	gl.BindTexture(gl.TEXTURE_2D_ARRAY, id)
+	gl.TexParameteri(gl.TEXTURE_2D_ARRAY, gl.TEXTURE_MIN_FILTER, gl.LINEAR_MIPMAP_LINEAR)
+	gl.TexParameteri(gl.TEXTURE_2D_ARRAY, gl.TEXTURE_MAG_FILTER, gl.NEAREST)
+	gl.TexParameteri(gl.TEXTURE_2D_ARRAY, gl.TEXTURE_WRAP_S, gl.REPEAT)
+	gl.TexParameteri(gl.TEXTURE_2D_ARRAY, gl.TEXTURE_WRAP_T, gl.REPEAT)
+	gl.TexImage3D(gl.TEXTURE_2D_ARRAY,
+		0,
+		gl.RGBA,
+		int32(size.X),
+		int32(size.Y),
+		int32(len(layers)),
+		0,
+		gl.RGBA,
+		gl.UNSIGNED_BYTE,
+		nil)
+	for i, l := range layers {
+		gl.TexSubImage3D(gl.TEXTURE_2D_ARRAY, 0, 0, 0, int32(i), int32(size.X), int32(size.Y), 1, gl.RGBA, gl.UNSIGNED_BYTE, gl.Ptr(l.Pix))
+	}
+	gl.GenerateMipmap(gl.TEXTURE_2D_ARRAY)
+
+	return Texture{id: id, target: gl.TEXTURE_2D_ARRAY}, nil
+}
@@ -0,0 +1,276 @@
+package texture
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/go-gl/gl/v4.1-core/gl"
+)
+
+// Compressed internal formats. These come from extensions so the core profile bindings don't define them.
+const (
+	formatBC1 = 0x83F1 // GL_COMPRESSED_RGBA_S3TC_DXT1_EXT
+	formatBC2 = 0x83F2 // GL_COMPRESSED_RGBA_S3TC_DXT3_EXT
+	formatBC3 = 0x83F3 // GL_COMPRESSED_RGBA_S3TC_DXT5_EXT
+	formatBC7 = 0x8E8C // GL_COMPRESSED_RGBA_BPTC_UNORM
+
+	// The same encodings tagged as sRGB color. See uploadFormat.
+	formatBC1SRGB = 0x8C4D // GL_COMPRESSED_SRGB_ALPHA_S3TC_DXT1_EXT
+	formatBC2SRGB = 0x8C4E // GL_COMPRESSED_SRGB_ALPHA_S3TC_DXT3_EXT
+	formatBC3SRGB = 0x8C4F // GL_COMPRESSED_SRGB_ALPHA_S3TC_DXT5_EXT
+	formatBC7SRGB = 0x8E8D // GL_COMPRESSED_SRGB_ALPHA_BPTC_UNORM
+)
+
+var (
+	extensionsOnce sync.Once
+	extensions     map[string]bool
+)
+
+func hasExtension(name string) bool {
+	extensionsOnce.Do(func() {
+		extensions = make(map[string]bool)
+		var n int32
+		gl.GetIntegerv(gl.NUM_EXTENSIONS, &n)
+		for i := uint32(0); i < uint32(n); i++ {
+			extensions[gl.GoStr(gl.GetStringi(gl.EXTENSIONS, i))] = true
+		}
+	})
+	return extensions[name]
+}
+
+func formatName(format uint32) string {
+	switch format {
+	case formatBC1:
+		return "BC1"
+	case formatBC2:
+		return "BC2"
+	case formatBC3:
+		return "BC3"
+	case formatBC7:
+		return "BC7"
+	case formatBC1SRGB:
+		return "BC1 sRGB"
+	case formatBC2SRGB:
+		return "BC2 sRGB"
+	case formatBC3SRGB:
+		return "BC3 sRGB"
+	case formatBC7SRGB:
+		return "BC7 sRGB"
+	}
+	return fmt.Sprintf("0x%X", format)
+}
+
+// formatSupported reports whether the driver can sample the given compressed format.
+func formatSupported(format uint32) bool {
+	switch format {
+	case formatBC1, formatBC2, formatBC3:
+		return hasExtension("GL_EXT_texture_compression_s3tc")
+	case formatBC7:
+		return hasExtension("GL_ARB_texture_compression_bptc")
+	}
+	return false
+}
+
+// uploadFormat returns the format an image is uploaded as. The renderer works in display space, with the
+// gamma pass off by default, so sRGB encodings are uploaded as their plain counterparts and sampled without
+// conversion to linear, the same as every other texture.
+func uploadFormat(format uint32) uint32 {
+	switch format {
+	case formatBC1SRGB:
+		return formatBC1
+	case formatBC2SRGB:
+		return formatBC2
+	case formatBC3SRGB:
+		return formatBC3
+	case formatBC7SRGB:
+		return formatBC7
+	}
+	return format
+}
+
+// blockBytes returns the number of bytes in one 4x4 block of the given format.
+func blockBytes(format uint32) (int, error) {
+	switch format {
+	case formatBC1, formatBC1SRGB:
+		return 8, nil
+	case formatBC2, formatBC3, formatBC7, formatBC2SRGB, formatBC3SRGB, formatBC7SRGB:
+		return 16, nil
+	}
+	return 0, fmt.Errorf("unsupported compressed format %v", formatName(format))
+}
+
+// maxCompressedSize is the largest width or height accepted from a container header.
+const maxCompressedSize = 16384
+
+// checkHeader rejects container sizes and mip counts no real texture has, before they are used to slice or
+// allocate anything. A mip count of 0 means just the base level.
+func checkHeader(width, height int32, mips int) error {
+	if width <= 0 || height <= 0 || width > maxCompressedSize || height > maxCompressedSize {
+		return fmt.Errorf("bad size %dx%d", width, height)
+	}
+	most := 1
+	for size := width | height; size > 1; size >>= 1 {
+		most++
+	}
+	if mips > most {
+		return fmt.Errorf("%d mip levels is more than a %dx%d texture has", mips, width, height)
+	}
+	return nil
+}
+
+// levelSize returns the byte size of a single mip level of the given dimensions.
+func levelSize(format uint32, width, height int32) (int, error) {
+	bb, err := blockBytes(format)
+	if err != nil {
+		return 0, err
+	}
+	bw := (int(width) + 3) / 4
+	bh := (int(height) + 3) / 4
+	return bw * bh * bb, nil
+}
+
+func uploadCompressed(img *Image) error {
+	format := uploadFormat(img.format)
+	if !formatSupported(format) {
+		return fmt.Errorf("driver does not support %v compressed textures", formatName(format))
+	}
+	if len(img.levels) > 1 {
+		gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, gl.LINEAR_MIPMAP_LINEAR)
+	} else {
+		gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, gl.LINEAR)
+	}
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAX_LEVEL, int32(len(img.levels)-1))
+	w, h := img.Width, img.Height
+	for level, data := range img.levels {
+		gl.CompressedTexImage2D(gl.TEXTURE_2D, int32(level), format, w, h, 0, int32(len(data)), gl.Ptr(data))
+		if w > 1 {
+			w /= 2
+		}
+		if h > 1 {
+			h /= 2
+		}
+	}
+	return nil
+}
+
+// splitLevels cuts a contiguous mip chain into its individual levels.
+func splitLevels(data []byte, format uint32, width, height int32, count int) ([][]byte, error) {
+	if count < 1 {
+		count = 1
+	}
+	levels := make([][]byte, 0, count)
+	for i := 0; i < count; i++ {
+		size, err := levelSize(format, width, height)
+		if err != nil {
+			return nil, err
+		}
+		if len(data) < size {
+			return nil, fmt.Errorf("truncated mip level %d: need %d bytes, have %d", i, size, len(data))
+		}
+		levels = append(levels, data[:size])
+		data = data[size:]
+		if width > 1 {
+			width /= 2
+		}
+		if height > 1 {
+			height /= 2
+		}
+	}
+	return levels, nil
+}
+
+// flipLevels flips every mip level of a compressed image so the bottom row comes first, like flipRGBA. Whole
+// blocks are moved and the rows inside each are reversed, which is exact for BC1, BC2 and BC3. BC7 blocks
+// can't be flipped without re-encoding them.
+func flipLevels(img *Image) error {
+	var flip func(block []byte, rows int)
+	switch img.format {
+	case formatBC1, formatBC1SRGB:
+		flip = flipColorBlock
+	case formatBC2, formatBC2SRGB:
+		flip = func(block []byte, rows int) {
+			// Explicit alpha, 4 bits a pixel.
+			reverseRows(block[:8], rows, 2)
+			flipColorBlock(block[8:], rows)
+		}
+	case formatBC3, formatBC3SRGB:
+		flip = func(block []byte, rows int) {
+			flipAlphaBlock(block[:8], rows)
+			flipColorBlock(block[8:], rows)
+		}
+	default:
+		return fmt.Errorf("%v images can't be flipped, store them bottom row first or turn off FlipVertically",
+			formatName(img.format))
+	}
+
+	bb, err := blockBytes(img.format)
+	if err != nil {
+		return err
+	}
+	w, h := img.Width, img.Height
+	for i, level := range img.levels {
+		// A partial last block row would have to end up first, shifting every row across block boundaries.
+		if h > 4 && h%4 != 0 {
+			return fmt.Errorf("mip level %d is %d high, which can't be flipped as it isn't a multiple of 4", i, h)
+		}
+		blockRows, rowBytes := (int(h)+3)/4, (int(w)+3)/4*bb
+		if len(level) != blockRows*rowBytes {
+			return fmt.Errorf("mip level %d is %d bytes, expected %d", i, len(level), blockRows*rowBytes)
+		}
+		rows := 4
+		if h < 4 {
+			rows = int(h)
+		}
+		reverseRows(level, blockRows, rowBytes)
+		for b := 0; b+bb <= len(level); b += bb {
+			flip(level[b:b+bb], rows)
+		}
+		if w > 1 {
+			w /= 2
+		}
+		if h > 1 {
+			h /= 2
+		}
+	}
+	return nil
+}
+
+// reverseRows reverses the order of the first n rows of size bytes in b.
+func reverseRows(b []byte, n, size int) {
+	row := make([]byte, size)
+	for i := 0; i < n/2; i++ {
+		top := b[i*size : (i+1)*size]
+		bottom := b[(n-1-i)*size : (n-i)*size]
+		copy(row, top)
+		copy(top, bottom)
+		copy(bottom, row)
+	}
+}
+
+// flipColorBlock flips the first rows pixel rows of a BC1 color block: two endpoints, then a byte of 2 bit
+// indices per row.
+func flipColorBlock(block []byte, rows int) {
+	reverseRows(block[4:8], rows, 1)
+}
+
+// flipAlphaBlock flips the first rows pixel rows of a BC3 alpha block: two endpoints, then 12 bits of 3 bit
+// indices per row, little endian.
+func flipAlphaBlock(block []byte, rows int) {
+	var bits uint64
+	for i := 0; i < 6; i++ {
+		bits |= uint64(block[2+i]) << uint(8*i)
+	}
+	flipped := bits
+	for r := 0; r < rows; r++ {
+		row := bits >> uint(12*r) & 0xFFF
+		to := uint(12 * (rows - 1 - r))
+		flipped = flipped&^(0xFFF<<to) | row<<to
+	}
+	for i := 0; i < 6; i++ {
+		block[2+i] = byte(flipped >> uint(8*i))
+	}
+}
+
+func compressedError(file string, err error) error {
+	return fmt.Errorf("failed to load %v: %v", file, err)
+}
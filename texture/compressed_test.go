@@ -0,0 +1,316 @@
+package texture
+
+import (
+	"bytes"
+	"encoding/binary"
+	"strings"
+	"testing"
+)
+
+// dds builds a DDS file with the given FourCC, and DXGI format when the FourCC is DX10.
+func dds(fourCC string, dxgi uint32, width, height, mips int, data []byte) []byte {
+	header := make([]byte, ddsHeaderSize)
+	le := binary.LittleEndian
+	le.PutUint32(header[0:], ddsHeaderSize)
+	le.PutUint32(header[8:], uint32(height))
+	le.PutUint32(header[12:], uint32(width))
+	le.PutUint32(header[24:], uint32(mips))
+	le.PutUint32(header[76:], ddsPixelFormatFourCC)
+	copy(header[80:], fourCC)
+	b := append([]byte(ddsMagic), header...)
+	if fourCC == "DX10" {
+		dx10 := make([]byte, ddsDX10Size)
+		le.PutUint32(dx10[0:], dxgi)
+		le.PutUint32(dx10[12:], 1)
+		b = append(b, dx10...)
+	}
+	return append(b, data...)
+}
+
+// ktx builds a little endian KTX file with one key/value pair and the given mip levels.
+func ktx(format uint32, width, height int, key, value string, levels ...[]byte) []byte {
+	var kv []byte
+	if key != "" {
+		entry := key + "\x00" + value + "\x00"
+		kv = append(le32(uint32(len(entry))), entry...)
+		kv = append(kv, make([]byte, -len(entry)&3)...)
+	}
+	b := append([]byte{}, ktxIdentifier...)
+	for _, field := range []uint32{ktxEndianness, 0, 1, 0, format, 0, uint32(width), uint32(height), 0, 0, 1, uint32(len(levels)), uint32(len(kv))} {
+		b = append(b, le32(field)...)
+	}
+	b = append(b, kv...)
+	for _, l := range levels {
+		b = append(b, le32(uint32(len(l)))...)
+		b = append(b, l...)
+		b = append(b, make([]byte, -len(l)&3)...)
+	}
+	return b
+}
+
+func le32(v uint32) []byte {
+	b := make([]byte, 4)
+	binary.LittleEndian.PutUint32(b, v)
+	return b
+}
+
+// bc1 returns a BC1 block with the given endpoint tag and one index byte per row.
+func bc1(tag byte, rows [4]byte) []byte {
+	return []byte{tag, 0, 0, 0, rows[0], rows[1], rows[2], rows[3]}
+}
+
+// bc3Alpha returns a BC3 alpha block with the given endpoint tag and 12 bits of indices per row.
+func bc3Alpha(tag byte, rows [4]uint16) []byte {
+	var bits uint64
+	for r, row := range rows {
+		bits |= uint64(row&0xFFF) << uint(12*r)
+	}
+	b := []byte{tag, 0}
+	for i := 0; i < 6; i++ {
+		b = append(b, byte(bits>>uint(8*i)))
+	}
+	return b
+}
+
+func withFlip(flip bool, f func()) {
+	saved := FlipVertically
+	FlipVertically = flip
+	defer func() { FlipVertically = saved }()
+	f()
+}
+
+func join(blocks ...[]byte) []byte {
+	return bytes.Join(blocks, nil)
+}
+
+func TestDDSFlip(t *testing.T) {
+	// An 8x8 BC1 image of 2x2 blocks tagged by position, with a 4x4, 2x2 and 1x1 mip chain.
+	top := [4]byte{0x00, 0x11, 0x22, 0x33}
+	level0 := join(bc1(1, top), bc1(2, top), bc1(3, top), bc1(4, top))
+	level1 := bc1(5, top)
+	level2 := bc1(6, top)
+	level3 := bc1(7, top)
+	src := dds("DXT1", 0, 8, 8, 4, join(level0, level1, level2, level3))
+
+	flipped := [4]byte{0x33, 0x22, 0x11, 0x00}
+	want := [][]byte{
+		// Block rows swap, and so do the pixel rows in each block.
+		join(bc1(3, flipped), bc1(4, flipped), bc1(1, flipped), bc1(2, flipped)),
+		bc1(5, flipped),
+		// 2 high, only the 2 rows in use swap.
+		bc1(6, [4]byte{0x11, 0x00, 0x22, 0x33}),
+		bc1(7, top),
+	}
+	withFlip(true, func() {
+		img, err := parseDDS(append([]byte{}, src...))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i, l := range img.levels {
+			if !bytes.Equal(l, want[i]) {
+				t.Errorf("flipped level %d is %v, want %v", i, l, want[i])
+			}
+		}
+	})
+	withFlip(false, func() {
+		img, err := parseDDS(append([]byte{}, src...))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(img.levels[0], level0) {
+			t.Errorf("level 0 changed with FlipVertically off: %v", img.levels[0])
+		}
+	})
+}
+
+func TestFlipBlocks(t *testing.T) {
+	color := bc1(9, [4]byte{0x00, 0x11, 0x22, 0x33})
+	colorFlipped := bc1(9, [4]byte{0x33, 0x22, 0x11, 0x00})
+	for _, tc := range []struct {
+		name       string
+		format     uint32
+		block      []byte
+		want       []byte
+		wantHalfUp []byte
+	}{
+		{"BC1", formatBC1SRGB, color, colorFlipped, bc1(9, [4]byte{0x11, 0x00, 0x22, 0x33})},
+		{
+			"BC2",
+			formatBC2,
+			join([]byte{0xA0, 0xA1, 0xB0, 0xB1, 0xC0, 0xC1, 0xD0, 0xD1}, color),
+			join([]byte{0xD0, 0xD1, 0xC0, 0xC1, 0xB0, 0xB1, 0xA0, 0xA1}, colorFlipped),
+			join([]byte{0xB0, 0xB1, 0xA0, 0xA1, 0xC0, 0xC1, 0xD0, 0xD1}, bc1(9, [4]byte{0x11, 0x00, 0x22, 0x33})),
+		},
+		{
+			"BC3",
+			formatBC3,
+			join(bc3Alpha(8, [4]uint16{0x123, 0x456, 0x789, 0xABC}), color),
+			join(bc3Alpha(8, [4]uint16{0xABC, 0x789, 0x456, 0x123}), colorFlipped),
+			join(bc3Alpha(8, [4]uint16{0x456, 0x123, 0x789, 0xABC}), bc1(9, [4]byte{0x11, 0x00, 0x22, 0x33})),
+		},
+	} {
+		for _, h := range []int32{4, 2} {
+			img := &Image{Width: 4, Height: h, format: tc.format, levels: [][]byte{append([]byte{}, tc.block...)}}
+			if err := flipLevels(img); err != nil {
+				t.Errorf("%s: %v", tc.name, err)
+				continue
+			}
+			want := tc.want
+			if h == 2 {
+				want = tc.wantHalfUp
+			}
+			if !bytes.Equal(img.levels[0], want) {
+				t.Errorf("%s %d high: flipped to %x, want %x", tc.name, h, img.levels[0], want)
+			}
+		}
+	}
+}
+
+func TestDDSFormats(t *testing.T) {
+	block16 := make([]byte, 16)
+	for _, tc := range []struct {
+		name   string
+		fourCC string
+		dxgi   uint32
+		height int
+		flip   bool
+		want   uint32
+		err    string
+	}{
+		{name: "DXT1", fourCC: "DXT1", height: 4, flip: true, want: formatBC1},
+		{name: "DXT3", fourCC: "DXT3", height: 4, flip: true, want: formatBC2},
+		{name: "DXT5", fourCC: "DXT5", height: 4, flip: true, want: formatBC3},
+		{name: "BC1 sRGB", fourCC: "DX10", dxgi: dxgiBC1UnormSRGB, height: 4, flip: true, want: formatBC1SRGB},
+		{name: "BC2 sRGB", fourCC: "DX10", dxgi: dxgiBC2UnormSRGB, height: 4, flip: true, want: formatBC2SRGB},
+		{name: "BC3", fourCC: "DX10", dxgi: dxgiBC3Unorm, height: 4, flip: true, want: formatBC3},
+		{name: "BC3 sRGB", fourCC: "DX10", dxgi: dxgiBC3UnormSRGB, height: 4, flip: true, want: formatBC3SRGB},
+		{name: "BC7 unflipped", fourCC: "DX10", dxgi: dxgiBC7Unorm, height: 4, want: formatBC7},
+		{name: "BC7 sRGB unflipped", fourCC: "DX10", dxgi: dxgiBC7UnormSRGB, height: 4, want: formatBC7SRGB},
+		{name: "BC7 flipped", fourCC: "DX10", dxgi: dxgiBC7Unorm, height: 4, flip: true, err: "BC7 images can't be flipped"},
+		{name: "partial block row", fourCC: "DXT5", height: 6, flip: true, err: "mip level 0 is 6 high"},
+		{name: "partial block row unflipped", fourCC: "DXT5", height: 6, want: formatBC3},
+		{name: "unknown DXGI", fourCC: "DX10", dxgi: 1, height: 4, err: "unsupported DXGI format 1"},
+	} {
+		withFlip(tc.flip, func() {
+			blocks := (tc.height + 3) / 4
+			img, err := parseDDS(dds(tc.fourCC, tc.dxgi, 4, tc.height, 1, bytes.Repeat(block16, blocks)))
+			switch {
+			case tc.err != "":
+				if err == nil || !strings.HasPrefix(err.Error(), tc.err) {
+					t.Errorf("%s: got error %v, want %q", tc.name, err, tc.err)
+				}
+			case err != nil:
+				t.Errorf("%s: %v", tc.name, err)
+			case img.format != tc.want:
+				t.Errorf("%s: format %v, want %v", tc.name, formatName(img.format), formatName(tc.want))
+			}
+		})
+	}
+}
+
+func TestKTXFlip(t *testing.T) {
+	level := bc1(1, [4]byte{0x00, 0x11, 0x22, 0x33})
+	flipped := bc1(1, [4]byte{0x33, 0x22, 0x11, 0x00})
+	for _, tc := range []struct {
+		name        string
+		format      uint32
+		orientation string
+		want        []byte
+		err         string
+	}{
+		{name: "top down", format: formatBC1, orientation: "S=r,T=d", want: flipped},
+		{name: "no orientation", format: formatBC1SRGB, want: flipped},
+		{name: "bottom up", format: formatBC1, orientation: "S=r,T=u", want: level},
+		{name: "BC7 top down", format: formatBC7, err: "BC7 images can't be flipped"},
+	} {
+		key := ""
+		if tc.orientation != "" {
+			key = "KTXorientation"
+		}
+		withFlip(true, func() {
+			l := level
+			if tc.format == formatBC7 {
+				l = make([]byte, 16)
+			}
+			img, err := parseKTX(ktx(tc.format, 4, 4, key, tc.orientation, append([]byte{}, l...)))
+			switch {
+			case tc.err != "":
+				if err == nil || !strings.HasPrefix(err.Error(), tc.err) {
+					t.Errorf("%s: got error %v, want %q", tc.name, err, tc.err)
+				}
+			case err != nil:
+				t.Errorf("%s: %v", tc.name, err)
+			case img.format != tc.format || !bytes.Equal(img.levels[0], tc.want):
+				t.Errorf("%s: got %v %v, want %v %v", tc.name, formatName(img.format), img.levels[0], formatName(tc.format), tc.want)
+			}
+		})
+	}
+
+	// Levels of the wrong size can't be flipped block by block.
+	withFlip(true, func() {
+		if _, err := parseKTX(ktx(formatBC1, 4, 8, "", "", level)); err == nil {
+			t.Errorf("short KTX level flipped without error")
+		}
+	})
+}
+
+func TestHeaderChecks(t *testing.T) {
+	block := make([]byte, 8)
+	for _, tc := range []struct {
+		name          string
+		width, height int
+		mips          int
+		err           string
+	}{
+		{name: "negative width", width: -48, height: 4, mips: 1, err: "bad size -48x4"},
+		{name: "zero height", width: 4, height: 0, mips: 1, err: "bad size 4x0"},
+		{name: "too wide", width: 16385, height: 4, mips: 1, err: "bad size 16385x4"},
+		{name: "huge mip count", width: 4, height: 4, mips: 1 << 31, err: "2147483648 mip levels is more than a 4x4 texture has"},
+		{name: "one mip too many", width: 8, height: 2, mips: 5, err: "5 mip levels is more than a 8x2 texture has"},
+		{name: "full chain", width: 8, height: 2, mips: 4},
+		{name: "no mip count", width: 4, height: 4, mips: 0},
+	} {
+		levels := tc.mips
+		if levels < 1 || levels > 4 {
+			levels = 1
+		}
+		// A block for each level, enough for the valid cases whose levels are all a block or less after 8x2.
+		data := bytes.Repeat(block, levels+1)
+		withFlip(false, func() {
+			_, err := parseDDS(dds("DXT1", 0, tc.width, tc.height, tc.mips, data))
+			var kvLevels [][]byte
+			for i := 0; i < levels; i++ {
+				kvLevels = append(kvLevels, block)
+			}
+			if tc.width == 8 {
+				kvLevels[0] = bytes.Repeat(block, 2)
+			}
+			k := ktx(formatBC1, tc.width, tc.height, "", "", kvLevels...)
+			// The mip count field is the 12th after the identifier.
+			binary.LittleEndian.PutUint32(k[12+4*11:], uint32(tc.mips))
+			_, ktxErr := parseKTX(k)
+			for format, err := range map[string]error{"DDS": err, "KTX": ktxErr} {
+				switch {
+				case tc.err == "" && err != nil:
+					t.Errorf("%s %s: %v", format, tc.name, err)
+				case tc.err != "" && (err == nil || err.Error() != tc.err):
+					t.Errorf("%s %s: got error %v, want %q", format, tc.name, err, tc.err)
+				}
+			}
+		})
+	}
+}
+
+func TestSRGBUploadsAsDisplaySpace(t *testing.T) {
+	for tagged, want := range map[uint32]uint32{
+		formatBC1SRGB: formatBC1,
+		formatBC2SRGB: formatBC2,
+		formatBC3SRGB: formatBC3,
+		formatBC7SRGB: formatBC7,
+		formatBC3:     formatBC3,
+	} {
+		if got := uploadFormat(tagged); got != want {
+			t.Errorf("%v uploads as %v, want %v", formatName(tagged), formatName(got), formatName(want))
+		}
+	}
+}
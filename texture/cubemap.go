@@ -0,0 +1,123 @@
+package texture
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+
+	"github.com/go-gl/gl/v4.1-core/gl"
+)
+
+// Cubemap faces are always given in GL order: +X, -X, +Y, -Y, +Z, -Z. Cubemaps follow the RenderMan
+// convention of top row first, so faces are never flipped regardless of FlipVertically.
+const cubeFaces = 6
+
+// NewCubemap builds a TEXTURE_CUBE_MAP from six square images of the same size.
+func NewCubemap(faces [cubeFaces]string) (Texture, error) {
+	var images [cubeFaces]*image.RGBA
+	for i, file := range faces {
+		rgba, err := loadRGBA(file)
+		if err != nil {
+			return Texture{}, err
+		}
+		images[i] = rgba
+	}
+	return newCubemapFromFaces(images)
+}
+
+// NewCubemapFromEquirectangular resamples an equirectangular (longitude/latitude) panorama into a cubemap
+// with faces of size x size pixels.
+func NewCubemapFromEquirectangular(file string, size int) (Texture, error) {
+	if size <= 0 {
+		return Texture{}, fmt.Errorf("invalid cubemap face size %d", size)
+	}
+	pano, err := loadRGBA(file)
+	if err != nil {
+		return Texture{}, err
+	}
+	var images [cubeFaces]*image.RGBA
+	for face := 0; face < cubeFaces; face++ {
+		images[face] = image.NewRGBA(image.Rect(0, 0, size, size))
+		for y := 0; y < size; y++ {
+			for x := 0; x < size; x++ {
+				// Map the pixel center into [-1, 1] face coordinates.
+				u := 2*(float64(x)+0.5)/float64(size) - 1
+				v := 2*(float64(y)+0.5)/float64(size) - 1
+				dx, dy, dz := cubeFaceDirection(face, u, v)
+				images[face].SetRGBA(x, y, sampleEquirectangular(pano, dx, dy, dz))
+			}
+		}
+	}
+	return newCubemapFromFaces(images)
+}
+
+// cubeFaceDirection returns the (unnormalized) direction through face coordinate u, v following the
+// orientation table in the GL specification.
+func cubeFaceDirection(face int, u, v float64) (float64, float64, float64) {
+	switch face {
+	case 0:
+		return 1, -v, -u
+	case 1:
+		return -1, -v, u
+	case 2:
+		return u, 1, v
+	case 3:
+		return u, -1, -v
+	case 4:
+		return u, -v, 1
+	default:
+		return -u, -v, -1
+	}
+}
+
+func sampleEquirectangular(pano *image.RGBA, dx, dy, dz float64) color.RGBA {
+	l := math.Sqrt(dx*dx + dy*dy + dz*dz)
+	lon := math.Atan2(dz, dx)
+	lat := math.Asin(dy / l)
+	size := pano.Rect.Size()
+	px := int((lon/(2*math.Pi) + 0.5) * float64(size.X))
+	py := int((0.5 - lat/math.Pi) * float64(size.Y))
+	if px >= size.X {
+		px = size.X - 1
+	}
+	if py >= size.Y {
+		py = size.Y - 1
+	}
+	return pano.RGBAAt(px, py)
+}
+
+func newCubemapFromFaces(faces [cubeFaces]*image.RGBA) (Texture, error) {
+	size := faces[0].Rect.Size()
+	if size.X != size.Y {
+		return Texture{}, fmt.Errorf("cubemap faces must be square, got %v", size)
+	}
+	for i, f := range faces {
+		if f.Rect.Size() != size {
+			return Texture{}, fmt.Errorf("cubemap face %d is %v, expected %v", i, f.Rect.Size(), size)
+		}
+	}
+
+	var id uint32
+	gl.GenTextures(1, &id)
+	gl.ActiveTexture(gl.TEXTURE0)
+	gl.BindTexture(gl.TEXTURE_CUBE_MAP, id)
+	gl.TexParameteri(gl.TEXTURE_CUBE_MAP, gl.TEXTURE_MIN_FILTER, gl.LINEAR)
+	gl.TexParameteri(gl.TEXTURE_CUBE_MAP, gl.TEXTURE_MAG_FILTER, gl.LINEAR)
+	gl.TexParameteri(gl.TEXTURE_CUBE_MAP, gl.TEXTURE_WRAP_S, gl.CLAMP_TO_EDGE)
+	gl.TexParameteri(gl.TEXTURE_CUBE_MAP, gl.TEXTURE_WRAP_T, gl.CLAMP_TO_EDGE)
+	gl.TexParameteri(gl.TEXTURE_CUBE_MAP, gl.TEXTURE_WRAP_R, gl.CLAMP_TO_EDGE)
+	for i, f := range faces {
+		gl.TexImage2D(gl.TEXTURE_CUBE_MAP_POSITIVE_X+uint32(i),
+			0,
+			gl.RGBA,
+			int32(size.X),
+			int32(size.Y),
+			0,
+			gl.RGBA,
+			gl.UNSIGNED_BYTE,
+			gl.Ptr(f.Pix))
+	}
+
+	return Texture{id: id, target: gl.TEXTURE_CUBE_MAP}, nil
+}
@@ -0,0 +1,117 @@
+package texture
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io/ioutil"
+)
+
+const (
+	ddsMagic      = "DDS "
+	ddsHeaderSize = 124
+	ddsDX10Size   = 20
+
+	ddsPixelFormatFourCC = 0x4
+
+	// DXGI_FORMAT values from the DX10 extended header.
+	dxgiBC1Unorm     = 71
+	dxgiBC1UnormSRGB = 72
+	dxgiBC2Unorm     = 74
+	dxgiBC2UnormSRGB = 75
+	dxgiBC3Unorm     = 77
+	dxgiBC3UnormSRGB = 78
+	dxgiBC7Unorm     = 98
+	dxgiBC7UnormSRGB = 99
+)
+
+// loadDDS reads a DirectDraw Surface holding BC1, BC2, BC3 or BC7 data. Only plain 2D textures are
+// supported.
+func loadDDS(file string) (*Image, error) {
+	b, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	img, err := parseDDS(b)
+	if err != nil {
+		return nil, compressedError(file, err)
+	}
+	return img, nil
+}
+
+func parseDDS(b []byte) (*Image, error) {
+	if len(b) < 4+ddsHeaderSize || string(b[:4]) != ddsMagic {
+		return nil, fmt.Errorf("not a DDS file")
+	}
+	header := b[4 : 4+ddsHeaderSize]
+	le := binary.LittleEndian
+	if le.Uint32(header[0:]) != ddsHeaderSize {
+		return nil, fmt.Errorf("bad DDS header size %d", le.Uint32(header[0:]))
+	}
+	height := int32(le.Uint32(header[8:]))
+	width := int32(le.Uint32(header[12:]))
+	mips := int(le.Uint32(header[24:]))
+	if err := checkHeader(width, height, mips); err != nil {
+		return nil, err
+	}
+
+	// The pixel format struct starts at offset 72 of the header.
+	pfFlags := le.Uint32(header[76:])
+	fourCC := string(header[80:84])
+	if pfFlags&ddsPixelFormatFourCC == 0 {
+		return nil, fmt.Errorf("uncompressed DDS files are not supported")
+	}
+
+	data := b[4+ddsHeaderSize:]
+	var format uint32
+	switch fourCC {
+	case "DXT1":
+		format = formatBC1
+	case "DXT3":
+		format = formatBC2
+	case "DXT5":
+		format = formatBC3
+	case "DX10":
+		if len(data) < ddsDX10Size {
+			return nil, fmt.Errorf("truncated DX10 header")
+		}
+		switch le.Uint32(data[0:]) {
+		case dxgiBC1Unorm:
+			format = formatBC1
+		case dxgiBC1UnormSRGB:
+			format = formatBC1SRGB
+		case dxgiBC2Unorm:
+			format = formatBC2
+		case dxgiBC2UnormSRGB:
+			format = formatBC2SRGB
+		case dxgiBC3Unorm:
+			format = formatBC3
+		case dxgiBC3UnormSRGB:
+			format = formatBC3SRGB
+		case dxgiBC7Unorm:
+			format = formatBC7
+		case dxgiBC7UnormSRGB:
+			format = formatBC7SRGB
+		default:
+			return nil, fmt.Errorf("unsupported DXGI format %d", le.Uint32(data[0:]))
+		}
+		if arraySize := le.Uint32(data[12:]); arraySize > 1 {
+			return nil, fmt.Errorf("DDS texture arrays are not supported")
+		}
+		data = data[ddsDX10Size:]
+	default:
+		return nil, fmt.Errorf("unsupported DDS FourCC %q", fourCC)
+	}
+
+	levels, err := splitLevels(data, format, width, height, mips)
+	if err != nil {
+		return nil, err
+	}
+	img := &Image{Width: width, Height: height, format: format, levels: levels}
+	// DDS files store their top row first.
+	if FlipVertically {
+		if err := flipLevels(img); err != nil {
+			return nil, err
+		}
+	}
+	return img, nil
+}
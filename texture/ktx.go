@@ -0,0 +1,124 @@
+package texture
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io/ioutil"
+	"strings"
+)
+
+var ktxIdentifier = []byte{0xAB, 'K', 'T', 'X', ' ', '1', '1', 0xBB, '\r', '\n', 0x1A, '\n'}
+
+const (
+	ktxHeaderSize = 64
+	ktxEndianness = 0x04030201
+)
+
+// loadKTX reads a KTX 1.1 container holding a compressed 2D texture.
+func loadKTX(file string) (*Image, error) {
+	b, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	img, err := parseKTX(b)
+	if err != nil {
+		return nil, compressedError(file, err)
+	}
+	return img, nil
+}
+
+func parseKTX(b []byte) (*Image, error) {
+	if len(b) < ktxHeaderSize || !bytes.Equal(b[:len(ktxIdentifier)], ktxIdentifier) {
+		return nil, fmt.Errorf("not a KTX file")
+	}
+	var order binary.ByteOrder = binary.LittleEndian
+	if order.Uint32(b[12:]) != ktxEndianness {
+		order = binary.BigEndian
+		if order.Uint32(b[12:]) != ktxEndianness {
+			return nil, fmt.Errorf("bad KTX endianness marker")
+		}
+	}
+	field := func(i int) uint32 { return order.Uint32(b[12+4*i:]) }
+	glType := field(1)
+	internalFormat := field(4)
+	width := int32(field(6))
+	height := int32(field(7))
+	depth := field(8)
+	arrayElements := field(9)
+	faces := field(10)
+	mips := int(field(11))
+	kvBytes := int(field(12))
+
+	if glType != 0 {
+		return nil, fmt.Errorf("uncompressed KTX files are not supported")
+	}
+	if depth > 1 || arrayElements > 0 || faces != 1 {
+		return nil, fmt.Errorf("only single 2D KTX textures are supported")
+	}
+	if _, err := blockBytes(internalFormat); err != nil {
+		return nil, err
+	}
+	if err := checkHeader(width, height, mips); err != nil {
+		return nil, err
+	}
+	if mips < 1 {
+		mips = 1
+	}
+
+	data := b[ktxHeaderSize:]
+	if len(data) < kvBytes {
+		return nil, fmt.Errorf("truncated key/value data")
+	}
+	orientation := ktxValue(data[:kvBytes], order, "KTXorientation")
+	data = data[kvBytes:]
+
+	levels := make([][]byte, 0, mips)
+	for i := 0; i < mips; i++ {
+		if len(data) < 4 {
+			return nil, fmt.Errorf("truncated mip level %d", i)
+		}
+		size := int(order.Uint32(data))
+		data = data[4:]
+		if len(data) < size {
+			return nil, fmt.Errorf("truncated mip level %d: need %d bytes, have %d", i, size, len(data))
+		}
+		levels = append(levels, data[:size])
+		// Each level is padded to a multiple of four bytes.
+		padded := (size + 3) &^ 3
+		if padded > len(data) {
+			padded = len(data)
+		}
+		data = data[padded:]
+	}
+	img := &Image{Width: width, Height: height, format: internalFormat, levels: levels}
+	// KTX files store their top row first unless their orientation says T=u, up.
+	if FlipVertically && !strings.Contains(orientation, "T=u") {
+		if err := flipLevels(img); err != nil {
+			return nil, err
+		}
+	}
+	return img, nil
+}
+
+// ktxValue returns the value stored under key in KTX key/value data, or "" if there is none.
+func ktxValue(kv []byte, order binary.ByteOrder, key string) string {
+	for len(kv) >= 4 {
+		size := int(order.Uint32(kv))
+		kv = kv[4:]
+		if size > len(kv) {
+			return ""
+		}
+		entry := kv[:size]
+		if i := bytes.IndexByte(entry, 0); i >= 0 && string(entry[:i]) == key {
+			return string(bytes.TrimRight(entry[i+1:], "\x00"))
+		}
+		// Each entry is padded to a multiple of four bytes.
+		padded := (size + 3) &^ 3
+		if padded > len(kv) {
+			padded = len(kv)
+		}
+		kv = kv[padded:]
+	}
+	return ""
+}
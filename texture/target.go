@@ -0,0 +1,21 @@
+package texture
+
+import (
+	"github.com/go-gl/gl/v4.1-core/gl"
+)
+
+// NewRenderTarget allocates an uninitialised TEXTURE_2D to render into, such as gl.RGBA16F for HDR color.
+// It is linearly filtered and clamped so post-processing passes can sample it directly.
+func NewRenderTarget(width, height int32, internalFormat uint32) Texture {
+	var id uint32
+	gl.GenTextures(1, &id)
+	gl.ActiveTexture(gl.TEXTURE0)
+	gl.BindTexture(gl.TEXTURE_2D, id)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, gl.LINEAR)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, gl.LINEAR)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_S, gl.CLAMP_TO_EDGE)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_T, gl.CLAMP_TO_EDGE)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAX_LEVEL, 0)
+	gl.TexImage2D(gl.TEXTURE_2D, 0, int32(internalFormat), width, height, 0, gl.RGBA, gl.FLOAT, nil)
+	return Texture{id: id, target: gl.TEXTURE_2D}
+}
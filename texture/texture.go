@@ -4,52 +4,191 @@ import (
 	"fmt"
 	"image"
 	"image/draw"
+	_ "image/gif"
 	_ "image/jpeg"
+	_ "image/png"
+	"io"
 	"os"
+	"path/filepath"
+	"strings"
 
 	"github.com/go-gl/gl/v4.1-core/gl"
+	_ "golang.org/x/image/bmp"
 )
 
+// FlipVertically controls whether images are flipped before upload. Image files store their top row first
+// while GL expects the bottom row first, so this defaults to true. BC7 images can't be flipped, so they
+// fail to load unless their KTX orientation already puts the bottom row first.
+var FlipVertically = true
+
 type Texture struct {
-	id uint32
+	id     uint32
+	target uint32
+}
+
+// Image is a decoded texture held in client memory. Decoding touches no GL state, so it is safe to do off
+// the render thread; only Upload needs the context.
+type Image struct {
+	Width, Height int32
+
+	// Exactly one of rgba or levels is set.
+	rgba *image.RGBA
+
+	// Compressed mip chain, largest level first, in the given GL internal format.
+	format uint32
+	levels [][]byte
 }
 
 func New(file string) (Texture, error) {
-	imgFile, err := os.Open(file)
+	img, err := Load(file)
 	if err != nil {
-		return Texture{0}, err
+		return Texture{}, err
 	}
-	img, _, err := image.Decode(imgFile)
+	return img.Upload()
+}
+
+// Load decodes file into an Image. DDS and KTX containers are recognised by extension, everything else goes
+// through the registered image decoders (JPEG, PNG, GIF and BMP).
+func Load(file string) (*Image, error) {
+	switch strings.ToLower(filepath.Ext(file)) {
+	case ".dds":
+		return loadDDS(file)
+	case ".ktx":
+		return loadKTX(file)
+	}
+	rgba, err := loadRGBA(file)
 	if err != nil {
-		return Texture{0}, err
+		return nil, err
 	}
-	rgba := image.NewRGBA(img.Bounds())
-	if rgba.Stride != rgba.Rect.Size().X*4 {
-		return Texture{0}, fmt.Errorf("unsupported stride")
+	if FlipVertically {
+		flipRGBA(rgba)
 	}
-	draw.Draw(rgba, rgba.Bounds(), img, image.Point{0, 0}, draw.Src)
+	size := rgba.Rect.Size()
+	return &Image{Width: int32(size.X), Height: int32(size.Y), rgba: rgba}, nil
+}
+
+// Upload creates a new TEXTURE_2D from the image.
+func (img *Image) Upload() (Texture, error) {
 	var id uint32
 	gl.GenTextures(1, &id)
+	t := Texture{id: id, target: gl.TEXTURE_2D}
+	if err := t.upload(img); err != nil {
+		gl.DeleteTextures(1, &id)
+		return Texture{}, err
+	}
+	return t, nil
+}
+
+// Reload replaces the contents of an existing TEXTURE_2D, keeping its name so bound users see the new data.
+func (t *Texture) Reload(img *Image) error {
+	if t.target != gl.TEXTURE_2D {
+		return fmt.Errorf("only 2D textures can be reloaded")
+	}
+	return t.upload(img)
+}
+
+func (t *Texture) upload(img *Image) error {
 	gl.ActiveTexture(gl.TEXTURE0)
-	gl.BindTexture(gl.TEXTURE_2D, id)
-	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, gl.LINEAR)
+	gl.BindTexture(gl.TEXTURE_2D, t.id)
 	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, gl.LINEAR)
 	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_S, gl.CLAMP_TO_EDGE)
 	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_T, gl.CLAMP_TO_EDGE)
+
+	if img.rgba == nil {
+		return uploadCompressed(img)
+	}
+
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, gl.LINEAR)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAX_LEVEL, 0)
 	gl.TexImage2D(gl.TEXTURE_2D,
 		0,
 		gl.RGBA,
-		int32(rgba.Rect.Size().X),
-		int32(rgba.Rect.Size().Y),
+		img.Width,
+		img.Height,
 		0,
 		gl.RGBA,
 		gl.UNSIGNED_BYTE,
-		gl.Ptr(rgba.Pix))
+		gl.Ptr(img.rgba.Pix))
+	return nil
+}
+
+// Decode reads an encoded JPEG, PNG, GIF or BMP image from r, such as one embedded in a model file. It is
+// flipped like Load.
+func Decode(r io.Reader) (*Image, error) {
+	img, _, err := image.Decode(r)
+	if err != nil {
+		return nil, err
+	}
+	rgba, err := toRGBA(img)
+	if err != nil {
+		return nil, err
+	}
+	if FlipVertically {
+		flipRGBA(rgba)
+	}
+	size := rgba.Rect.Size()
+	return &Image{Width: int32(size.X), Height: int32(size.Y), rgba: rgba}, nil
+}
 
-	return Texture{id: id}, nil
+// FromRGBA wraps an image generated in memory, such as a glyph atlas. It is uploaded as is, top row first.
+func FromRGBA(rgba *image.RGBA) (*Image, error) {
+	size := rgba.Rect.Size()
+	if rgba.Stride != size.X*4 || rgba.Rect.Min != (image.Point{}) {
+		return nil, fmt.Errorf("unsupported stride")
+	}
+	return &Image{Width: int32(size.X), Height: int32(size.Y), rgba: rgba}, nil
 }
 
+// loadRGBA decodes file into a tightly packed RGBA image ready for upload.
+func loadRGBA(file string) (*image.RGBA, error) {
+	imgFile, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer imgFile.Close()
+	img, _, err := image.Decode(imgFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode %v: %v", file, err)
+	}
+	return toRGBA(img)
+}
+
+func toRGBA(img image.Image) (*image.RGBA, error) {
+	b := img.Bounds()
+	rgba := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	if rgba.Stride != rgba.Rect.Size().X*4 {
+		return nil, fmt.Errorf("unsupported stride")
+	}
+	draw.Draw(rgba, rgba.Bounds(), img, b.Min, draw.Src)
+	return rgba, nil
+}
+
+// flipRGBA swaps rows in place so the bottom row comes first.
+func flipRGBA(rgba *image.RGBA) {
+	h := rgba.Rect.Size().Y
+	row := make([]byte, rgba.Stride)
+	for y := 0; y < h/2; y++ {
+		top := rgba.Pix[y*rgba.Stride : (y+1)*rgba.Stride]
+		bottom := rgba.Pix[(h-1-y)*rgba.Stride : (h-y)*rgba.Stride]
+		copy(row, top)
+		copy(top, bottom)
+		copy(bottom, row)
+	}
+}
+
+// Bind binds the texture to the given texture unit using whichever target it was created with.
 func (t *Texture) Bind(slot uint32) {
 	gl.ActiveTexture(slot)
-	gl.BindTexture(gl.TEXTURE_2D, t.id)
+	gl.BindTexture(t.target, t.id)
+}
+
+// Delete frees the GL texture. The Texture must not be used afterwards.
+func (t *Texture) Delete() {
+	gl.DeleteTextures(1, &t.id)
+	t.id = 0
+}
+
+// ID returns the GL texture name, for attaching the texture to a framebuffer.
+func (t *Texture) ID() uint32 {
+	return t.id
 }
@@ -0,0 +1,150 @@
+package ui
+
+import (
+	"github.com/go-gl/glfw/v3.1/glfw"
+	"github.com/go-gl/mathgl/mgl32"
+
+	"github.com/brandonnelson3/GoPlay/font"
+	"github.com/brandonnelson3/GoPlay/input"
+)
+
+// Global immediate mode UI. Windows and widgets are declared every frame between Update and the font
+// manager's Flush, and report what the user did to them as they are declared. Widgets are identified by
+// their window title and label, so labels must be unique within a window.
+var M manager
+
+type manager struct {
+	// Visible shows the UI and captures the keyboard and mouse for it. F1 toggles it.
+	Visible bool
+	// Font draws every window. Init sets it to the font manager's default.
+	Font *font.Font
+
+	windows map[string]*window
+	current *window
+
+	// hot is the widget under the mouse, active the one being clicked or dragged and focus the text input
+	// being typed in. The empty string is no widget.
+	hot, active, focus string
+	// released is the widget the mouse button was let go over this frame.
+	released string
+
+	mouse         mgl32.Vec2
+	down, wasDown bool
+	// drag is the mouse's offset from the top left of the window being dragged.
+	drag mgl32.Vec2
+}
+
+// window is what a window remembers between frames.
+type window struct {
+	title    string
+	position mgl32.Vec2
+	width    float32
+	// height is the window's height at the end of the previous frame, so the background can be drawn
+	// before its contents are known.
+	height float32
+
+	// cursor is where the next widget goes.
+	cursor mgl32.Vec2
+}
+
+// Layout and colors shared by every window.
+const (
+	padding = 6
+	spacing = 4
+)
+
+var (
+	windowColor      = mgl32.Vec4{0.08, 0.08, 0.1, 0.85}
+	titleColor       = mgl32.Vec4{0.2, 0.3, 0.5, 1}
+	widgetColor      = mgl32.Vec4{0.2, 0.2, 0.25, 1}
+	widgetHotColor   = mgl32.Vec4{0.3, 0.3, 0.38, 1}
+	widgetFocusColor = mgl32.Vec4{0.25, 0.25, 0.35, 1}
+	accentColor      = mgl32.Vec4{0.35, 0.55, 0.9, 1}
+	textColor        = mgl32.Vec4{1, 1, 1, 1}
+)
+
+func init() {
+	M = manager{windows: map[string]*window{}}
+}
+
+// Init picks the UI's font. Must be called after font.M.Init.
+func (m *manager) Init() {
+	m.Font = font.M.Default
+}
+
+// Update starts a UI frame: it toggles the UI with F1 and picks up the mouse. Call it once per frame after
+// input.M.RunKeys and before declaring any windows.
+func (m *manager) Update() {
+	if input.M.Pressed(glfw.KeyF1) {
+		m.Visible = !m.Visible
+		m.active, m.focus = "", ""
+	}
+	input.M.Capture(m.Visible)
+
+	m.mouse = mgl32.Vec2{float32(input.M.MouseX), float32(input.M.MouseY)}
+	m.wasDown = m.down
+	// A click that starts and ends between two frames still counts as down for one.
+	m.down = input.M.MouseDown(glfw.MouseButtonLeft) || input.M.MouseClicked(glfw.MouseButtonLeft)
+	m.hot, m.released = "", ""
+	if !m.down {
+		if m.wasDown {
+			m.released = m.active
+		}
+		m.active = ""
+	}
+	if input.M.MouseClicked(glfw.MouseButtonLeft) || input.M.Pressed(glfw.KeyEscape) || input.M.Pressed(glfw.KeyEnter) {
+		// Clicking anywhere but the focused text input, or finishing with it, unfocuses it. A click on it
+		// focuses it again as it is declared.
+		m.focus = ""
+	}
+}
+
+func (m *manager) lineHeight() float32 {
+	return m.Font.LineHeight + 4
+}
+
+// inside reports whether the mouse is over the rectangle at position, size big.
+func (m *manager) inside(position, size mgl32.Vec2) bool {
+	return m.mouse.X() >= position.X() && m.mouse.X() < position.X()+size.X() &&
+		m.mouse.Y() >= position.Y() && m.mouse.Y() < position.Y()+size.Y()
+}
+
+// interact updates the hot and active widget for id covering the rectangle at position, size big, and
+// reports whether the mouse was pressed on it this frame.
+func (m *manager) interact(id string, position, size mgl32.Vec2) (pressed bool) {
+	if m.inside(position, size) && (m.active == "" || m.active == id) {
+		m.hot = id
+		if m.down && !m.wasDown {
+			m.active = id
+			return true
+		}
+	}
+	return false
+}
+
+// next reserves a row height tall in the current window, returning its top left corner and width.
+func (m *manager) next(height float32) (mgl32.Vec2, float32) {
+	w := m.current
+	position := w.cursor
+	w.cursor[1] += height + spacing
+	return position, w.width - 2*padding
+}
+
+func (m *manager) fill(position, size mgl32.Vec2, color mgl32.Vec4) {
+	font.FillRect(m.Font, position.X(), position.Y(), size.X(), size.Y(), color)
+}
+
+func (m *manager) text(x, y float32, s string, align font.Align) {
+	font.Draw(m.Font, x, y+2, s, textColor, align)
+}
+
+// background picks the color behind widget id.
+func (m *manager) background(id string) mgl32.Vec4 {
+	switch {
+	case m.focus == id:
+		return widgetFocusColor
+	case m.hot == id || m.active == id:
+		return widgetHotColor
+	}
+	return widgetColor
+}
@@ -0,0 +1,170 @@
+package ui
+
+import (
+	"fmt"
+
+	"github.com/go-gl/glfw/v3.1/glfw"
+	"github.com/go-gl/mathgl/mgl32"
+
+	"github.com/brandonnelson3/GoPlay/font"
+	"github.com/brandonnelson3/GoPlay/input"
+)
+
+// Begin starts a window, placed at x, y the first time it is shown and dragged around by its title bar
+// after that. It returns false while the UI is hidden. Otherwise widgets declared next go in the window
+// until End is called.
+func Begin(title string, x, y, width float32) bool {
+	m := &M
+	if !m.Visible {
+		return false
+	}
+	w, ok := m.windows[title]
+	if !ok {
+		w = &window{title: title, position: mgl32.Vec2{x, y}, width: width}
+		m.windows[title] = w
+	}
+	m.current = w
+
+	titleSize := mgl32.Vec2{w.width, m.lineHeight()}
+	if m.interact(title, w.position, titleSize) {
+		m.drag = m.mouse.Sub(w.position)
+	}
+	if m.active == title {
+		w.position = m.mouse.Sub(m.drag)
+	}
+
+	m.fill(w.position, mgl32.Vec2{w.width, w.height}, windowColor)
+	m.fill(w.position, titleSize, titleColor)
+	m.text(w.position.X()+padding, w.position.Y(), title, font.AlignLeft)
+	w.cursor = w.position.Add(mgl32.Vec2{padding, titleSize.Y() + padding})
+	return true
+}
+
+// End finishes the current window.
+func End() {
+	m := &M
+	w := m.current
+	w.height = w.cursor.Y() - spacing + padding - w.position.Y()
+	m.current = nil
+}
+
+// Label writes s on its own row.
+func Label(s string) {
+	m := &M
+	position, _ := m.next(m.lineHeight())
+	m.text(position.X(), position.Y(), s, font.AlignLeft)
+}
+
+// Button draws a button and reports whether it was clicked.
+func Button(label string) bool {
+	m := &M
+	id := m.current.title + "/" + label
+	position, width := m.next(m.lineHeight())
+	size := mgl32.Vec2{width, m.lineHeight()}
+	m.interact(id, position, size)
+	m.fill(position, size, m.background(id))
+	m.text(position.X()+width/2, position.Y(), label, font.AlignCenter)
+	return m.released == id && m.inside(position, size)
+}
+
+// Checkbox draws a box ticked while value is true, flipping value when clicked. It reports whether value
+// changed.
+func Checkbox(label string, value *bool) bool {
+	m := &M
+	id := m.current.title + "/" + label
+	height := m.lineHeight()
+	position, width := m.next(height)
+	m.interact(id, position, mgl32.Vec2{width, height})
+	changed := m.released == id && m.inside(position, mgl32.Vec2{width, height})
+	if changed {
+		*value = !*value
+	}
+
+	m.fill(position, mgl32.Vec2{height, height}, m.background(id))
+	if *value {
+		m.fill(position.Add(mgl32.Vec2{4, 4}), mgl32.Vec2{height - 8, height - 8}, accentColor)
+	}
+	m.text(position.X()+height+spacing, position.Y(), label, font.AlignLeft)
+	return changed
+}
+
+// labelled reserves a row with label on its left, returning where the widget on its right goes and how big
+// it is.
+func (m *manager) labelled(label string) (mgl32.Vec2, mgl32.Vec2) {
+	position, width := m.next(m.lineHeight())
+	m.text(position.X(), position.Y(), label, font.AlignLeft)
+	labelWidth := width * 0.4
+	return position.Add(mgl32.Vec2{labelWidth, 0}), mgl32.Vec2{width - labelWidth, m.lineHeight()}
+}
+
+// slider draws a track for a value at fraction of the way from its minimum to its maximum, showing text on
+// it. While it is dragged it returns where the mouse is along it, and true.
+func (m *manager) slider(label string, fraction float32, text string) (float32, bool) {
+	id := m.current.title + "/" + label
+	position, size := m.labelled(label)
+	m.interact(id, position, size)
+	dragged := m.active == id
+	if dragged {
+		fraction = mgl32.Clamp((m.mouse.X()-position.X())/size.X(), 0, 1)
+	}
+
+	m.fill(position, size, m.background(id))
+	m.fill(position, mgl32.Vec2{size.X() * mgl32.Clamp(fraction, 0, 1), size.Y()}, accentColor)
+	m.text(position.X()+size.X()/2, position.Y(), text, font.AlignCenter)
+	return fraction, dragged
+}
+
+// SliderFloat lets value be dragged between min and max. It reports whether value changed.
+func SliderFloat(label string, value *float32, min, max float32) bool {
+	fraction, dragged := M.slider(label, (*value-min)/(max-min), fmt.Sprintf("%.2f", *value))
+	if !dragged {
+		return false
+	}
+	old := *value
+	*value = min + fraction*(max-min)
+	return *value != old
+}
+
+// SliderInt lets value be dragged between min and max. It reports whether value changed.
+func SliderInt(label string, value *int, min, max int) bool {
+	fraction, dragged := M.slider(label, float32(*value-min)/float32(max-min), fmt.Sprintf("%d", *value))
+	if !dragged {
+		return false
+	}
+	old := *value
+	*value = min + int(fraction*float32(max-min)+0.5)
+	return *value != old
+}
+
+// TextInput lets value be edited once clicked, until Enter or Escape is pressed or something else is
+// clicked. It reports whether value changed.
+func TextInput(label string, value *string) bool {
+	m := &M
+	id := m.current.title + "/" + label
+	position, size := m.labelled(label)
+	if m.interact(id, position, size) {
+		m.focus = id
+	}
+
+	changed := false
+	if m.focus == id {
+		runes := []rune(*value)
+		if input.M.Pressed(glfw.KeyBackspace) && len(runes) > 0 {
+			runes = runes[:len(runes)-1]
+			changed = true
+		}
+		if chars := input.M.Chars(); len(chars) > 0 {
+			runes = append(runes, chars...)
+			changed = true
+		}
+		*value = string(runes)
+	}
+
+	m.fill(position, size, m.background(id))
+	text := *value
+	if m.focus == id {
+		text += "_"
+	}
+	m.text(position.X()+spacing, position.Y(), text, font.AlignLeft)
+	return changed
+}
@@ -0,0 +1,79 @@
+package voxelterrain
+
+import (
+	"sort"
+
+	"github.com/go-gl/gl/v4.1-core/gl"
+	"github.com/go-gl/mathgl/mgl32"
+
+	"github.com/brandonnelson3/GoPlay/shaders"
+	"github.com/brandonnelson3/GoPlay/voxelterrain/voxel"
+)
+
+// mesh is the part of a cell's faces drawn with one render mode.
+type mesh struct {
+	verts   []shaders.TerrainShader_Vertex
+	indices []uint32
+	// centers holds twice the cell local centre of each quad in a translucent mesh, so it can be sorted.
+	centers [][3]int32
+	vbo     *shaders.VertexBuffer
+	// uploaded is false while verts and indices hold a mesh the vbo doesn't have yet.
+	uploaded bool
+	// sortedFrom is the camera voxel a translucent mesh was last sorted for, valid while sorted is true.
+	sorted     bool
+	sortedFrom voxel.Pos
+}
+
+// upload pushes the latest verts and indices to the GPU. Must be called on the render thread.
+func (m *mesh) upload() {
+	m.uploaded = true
+	if len(m.verts) == 0 {
+		m.delete()
+		return
+	}
+	if m.vbo == nil {
+		m.vbo = shaders.NewVertexBuffer(shaders.TerrainShader_Layout, m.verts, m.indices)
+		return
+	}
+	m.vbo.Update(m.verts, m.indices)
+}
+
+// draw uploads the mesh if it changed and draws it. Must be called on the render thread.
+func (m *mesh) draw() {
+	if !m.uploaded {
+		m.upload()
+	}
+	if m.vbo != nil {
+		m.vbo.Draw(gl.TRIANGLES)
+	}
+}
+
+func (m *mesh) delete() {
+	if m.vbo != nil {
+		m.vbo.Delete()
+		m.vbo = nil
+	}
+}
+
+// sortBackToFront orders the quads from farthest to nearest eye, given in cell local coordinates. Only the
+// indices move.
+func (m *mesh) sortBackToFront(eye mgl32.Vec3) {
+	distances := make([]float32, len(m.centers))
+	order := make([]int, len(m.centers))
+	for i, c := range m.centers {
+		d := mgl32.Vec3{float32(c[0]) / 2, float32(c[1]) / 2, float32(c[2]) / 2}.Sub(eye)
+		distances[i] = d.Dot(d)
+		order[i] = i
+	}
+	sort.Slice(order, func(i, j int) bool {
+		return distances[order[i]] > distances[order[j]]
+	})
+	indices := make([]uint32, 0, len(m.indices))
+	centers := make([][3]int32, 0, len(m.centers))
+	for _, q := range order {
+		indices = append(indices, m.indices[q*6:q*6+6]...)
+		centers = append(centers, m.centers[q])
+	}
+	m.indices, m.centers = indices, centers
+	m.uploaded = false
+}
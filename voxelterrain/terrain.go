@@ -1,180 +1,151 @@
 package voxelterrain
 
 import (
+	"log"
+	"math"
+	"sort"
 	"time"
 
 	"github.com/aquilax/go-perlin"
 	"github.com/go-gl/gl/v4.1-core/gl"
+	"github.com/go-gl/glfw/v3.1/glfw"
 	"github.com/go-gl/mathgl/mgl32"
 
 	"sync"
 
 	"fmt"
 
+	"github.com/brandonnelson3/GoPlay/assetmanager"
 	"github.com/brandonnelson3/GoPlay/camera"
+	"github.com/brandonnelson3/GoPlay/debugdraw"
+	"github.com/brandonnelson3/GoPlay/fog"
+	"github.com/brandonnelson3/GoPlay/input"
+	"github.com/brandonnelson3/GoPlay/scene"
 	"github.com/brandonnelson3/GoPlay/shaders"
 	"github.com/brandonnelson3/GoPlay/texture"
-	"github.com/brandonnelson3/GoPlay/window"
+	"github.com/brandonnelson3/GoPlay/voxelterrain/voxel"
 )
 
-const (
-	cellsize     = 32
-	cellsizep1   = cellsize + 1
-	cellsizep1_2 = cellsizep1 * cellsizep1
-	cellsizep1_3 = cellsizep1 * cellsizep1 * cellsizep1
+// cutoutAlpha is the alpha below which cutout block texels are discarded, in color and shadow passes
+// alike.
+const cutoutAlpha = 0.5
 
-	worldSize   = 6
-	worldSizem1 = worldSize - 1
-	worldSizet2 = worldSize * 2
-	worldTotal  = (2 * worldSize) * (2 * worldSize) * (2 * worldSize)
-)
+// DefaultWorldSize is how many cells new terrain loads out from the camera's cell, see SetWorldSize.
+const DefaultWorldSize = 6
+
+// viewDistance is how far terrain is loaded in every direction from the cell the camera is in with the
+// given world size. Anything further out streams in and out as the camera moves.
+func viewDistance(worldSize int32) float32 {
+	return float32((worldSize - 1) * voxel.Size)
+}
 
 var (
-	halfCell = mgl32.Vec3{cellsize / 2, cellsize / 2, cellsize / 2}
+	halfCell = mgl32.Vec3{voxel.Size / 2, voxel.Size / 2, voxel.Size / 2}
 )
 
 type cell struct {
-	verts []shaders.DefaultShader_Vertex
-	vbo   *shaders.DefaultShader_VertexBuffer
-
-	data [cellsizep1_3]byte
-	id   cellid
+	voxel.Cell
+	// One mesh per render mode.
+	meshes [voxel.RenderModes]mesh
+	// edits counts changes to what the cell's mesh can see, so meshes built from an older snapshot are
+	// dropped.
+	edits int
 }
 
 type terrain struct {
-	shader  *shaders.DefaultShader
-	texture texture.Texture
+	shader            *shaders.TerrainShader
+	depthShader       *shaders.DepthShader
+	cutoutDepthShader *shaders.DepthShader
+	texture           texture.Texture
 
 	mu    sync.Mutex
-	world map[cellid]*cell
-}
-
-func idx(x, y, z int32) int32 {
-	return (x*cellsizep1_2 + y*cellsizep1 + z)
+	world map[voxel.CellID]*cell
+	// Cells whose mesh is stale because a voxel or light value it can see changed.
+	dirty map[voxel.CellID]bool
+
+	// worldSize is how many cells are loaded out from the camera's cell. Guarded by mu.
+	worldSize int32
+	// generators holds a channel per cell offset from the camera's cell that is closed to stop the
+	// goroutine loading it. Guarded by mu.
+	generators map[voxel.CellID]chan struct{}
+
+	// ShowCells outlines every loaded cell, colored by its state. See outlineCells.
+	ShowCells bool
 }
 
 func (c *cell) generate() {
 	noise := perlin.NewPerlin(2, 2, 3, int64(0))
-	for x := int32(0); x < cellsizep1; x++ {
-		for z := int32(0); z < cellsizep1; z++ {
-			h := ((noise.Noise2D(float64(c.id.x*cellsize+x)/10.0, float64(c.id.z*cellsize+z)/10.0) + 1) / 2.0) * 20
-			for y := int32(0); y < cellsizep1; y++ {
-				index := idx(x, y, z)
+	for x := int32(-1); x <= voxel.Size; x++ {
+		for z := int32(-1); z <= voxel.Size; z++ {
+			h := ((noise.Noise2D(float64(c.ID.X*voxel.Size+x)/10.0, float64(c.ID.Z*voxel.Size+z)/10.0) + 1) / 2.0) * 20
+			for y := int32(-1); y <= voxel.Size; y++ {
+				index := voxel.Idx(x, y, z)
 
-				if float64(c.id.y*cellsize+y) < h {
+				if float64(c.ID.Y*voxel.Size+y) < h {
 					//if y == 0 {
-					c.data[index] = 1
+					c.Data[index] = voxel.Crate
 				} else {
-					c.data[index] = 0
+					c.Data[index] = voxel.Air
 				}
 			}
 		}
 	}
 }
 
-func (c *cell) polygonize(s *shaders.DefaultShader) {
-	verts := []shaders.DefaultShader_Vertex{}
-	for x := int32(0); x < cellsize; x++ {
-		for y := int32(0); y < cellsize; y++ {
-			for z := int32(0); z < cellsize; z++ {
-				fx := float32(x)
-				fy := float32(y)
-				fz := float32(z)
-
-				index := idx(x, y, z)
-
-				index1x := idx(x+1, y, z)
-				index1y := idx(x, y+1, z)
-				index1z := idx(x, y, z+1)
-
-				if c.data[index] == 0 && c.data[index1x] != 0 || c.data[index] != 0 && c.data[index1x] == 0 {
-					verts = append(verts,
-						shaders.DefaultShader_Vertex{mgl32.Vec3{fx, fy, fz}, mgl32.Vec2{0.0, 0.0}},
-						shaders.DefaultShader_Vertex{mgl32.Vec3{fx, fy, fz - 1}, mgl32.Vec2{0.0, 1.0}},
-						shaders.DefaultShader_Vertex{mgl32.Vec3{fx, fy - 1, fz}, mgl32.Vec2{1.0, 0.0}},
-						shaders.DefaultShader_Vertex{mgl32.Vec3{fx, fy - 1, fz}, mgl32.Vec2{1.0, 0.0}},
-						shaders.DefaultShader_Vertex{mgl32.Vec3{fx, fy, fz - 1}, mgl32.Vec2{0.0, 1.0}},
-						shaders.DefaultShader_Vertex{mgl32.Vec3{fx, fy - 1, fz - 1}, mgl32.Vec2{1.0, 1.0}})
-				}
-
-				if c.data[index] == 0 && c.data[index1y] != 0 || c.data[index] != 0 && c.data[index1y] == 0 {
-					verts = append(verts,
-						shaders.DefaultShader_Vertex{mgl32.Vec3{fx, fy, fz}, mgl32.Vec2{0.0, 0.0}},
-						shaders.DefaultShader_Vertex{mgl32.Vec3{fx, fy, fz - 1}, mgl32.Vec2{0.0, 1.0}},
-						shaders.DefaultShader_Vertex{mgl32.Vec3{fx - 1, fy, fz}, mgl32.Vec2{1.0, 0.0}},
-						shaders.DefaultShader_Vertex{mgl32.Vec3{fx - 1, fy, fz}, mgl32.Vec2{1.0, 0.0}},
-						shaders.DefaultShader_Vertex{mgl32.Vec3{fx, fy, fz - 1}, mgl32.Vec2{0.0, 1.0}},
-						shaders.DefaultShader_Vertex{mgl32.Vec3{fx - 1, fy, fz - 1}, mgl32.Vec2{1.0, 1.0}})
-				}
-
-				if c.data[index] == 0 && c.data[index1z] != 0 || c.data[index] != 0 && c.data[index1z] == 0 {
-					verts = append(verts,
-						shaders.DefaultShader_Vertex{mgl32.Vec3{fx, fy, fz}, mgl32.Vec2{0.0, 0.0}},
-						shaders.DefaultShader_Vertex{mgl32.Vec3{fx, fy - 1, fz}, mgl32.Vec2{0.0, 1.0}},
-						shaders.DefaultShader_Vertex{mgl32.Vec3{fx - 1, fy, fz}, mgl32.Vec2{1.0, 0.0}},
-						shaders.DefaultShader_Vertex{mgl32.Vec3{fx - 1, fy, fz}, mgl32.Vec2{1.0, 0.0}},
-						shaders.DefaultShader_Vertex{mgl32.Vec3{fx, fy - 1, fz}, mgl32.Vec2{0.0, 1.0}},
-						shaders.DefaultShader_Vertex{mgl32.Vec3{fx - 1, fy - 1, fz}, mgl32.Vec2{1.0, 1.0}})
-				}
-			}
-		}
+// delete frees the cell's meshes. Must be called on the render thread.
+func (c *cell) delete() {
+	for i := range c.meshes {
+		c.meshes[i].delete()
 	}
-	if len(verts) == 0 {
-		return
-	}
-	c.verts = verts
-}
-
-type cellid struct {
-	x, y, z int32
-}
-
-func (lhs *cellid) Equal(rhs cellid) bool {
-	return lhs.x == rhs.x && lhs.y == rhs.y && lhs.z == rhs.z
 }
 
-func NewCell(s *shaders.DefaultShader, id cellid) *cell {
-	cell := &cell{id: id}
+// NewCell generates a cell's voxels. It is lit and meshed once it is inserted into the world.
+func NewCell(id voxel.CellID) *cell {
+	cell := &cell{Cell: voxel.Cell{ID: id}}
 	cell.generate()
-	cell.polygonize(s)
 	return cell
 }
 
-func isCellInWorld(cell, centroidCell cellid) bool {
-	if cell.x < centroidCell.x-worldSizem1 {
+func isCellInWorld(cell, centroidCell voxel.CellID, worldSize int32) bool {
+	if cell.X < centroidCell.X-worldSize+1 {
 		return false
 	}
-	if cell.y < centroidCell.y-worldSizem1 {
+	if cell.Y < centroidCell.Y-worldSize+1 {
 		return false
 	}
-	if cell.z < centroidCell.z-worldSizem1 {
+	if cell.Z < centroidCell.Z-worldSize+1 {
 		return false
 	}
 
-	if cell.x > centroidCell.x+worldSize {
+	if cell.X > centroidCell.X+worldSize {
 		return false
 	}
-	if cell.y > centroidCell.y+worldSize {
+	if cell.Y > centroidCell.Y+worldSize {
 		return false
 	}
-	if cell.z > centroidCell.z+worldSize {
+	if cell.Z > centroidCell.Z+worldSize {
 		return false
 	}
 	return true
 }
 
-func (t *terrain) generate(x, y, z int32) {
-	lastCell := cellid{-1, -1, -1}
+// generate keeps the cell at offset from the camera's cell loaded until quit is closed.
+func (t *terrain) generate(offset voxel.CellID, quit chan struct{}) {
+	lastCell := voxel.CellID{-1, -1, -1}
 	for {
 		// No point in checking more often then every 100ms.
-		<-time.After(100 * time.Millisecond)
+		select {
+		case <-quit:
+			return
+		case <-time.After(100 * time.Millisecond):
+		}
 
 		// Positions are shifted by half a cell from cell positions since cell positions are in the lower left corner.
 		pos := camera.C.GetPosition().Sub(halfCell)
 
 		// If this is the same cell as last iteration bail.
-		thisCell := cellid{int32(pos.X())/cellsize + x, int32(pos.Y())/cellsize + y, int32(pos.Z())/cellsize + z}
-		if lastCell.Equal(thisCell) {
+		thisCell := voxel.CellID{int32(pos.X())/voxel.Size + offset.X, int32(pos.Y())/voxel.Size + offset.Y, int32(pos.Z())/voxel.Size + offset.Z}
+		if lastCell == thisCell {
 			continue
 		}
 		lastCell = thisCell
@@ -187,63 +158,271 @@ func (t *terrain) generate(x, y, z int32) {
 			continue
 		}
 
-		// This is a new cell not currently present in the world. Generate then insert.
-		c := NewCell(t.shader, thisCell)
+		// This is a new cell not currently present in the world. Generate and light it on its own, then
+		// insert it and settle its light with the neighbours, then mesh everything that changed.
+		c := NewCell(thisCell)
+		voxel.LightAlone(&c.Cell)
 		t.mu.Lock()
+		if _, ok := t.world[thisCell]; ok {
+			t.mu.Unlock()
+			continue
+		}
 		t.world[thisCell] = c
 		t.mu.Unlock()
+		t.lightBorders(c)
+		t.remesh()
 	}
 }
 
 func NewTerrain() (*terrain, error) {
-	shader, err := shaders.NewDefaultShader()
+	shader, err := assetmanager.M.TerrainShader()
 	if err != nil {
 		return nil, err
 	}
 	shader.Activate()
-	// Load the texture
-	texture, err := texture.New("assets/crate.jpg")
+	depthShader, err := assetmanager.M.TerrainDepthShader()
 	if err != nil {
 		return nil, err
 	}
-	t := &terrain{shader: shader, texture: texture, world: make(map[cellid]*cell)}
+	cutoutDepthShader, err := assetmanager.M.TerrainCutoutDepthShader()
+	if err != nil {
+		return nil, err
+	}
+	// One texture array layer per block.
+	var files []string
+	for _, f := range voxel.Textures() {
+		files = append(files, assetmanager.M.Path(f))
+	}
+	texture, err := texture.NewArray(files)
+	if err != nil {
+		return nil, err
+	}
+	t := &terrain{shader: shader, depthShader: depthShader, cutoutDepthShader: cutoutDepthShader, texture: texture, world: make(map[voxel.CellID]*cell), dirty: make(map[voxel.CellID]bool), generators: make(map[voxel.CellID]chan struct{})}
+	t.SetWorldSize(DefaultWorldSize)
+	input.M.Register(glfw.KeyF2, t.logStats)
+	input.M.Register(glfw.KeyF3, t.toggleCells)
+	return t, nil
+}
 
-	for x := int32(1 - worldSize); x <= worldSize; x++ {
-		for y := int32(1 - worldSize); y <= worldSize; y++ {
-			for z := int32(1 - worldSize); z <= worldSize; z++ {
-				go t.generate(x, y, z)
+// SetWorldSize loads cells up to worldSize out from the camera's cell on each axis, and worldSize-1 back.
+// Cells further out are unloaded by the next Render.
+func (t *terrain) SetWorldSize(worldSize int32) {
+	if worldSize < 1 {
+		worldSize = 1
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.worldSize = worldSize
+	for offset, quit := range t.generators {
+		if !isCellInWorld(offset, voxel.CellID{}, worldSize) {
+			close(quit)
+			delete(t.generators, offset)
+		}
+	}
+	for x := 1 - worldSize; x <= worldSize; x++ {
+		for y := 1 - worldSize; y <= worldSize; y++ {
+			for z := 1 - worldSize; z <= worldSize; z++ {
+				offset := voxel.CellID{x, y, z}
+				if _, ok := t.generators[offset]; !ok {
+					quit := make(chan struct{})
+					t.generators[offset] = quit
+					go t.generate(offset, quit)
+				}
 			}
 		}
 	}
-	return t, nil
+
+	// Hide cells popping in and out behind fog.
+	fog.M.AutoDistance = viewDistance(worldSize)
+}
+
+func (t *terrain) WorldSize() int32 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.worldSize
+}
+
+// Stats reports how many cells are loaded, and how many quads their meshes hold on the GPU in how many
+// bytes.
+func (t *terrain) Stats() (cells, quads, bytes int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, c := range t.world {
+		cells++
+		for _, m := range c.meshes {
+			if m.vbo != nil {
+				quads += int(m.vbo.Size) / 6
+				bytes += m.vbo.Bytes()
+			}
+		}
+	}
+	return cells, quads, bytes
 }
 
-func (t *terrain) Render() {
+// floatQuadBytes is what a quad cost as six float32 position and texture coordinate vertices, before
+// terrain vertices were packed.
+const floatQuadBytes = 6 * 20
+
+func (t *terrain) logStats(repeat bool, _ float32) {
+	if repeat {
+		return
+	}
+	cells, quads, bytes := t.Stats()
+	if quads == 0 {
+		return
+	}
+	log.Printf("Terrain: %d cells, %d quads, %d KiB of vertex and index data, %.1f bytes/quad (%.1fx smaller than float vertices)",
+		cells, quads, bytes/1024, float64(bytes)/float64(quads), float64(floatQuadBytes)/(float64(bytes)/float64(quads)))
+}
+
+// activate sets up the terrain shader for v.
+func (t *terrain) activate(v *scene.View) {
 	t.shader.Activate()
-	t.shader.SetProjection(mgl32.Perspective(mgl32.DegToRad(camera.C.FOVDegrees), float32(window.M.Width)/float32(window.M.Height), camera.C.NearPlaneDist, camera.C.FarPlaneDist))
-	t.shader.SetView(camera.C.GetViewMatrix())
+	t.shader.SetProjection(v.Projection)
+	t.shader.SetView(v.View)
 	t.texture.Bind(gl.TEXTURE0)
-	pos := camera.C.GetPosition()
-	pos = pos.Sub(halfCell)
-	centroidCell := cellid{int32(pos.X()) / cellsize, int32(pos.Y()) / cellsize, int32(pos.Z()) / cellsize}
+}
+
+// Render unloads cells that left the world and draws the opaque and cutout faces of the rest. Cells are
+// positioned in world space, so the terrain's node transform is ignored.
+func (t *terrain) Render(v *scene.View, _ mgl32.Mat4) {
+	t.activate(v)
+	pos := v.Position.Sub(halfCell)
+	centroidCell := voxel.CellID{int32(pos.X()) / voxel.Size, int32(pos.Y()) / voxel.Size, int32(pos.Z()) / voxel.Size}
 	t.mu.Lock()
 	defer t.mu.Unlock()
-	if size := len(t.world); size > worldTotal {
-		fmt.Printf("WARNING: Scene has %d cells should be %v\n", len(t.world), worldTotal)
+	for _, c := range t.world {
+		if !isCellInWorld(c.ID, centroidCell, t.worldSize) {
+			c.delete()
+			delete(t.world, c.ID)
+		}
+	}
+	if size, total := len(t.world), 8*t.worldSize*t.worldSize*t.worldSize; size > int(total) {
+		fmt.Printf("WARNING: Scene has %d cells should be %v\n", size, total)
+	}
+	for _, mode := range []voxel.RenderMode{voxel.RenderOpaque, voxel.RenderCutout} {
+		if mode == voxel.RenderCutout {
+			t.shader.SetAlphaCutoff(cutoutAlpha)
+		}
+		for id, c := range t.world {
+			t.shader.SetModel(mgl32.Translate3D(id.Origin().Elem()))
+			c.meshes[mode].draw()
+		}
+	}
+	t.shader.SetAlphaCutoff(0)
+	if t.ShowCells {
+		t.outlineCells()
 	}
+}
+
+func (t *terrain) toggleCells(repeat bool, _ float32) {
+	if !repeat {
+		t.ShowCells = !t.ShowCells
+	}
+}
+
+// Cell outline colors.
+var (
+	cellDirtyColor   = mgl32.Vec4{1, 0.2, 0.2, 1}
+	cellUploadColor  = mgl32.Vec4{1, 0.9, 0.2, 1}
+	cellEmptyColor   = mgl32.Vec4{0.5, 0.5, 0.5, 0.4}
+	cellMeshedColor  = mgl32.Vec4{0.2, 1, 0.3, 1}
+	cellOutlineInset = mgl32.Vec3{0.05, 0.05, 0.05}
+)
+
+// outlineCells draws the bounds of every loaded cell: red while waiting to be remeshed, yellow while its
+// new mesh waits to be uploaded, grey with nothing to draw and green once meshed. Must be called with t.mu
+// held.
+func (t *terrain) outlineCells() {
 	for id, c := range t.world {
-		if !isCellInWorld(c.id, centroidCell) {
-			delete(t.world, c.id)
-			continue
+		color := cellEmptyColor
+		empty := true
+		for _, m := range c.meshes {
+			if !m.uploaded {
+				color = cellUploadColor
+			}
+			if len(m.verts) > 0 {
+				empty = false
+			}
 		}
-		if c.verts == nil {
-			continue
+		switch {
+		case t.dirty[id]:
+			color = cellDirtyColor
+		case color == cellUploadColor:
+		case !empty:
+			color = cellMeshedColor
+		}
+		// Inset slightly so neighbouring outlines don't overlap.
+		min := id.Origin().Add(cellOutlineInset)
+		max := id.Origin().Add(mgl32.Vec3{voxel.Size, voxel.Size, voxel.Size}).Sub(cellOutlineInset)
+		debugdraw.DrawAABB(min, max, color, debugdraw.Options{DepthTest: true})
+	}
+}
+
+// RenderTranslucent blends translucent faces over everything drawn so far, farthest cells first and each
+// cell's faces sorted back to front.
+func (t *terrain) RenderTranslucent(v *scene.View, _ mgl32.Mat4) {
+	t.activate(v)
+	eye := v.Position
+	eyeVoxel := voxel.Pos{int32(math.Floor(float64(eye[0]))), int32(math.Floor(float64(eye[1]))), int32(math.Floor(float64(eye[2])))}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var cells []*cell
+	for _, c := range t.world {
+		// Cells whose translucent faces all went away still need their old buffer freed.
+		if m := &c.meshes[voxel.RenderTranslucent]; len(m.verts) > 0 || m.vbo != nil {
+			cells = append(cells, c)
+		}
+	}
+	distance := func(c *cell) float32 {
+		d := c.ID.Origin().Add(halfCell).Sub(eye)
+		return d.Dot(d)
+	}
+	sort.Slice(cells, func(i, j int) bool {
+		return distance(cells[i]) > distance(cells[j])
+	})
+
+	gl.Enable(gl.BLEND)
+	// Textures are decoded into image.RGBA, so their alpha is already premultiplied.
+	gl.BlendFunc(gl.ONE, gl.ONE_MINUS_SRC_ALPHA)
+	gl.DepthMask(false)
+	for _, c := range cells {
+		m := &c.meshes[voxel.RenderTranslucent]
+		// Only re-sort when the camera crosses into another voxel.
+		if !m.sorted || m.sortedFrom != eyeVoxel {
+			m.sortBackToFront(eye.Sub(c.ID.Origin()))
+			m.sorted, m.sortedFrom = true, eyeVoxel
+		}
+		t.shader.SetModel(mgl32.Translate3D(c.ID.Origin().Elem()))
+		m.draw()
+	}
+	gl.DepthMask(true)
+	gl.Disable(gl.BLEND)
+}
+
+// RenderShadow draws the opaque and cutout faces of every cell into the current shadow map cascade.
+// Cutout faces are alpha tested so light shines through their gaps. Translucent faces let light through
+// and cast no shadow.
+func (t *terrain) RenderShadow(lightSpace, _ mgl32.Mat4) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, mode := range []voxel.RenderMode{voxel.RenderOpaque, voxel.RenderCutout} {
+		s := t.depthShader
+		if mode == voxel.RenderCutout {
+			s = t.cutoutDepthShader
+		}
+		s.Activate()
+		s.SetLightSpace(lightSpace)
+		if mode == voxel.RenderCutout {
+			s.SetAlphaCutoff(cutoutAlpha)
+			t.texture.Bind(gl.TEXTURE0)
 		}
-		if c.vbo == nil {
-			c.vbo = shaders.NewDefaultShader_VertexBuffer(t.shader, c.verts)
+		for id, c := range t.world {
+			if vbo := c.meshes[mode].vbo; vbo != nil {
+				s.SetModel(mgl32.Translate3D(id.Origin().Elem()))
+				vbo.Draw(gl.TRIANGLES)
+			}
 		}
-		c.vbo.Activate()
-		t.shader.SetModel(mgl32.Translate3D(float32(id.x*cellsize), float32(id.y*cellsize), float32(id.z*cellsize)))
-		gl.DrawArrays(gl.TRIANGLES, 0, c.vbo.Size)
 	}
 }
@@ -0,0 +1,160 @@
+package voxelterrain
+
+import (
+	"github.com/brandonnelson3/GoPlay/voxelterrain/voxel"
+)
+
+// cells makes the loaded cells a voxel.Cells. It must only be used with t.mu held.
+type cells terrain
+
+func (w *cells) Cell(id voxel.CellID) *voxel.Cell {
+	c, ok := w.world[id]
+	if !ok {
+		return nil
+	}
+	return &c.Cell
+}
+
+// Changed queues a remesh of the cell.
+func (w *cells) Changed(id voxel.CellID) {
+	if c, ok := w.world[id]; ok {
+		c.edits++
+	}
+	w.dirty[id] = true
+}
+
+// borderCells lets a new cell's border light be settled without holding t.mu. Cells are copied from the
+// terrain the first time they're asked for, and only the copies are lit.
+type borderCells struct {
+	t      *terrain
+	copies map[voxel.CellID]*borderCopy
+	// Cells told about a light change, see voxel.Cells.
+	changed map[voxel.CellID]bool
+}
+
+// borderCopy is a cell as it was when copied. from is nil if it wasn't loaded.
+type borderCopy struct {
+	from  *cell
+	edits int
+	cell  voxel.Cell
+}
+
+func newBorderCells(t *terrain) *borderCells {
+	return &borderCells{t: t, copies: make(map[voxel.CellID]*borderCopy), changed: make(map[voxel.CellID]bool)}
+}
+
+func (w *borderCells) Cell(id voxel.CellID) *voxel.Cell {
+	b, ok := w.copies[id]
+	if !ok {
+		b = &borderCopy{}
+		w.t.mu.Lock()
+		if c, ok := w.t.world[id]; ok {
+			b.from, b.edits, b.cell = c, c.edits, c.Cell
+		}
+		w.t.mu.Unlock()
+		w.copies[id] = b
+	}
+	if b.from == nil {
+		return nil
+	}
+	return &b.cell
+}
+
+func (w *borderCells) Changed(id voxel.CellID) {
+	w.changed[id] = true
+}
+
+// commit writes the changed light back and queues the remeshes, unless any cell copied has changed, been
+// loaded or been unloaded since, in which case nothing is written and it reports false.
+func (w *borderCells) commit() bool {
+	t := w.t
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for id, b := range w.copies {
+		c := t.world[id]
+		if c != b.from || c != nil && c.edits != b.edits {
+			return false
+		}
+	}
+	for id := range w.changed {
+		if b, ok := w.copies[id]; ok && b.from != nil {
+			b.from.Light = b.cell.Light
+		}
+		(*cells)(t).Changed(id)
+	}
+	return true
+}
+
+// lightBorders settles the light of c, lit by LightAlone and already in t.world, with its loaded
+// neighbours. Must be called without t.mu held: the light is worked out on copies of the cells it reaches
+// and swapped in under the lock, or worked out again if one of them changed meanwhile.
+func (t *terrain) lightBorders(c *cell) {
+	for {
+		w := newBorderCells(t)
+		own := w.Cell(c.ID)
+		if own == nil || w.copies[c.ID].from != c {
+			// Unloaded before its light settled.
+			return
+		}
+		voxel.LightBorders(w, own)
+		if w.commit() {
+			return
+		}
+	}
+}
+
+// SetBlock places or removes a voxel at world voxel coordinates, relighting and remeshing whatever it
+// affects. Voxels outside the loaded world are ignored.
+func (t *terrain) SetBlock(x, y, z int32, id byte) {
+	t.mu.Lock()
+	changed := voxel.SetBlock((*cells)(t), voxel.Pos{x, y, z}, id)
+	t.mu.Unlock()
+	if changed {
+		t.remesh()
+	}
+}
+
+// remesh rebuilds the mesh of every dirty cell. Must be called without t.mu held: the cells are copied
+// under the lock, meshed without it, and each finished mesh swapped in unless its cell changed meanwhile,
+// in which case whoever changed it remeshes it again.
+func (t *terrain) remesh() {
+	type job struct {
+		c        *cell
+		edits    int
+		snapshot *voxel.Snapshot
+	}
+	var jobs []job
+	t.mu.Lock()
+	for id := range t.dirty {
+		delete(t.dirty, id)
+		c, ok := t.world[id]
+		if !ok {
+			continue
+		}
+		jobs = append(jobs, job{c, c.edits, voxel.Snap((*cells)(t), &c.Cell)})
+	}
+	t.mu.Unlock()
+
+	for _, j := range jobs {
+		meshes := j.snapshot.Polygonize()
+		t.mu.Lock()
+		if t.world[j.c.ID] == j.c && j.c.edits == j.edits {
+			for i := range j.c.meshes {
+				m := &j.c.meshes[i]
+				m.verts, m.indices, m.centers = meshes[i].Verts, meshes[i].Indices, meshes[i].Centers
+				m.uploaded = false
+				m.sorted = false
+			}
+		}
+		t.mu.Unlock()
+	}
+}
+
+// Solid reports whether the voxel at world voxel coordinates blocks movement, and whether its cell is
+// loaded at all. It makes the terrain a physics.Voxels.
+func (t *terrain) Solid(x, y, z int32) (solid, loaded bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	id, ok := voxel.BlockAt((*cells)(t), voxel.Pos{x, y, z})
+	return id != voxel.Air, ok
+}
@@ -0,0 +1,93 @@
+package voxelterrain
+
+import (
+	"testing"
+
+	"github.com/brandonnelson3/GoPlay/voxelterrain/voxel"
+)
+
+var testCells = []voxel.CellID{{0, 0, 0}, {1, 0, 0}, {0, 1, 0}, {0, -1, 0}, {1, 1, 0}}
+
+func newTestTerrain() *terrain {
+	return &terrain{world: make(map[voxel.CellID]*cell), dirty: make(map[voxel.CellID]bool)}
+}
+
+// block is open air under a roof at y = 40, with a lamp next to the border between the two columns of
+// cells.
+func block(x, y, z int32) byte {
+	switch {
+	case y == 40:
+		return voxel.Crate
+	case x == 31 && y == 5 && z == 5:
+		return voxel.Lamp
+	}
+	return voxel.Air
+}
+
+// load inserts a new cell the way generate does, lit alone.
+func (t *terrain) load(id voxel.CellID) *cell {
+	c := &cell{Cell: voxel.Cell{ID: id}}
+	for x := int32(-1); x <= voxel.Size; x++ {
+		for y := int32(-1); y <= voxel.Size; y++ {
+			for z := int32(-1); z <= voxel.Size; z++ {
+				c.Data[voxel.Idx(x, y, z)] = block(id.X*voxel.Size+x, id.Y*voxel.Size+y, id.Z*voxel.Size+z)
+			}
+		}
+	}
+	voxel.LightAlone(&c.Cell)
+	t.world[id] = c
+	return c
+}
+
+func checkSameLight(t *testing.T, got, want *terrain) {
+	for _, id := range testCells {
+		if got.world[id].Light != want.world[id].Light {
+			t.Errorf("cell %v lit differently from settling under the lock", id)
+		}
+	}
+}
+
+func TestLightBordersOnCopies(t *testing.T) {
+	want, got := newTestTerrain(), newTestTerrain()
+	for _, id := range testCells {
+		voxel.LightBorders((*cells)(want), &want.load(id).Cell)
+		got.lightBorders(got.load(id))
+		if !got.dirty[id] {
+			t.Errorf("cell %v not queued for meshing after its light settled", id)
+		}
+	}
+	checkSameLight(t, got, want)
+}
+
+func TestLightBordersRedoneAfterEdit(t *testing.T) {
+	lamp := voxel.Pos{40, 31, 5}
+	first, last := testCells[:len(testCells)-1], testCells[len(testCells)-1]
+	want, got := newTestTerrain(), newTestTerrain()
+	for _, id := range first {
+		voxel.LightBorders((*cells)(want), &want.load(id).Cell)
+		got.lightBorders(got.load(id))
+	}
+	// A lamp is placed below the new cell while its border light is being worked out.
+	c := want.load(last)
+	want.SetBlock(lamp[0], lamp[1], lamp[2], voxel.Lamp)
+	voxel.LightBorders((*cells)(want), &c.Cell)
+
+	c = got.load(last)
+	w := newBorderCells(got)
+	voxel.LightBorders(w, w.Cell(last))
+	got.SetBlock(lamp[0], lamp[1], lamp[2], voxel.Lamp)
+	edited := got.world[voxel.CellID{1, 0, 0}].Light
+	if w.commit() {
+		t.Fatalf("light worked out before the edit was committed after it")
+	}
+	if got.world[voxel.CellID{1, 0, 0}].Light != edited {
+		t.Errorf("failed commit wrote light back")
+	}
+	got.lightBorders(c)
+	checkSameLight(t, got, want)
+
+	// Cells unloaded before their light settles are left alone.
+	gone := got.load(voxel.CellID{0, 2, 0})
+	delete(got.world, gone.ID)
+	got.lightBorders(gone)
+}
@@ -0,0 +1,62 @@
+package voxel
+
+// Block ids, as stored in Cell.Data.
+const (
+	Air byte = iota
+	Crate
+	Lamp
+	Glass
+	Leaves
+)
+
+// RenderMode decides which of a cell's meshes a block's faces go into, and how that mesh is drawn.
+type RenderMode int
+
+const (
+	// RenderOpaque blocks hide everything behind them and stop light.
+	RenderOpaque RenderMode = iota
+	// RenderCutout blocks are alpha tested, each texel either fully opaque or fully clear.
+	RenderCutout
+	// RenderTranslucent blocks are alpha blended, drawn back to front after everything else.
+	RenderTranslucent
+	RenderModes
+)
+
+// Block describes one voxel material. Its id is its index in Blocks, and also the layer of the terrain
+// texture array its faces are drawn with. Terrain vertices have room for ids up to 15.
+type Block struct {
+	Name    string
+	Texture string
+	// Emission is the block light level the block gives off, 0 for blocks that don't glow.
+	Emission byte
+	Mode     RenderMode
+}
+
+var Blocks = []Block{
+	Air:    {Name: "air"},
+	Crate:  {Name: "crate", Texture: "crate.jpg"},
+	Lamp:   {Name: "lamp", Texture: "crate.jpg", Emission: 14},
+	Glass:  {Name: "glass", Texture: "glass.png", Mode: RenderTranslucent},
+	Leaves: {Name: "leaves", Texture: "leaves.png", Mode: RenderCutout},
+}
+
+// IsOpaque reports whether a block hides whatever is behind it, stopping light and casting ambient
+// occlusion.
+func IsOpaque(id byte) bool {
+	return id != Air && Blocks[id].Mode == RenderOpaque
+}
+
+// faceVisible reports whether block a has a face towards its neighbour b. Faces between two of the same
+// see-through block are culled so a body of glass or leaves reads as one volume.
+func faceVisible(a, b byte) bool {
+	return a != Air && !IsOpaque(b) && a != b
+}
+
+// Textures returns the texture of every block but air, in id order.
+func Textures() []string {
+	var textures []string
+	for _, b := range Blocks[1:] {
+		textures = append(textures, b.Texture)
+	}
+	return textures
+}
@@ -0,0 +1,157 @@
+// Package voxel holds the terrain's voxel data and everything computed from it without a GL context:
+// block properties, light propagation and meshing.
+package voxel
+
+import (
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+const (
+	// Size is the number of voxels along each side of a cell.
+	Size = 32
+	// Cell data is padded with one voxel of each neighbouring cell on every side, so it covers -1..Size on
+	// each axis.
+	sizep2   = Size + 2
+	sizep2_2 = sizep2 * sizep2
+	sizep2_3 = sizep2 * sizep2 * sizep2
+)
+
+// CellID is a cell's position in cells.
+type CellID struct {
+	X, Y, Z int32
+}
+
+// Origin returns the world position of the cell's lower corner.
+func (id CellID) Origin() mgl32.Vec3 {
+	return mgl32.Vec3{float32(id.X * Size), float32(id.Y * Size), float32(id.Z * Size)}
+}
+
+// Cell is the voxels and light of one cube of the world.
+type Cell struct {
+	ID CellID
+	// Data holds the block id of every voxel, padded with copies of the neighbouring cells' voxels. Index
+	// it with Idx.
+	Data [sizep2_3]byte
+	// Light holds the sky and block light of each voxel the cell owns, see LightChannel. Unlike Data it
+	// isn't padded. Index it with Lidx.
+	Light [Size * Size * Size]byte
+}
+
+// Idx returns the index into Cell.Data of cell local voxel x, y, z, each in -1..Size.
+func Idx(x, y, z int32) int32 {
+	return ((x+1)*sizep2_2 + (y+1)*sizep2 + z + 1)
+}
+
+// Lidx returns the index into Cell.Light of cell local voxel x, y, z, each in 0..Size-1.
+func Lidx(x, y, z int32) int32 {
+	return (x*Size+y)*Size + z
+}
+
+// Pos is a voxel position in world coordinates.
+type Pos [3]int32
+
+func (p Pos) Add(d Pos) Pos {
+	return Pos{p[0] + d[0], p[1] + d[1], p[2] + d[2]}
+}
+
+// origin returns the world position of the cell's lower corner voxel.
+func (id CellID) origin() Pos {
+	return Pos{id.X * Size, id.Y * Size, id.Z * Size}
+}
+
+func floorDiv(a, b int32) int32 {
+	q := a / b
+	if a%b != 0 && a < 0 {
+		q--
+	}
+	return q
+}
+
+// Locate splits a world voxel position into its cell and the cell local position.
+func Locate(p Pos) (CellID, [3]int32) {
+	id := CellID{floorDiv(p[0], Size), floorDiv(p[1], Size), floorDiv(p[2], Size)}
+	return id, [3]int32{p[0] - id.X*Size, p[1] - id.Y*Size, p[2] - id.Z*Size}
+}
+
+// Cells is a set of loaded cells. Voxels in cells it doesn't have are treated as opaque and unlit.
+type Cells interface {
+	// Cell returns the cell id, or nil if it isn't loaded.
+	Cell(id CellID) *Cell
+	// Changed is told about every cell whose mesh can see a voxel whose block or light changed.
+	Changed(id CellID)
+}
+
+// BlockAt returns the block at p, and whether its cell is loaded.
+func BlockAt(w Cells, p Pos) (byte, bool) {
+	id, l := Locate(p)
+	c := w.Cell(id)
+	if c == nil {
+		return Air, false
+	}
+	return c.Data[Idx(l[0], l[1], l[2])], true
+}
+
+// LightAt returns the light of the voxel at p, packed like Cell.Light.
+func LightAt(w Cells, p Pos) byte {
+	id, l := Locate(p)
+	c := w.Cell(id)
+	if c == nil {
+		return 0
+	}
+	return c.Light[Lidx(l[0], l[1], l[2])]
+}
+
+func setLightAt(w Cells, p Pos, v byte) {
+	id, l := Locate(p)
+	c := w.Cell(id)
+	if c == nil {
+		return
+	}
+	c.Light[Lidx(l[0], l[1], l[2])] = v
+	changed(w, p)
+}
+
+// changed tells w about every cell whose mesh can see voxel p: its own cell, and the neighbours whose
+// padding covers it.
+func changed(w Cells, p Pos) {
+	id, l := Locate(p)
+	var ranges [3][]int32
+	for axis := 0; axis < 3; axis++ {
+		ranges[axis] = []int32{0}
+		if l[axis] == 0 {
+			ranges[axis] = append(ranges[axis], -1)
+		}
+		if l[axis] == Size-1 {
+			ranges[axis] = append(ranges[axis], 1)
+		}
+	}
+	for _, dx := range ranges[0] {
+		for _, dy := range ranges[1] {
+			for _, dz := range ranges[2] {
+				w.Changed(CellID{id.X + dx, id.Y + dy, id.Z + dz})
+			}
+		}
+	}
+}
+
+// setBlockAt changes a voxel in its cell and in every neighbour's padding copy of it.
+func setBlockAt(w Cells, p Pos, v byte) {
+	id, _ := Locate(p)
+	for dx := int32(-1); dx <= 1; dx++ {
+		for dy := int32(-1); dy <= 1; dy++ {
+			for dz := int32(-1); dz <= 1; dz++ {
+				n := CellID{id.X + dx, id.Y + dy, id.Z + dz}
+				c := w.Cell(n)
+				if c == nil {
+					continue
+				}
+				x, y, z := p[0]-n.X*Size, p[1]-n.Y*Size, p[2]-n.Z*Size
+				if x < -1 || x > Size || y < -1 || y > Size || z < -1 || z > Size {
+					continue
+				}
+				c.Data[Idx(x, y, z)] = v
+			}
+		}
+	}
+	changed(w, p)
+}
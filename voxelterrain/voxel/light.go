@@ -0,0 +1,259 @@
+package voxel
+
+// Light levels run from 0 (dark) to MaxLight. Each voxel stores its sky light in the high nibble of a byte
+// and its block light in the low nibble.
+const MaxLight = 15
+
+// LightChannel is the bit offset of a light nibble.
+type LightChannel uint8
+
+const (
+	BlockChannel LightChannel = 0
+	SkyChannel   LightChannel = 4
+)
+
+func GetLight(v byte, ch LightChannel) byte {
+	return v >> ch & 0xF
+}
+
+func SetLight(v byte, ch LightChannel, level byte) byte {
+	return v&^(0xF<<ch) | level<<ch
+}
+
+var neighbours = [6]Pos{{1, 0, 0}, {-1, 0, 0}, {0, 1, 0}, {0, -1, 0}, {0, 0, 1}, {0, 0, -1}}
+
+// down is the index of {0, -1, 0} in neighbours.
+const down = 3
+
+// spreadsTo returns the level light of the given level reaches a neighbour in direction dir with. Full sky
+// light shines straight down without fading, like sunlight through open air.
+func spreadsTo(ch LightChannel, level byte, dir int) byte {
+	if ch == SkyChannel && dir == down && level == MaxLight {
+		return MaxLight
+	}
+	if level == 0 {
+		return 0
+	}
+	return level - 1
+}
+
+// propagateLight flood fills light outwards from every voxel in queue, raising neighbours that are darker
+// than the light reaching them.
+func propagateLight(w Cells, ch LightChannel, queue []Pos) {
+	for len(queue) > 0 {
+		p := queue[0]
+		queue = queue[1:]
+		level := GetLight(LightAt(w, p), ch)
+		for dir, d := range neighbours {
+			next := spreadsTo(ch, level, dir)
+			if next == 0 {
+				continue
+			}
+			n := p.Add(d)
+			if id, ok := BlockAt(w, n); !ok || IsOpaque(id) {
+				continue
+			}
+			v := LightAt(w, n)
+			if GetLight(v, ch) >= next {
+				continue
+			}
+			setLightAt(w, n, SetLight(v, ch, next))
+			queue = append(queue, n)
+		}
+	}
+}
+
+// lightRemoval is a voxel that has just been darkened, and the level it had before.
+type lightRemoval struct {
+	pos   Pos
+	level byte
+}
+
+// removeLight darkens every voxel that was lit through the voxels in queue, which must already be set to
+// 0. Voxels lit from elsewhere, and emissive blocks, then flood their light back into the darkened area.
+func removeLight(w Cells, ch LightChannel, queue []lightRemoval) {
+	var relight []Pos
+	for len(queue) > 0 {
+		r := queue[0]
+		queue = queue[1:]
+		for dir, d := range neighbours {
+			n := r.pos.Add(d)
+			id, ok := BlockAt(w, n)
+			if !ok {
+				continue
+			}
+			v := LightAt(w, n)
+			level := GetLight(v, ch)
+			if level == 0 {
+				continue
+			}
+			if ch == BlockChannel && Blocks[id].Emission > 0 {
+				// Sources keep their own light.
+				relight = append(relight, n)
+				continue
+			}
+			if level <= spreadsTo(ch, r.level, dir) {
+				// Dim enough that it may have been lit through r.
+				setLightAt(w, n, SetLight(v, ch, 0))
+				queue = append(queue, lightRemoval{n, level})
+			} else {
+				relight = append(relight, n)
+			}
+		}
+	}
+	propagateLight(w, ch, relight)
+}
+
+// LightCell lights c, which w must already return: sky light pours down open columns, emissive blocks are
+// lit, and light flows both ways across the faces shared with the loaded neighbours. It is LightAlone
+// followed by LightBorders.
+func LightCell(w Cells, c *Cell) {
+	LightAlone(c)
+	LightBorders(w, c)
+}
+
+// alone is a Cells holding just one cell.
+type alone struct {
+	c *Cell
+}
+
+func (a alone) Cell(id CellID) *Cell {
+	if id == a.c.ID {
+		return a.c
+	}
+	return nil
+}
+
+func (alone) Changed(CellID) {}
+
+// LightAlone lights an unlit cell as if it were the only one loaded, under an open sky. It only touches c,
+// so it can run before c is added to the world.
+func LightAlone(c *Cell) {
+	w := alone{c}
+	origin := c.ID.origin()
+	var sky, block []Pos
+	for x := int32(0); x < Size; x++ {
+		for z := int32(0); z < Size; z++ {
+			for y := int32(Size - 1); y >= 0; y-- {
+				if IsOpaque(c.Data[Idx(x, y, z)]) {
+					break
+				}
+				p := origin.Add(Pos{x, y, z})
+				setLightAt(w, p, SetLight(LightAt(w, p), SkyChannel, MaxLight))
+				sky = append(sky, p)
+			}
+			for y := int32(0); y < Size; y++ {
+				if e := Blocks[c.Data[Idx(x, y, z)]].Emission; e > 0 {
+					p := origin.Add(Pos{x, y, z})
+					setLightAt(w, p, SetLight(LightAt(w, p), BlockChannel, e))
+					block = append(block, p)
+				}
+			}
+		}
+	}
+	propagateLight(w, SkyChannel, sky)
+	propagateLight(w, BlockChannel, block)
+}
+
+// LightBorders settles the light of a cell lit by LightAlone with its loaded neighbours, once w returns it.
+// Sky light is taken back from columns the cell above shades, and from columns below that assumed this cell
+// was open, and light flows across every shared face in both directions.
+func LightBorders(w Cells, c *Cell) {
+	origin := c.ID.origin()
+	var skyRemovals []lightRemoval
+	for x := int32(0); x < Size; x++ {
+		for z := int32(0); z < Size; z++ {
+			top, above := origin.Add(Pos{x, Size - 1, z}), origin.Add(Pos{x, Size, z})
+			if id, ok := BlockAt(w, above); ok && (IsOpaque(id) || GetLight(LightAt(w, above), SkyChannel) < MaxLight) {
+				if v := LightAt(w, top); GetLight(v, SkyChannel) == MaxLight {
+					setLightAt(w, top, SetLight(v, SkyChannel, 0))
+					skyRemovals = append(skyRemovals, lightRemoval{top, MaxLight})
+				}
+			}
+		}
+	}
+	removeLight(w, SkyChannel, skyRemovals)
+
+	skyRemovals = skyRemovals[:0]
+	for x := int32(0); x < Size; x++ {
+		for z := int32(0); z < Size; z++ {
+			bottom, below := origin.Add(Pos{x, 0, z}), origin.Add(Pos{x, -1, z})
+			if IsOpaque(c.Data[Idx(x, 0, z)]) || GetLight(LightAt(w, bottom), SkyChannel) < MaxLight {
+				if v := LightAt(w, below); GetLight(v, SkyChannel) == MaxLight {
+					setLightAt(w, below, SetLight(v, SkyChannel, 0))
+					skyRemovals = append(skyRemovals, lightRemoval{below, MaxLight})
+				}
+			}
+		}
+	}
+	removeLight(w, SkyChannel, skyRemovals)
+
+	// Every lit voxel on either side of a shared face spreads across it.
+	var seeds []Pos
+	for axis := 0; axis < 3; axis++ {
+		s, u := (axis+1)%3, (axis+2)%3
+		for _, layer := range []int32{-1, 0, Size - 1, Size} {
+			for i := int32(0); i < Size; i++ {
+				for j := int32(0); j < Size; j++ {
+					var p Pos
+					p[axis] = layer
+					p[s] = i
+					p[u] = j
+					p = origin.Add(p)
+					if LightAt(w, p) != 0 {
+						seeds = append(seeds, p)
+					}
+				}
+			}
+		}
+	}
+	propagateLight(w, SkyChannel, seeds)
+	propagateLight(w, BlockChannel, seeds)
+
+	// The neighbours' meshes see the light LightAlone gave the cell's outer voxels.
+	for dx := int32(-1); dx <= 1; dx++ {
+		for dy := int32(-1); dy <= 1; dy++ {
+			for dz := int32(-1); dz <= 1; dz++ {
+				w.Changed(CellID{c.ID.X + dx, c.ID.Y + dy, c.ID.Z + dz})
+			}
+		}
+	}
+}
+
+// SetBlock places or removes the voxel at p, relighting whatever it affects. It reports false, changing
+// nothing, when p isn't loaded or already holds id.
+func SetBlock(w Cells, p Pos, id byte) bool {
+	old, ok := BlockAt(w, p)
+	if !ok || old == id {
+		return false
+	}
+	setBlockAt(w, p, id)
+
+	v := LightAt(w, p)
+	for _, ch := range []LightChannel{SkyChannel, BlockChannel} {
+		if IsOpaque(id) || ch == BlockChannel && Blocks[old].Emission > 0 {
+			// Whatever shone through or from this voxel is gone.
+			if level := GetLight(v, ch); level > 0 {
+				v = SetLight(v, ch, 0)
+				setLightAt(w, p, v)
+				removeLight(w, ch, []lightRemoval{{p, level}})
+				v = LightAt(w, p)
+			}
+		}
+		if !IsOpaque(id) {
+			// Neighbouring light flows into the opening.
+			var seeds []Pos
+			for _, d := range neighbours {
+				if n := p.Add(d); GetLight(LightAt(w, n), ch) > 0 {
+					seeds = append(seeds, n)
+				}
+			}
+			propagateLight(w, ch, seeds)
+		}
+	}
+	if e := Blocks[id].Emission; e > 0 {
+		setLightAt(w, p, SetLight(LightAt(w, p), BlockChannel, e))
+		propagateLight(w, BlockChannel, []Pos{p})
+	}
+	return true
+}
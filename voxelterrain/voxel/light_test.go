@@ -0,0 +1,183 @@
+package voxel
+
+import (
+	"testing"
+)
+
+// testWorld is a Cells over a map, recording which cells were told they changed.
+type testWorld struct {
+	cells   map[CellID]*Cell
+	changed map[CellID]bool
+}
+
+func newTestWorld() *testWorld {
+	return &testWorld{cells: map[CellID]*Cell{}, changed: map[CellID]bool{}}
+}
+
+func (w *testWorld) Cell(id CellID) *Cell {
+	return w.cells[id]
+}
+
+func (w *testWorld) Changed(id CellID) {
+	w.changed[id] = true
+}
+
+// add fills cell id, padding included, from block and lights it.
+func (w *testWorld) add(id CellID, block func(p Pos) byte) *Cell {
+	c := &Cell{ID: id}
+	origin := id.origin()
+	for x := int32(-1); x <= Size; x++ {
+		for y := int32(-1); y <= Size; y++ {
+			for z := int32(-1); z <= Size; z++ {
+				c.Data[Idx(x, y, z)] = block(origin.Add(Pos{x, y, z}))
+			}
+		}
+	}
+	w.cells[id] = c
+	LightCell(w, c)
+	return c
+}
+
+func (w *testWorld) light(p Pos, ch LightChannel) byte {
+	return GetLight(LightAt(w, p), ch)
+}
+
+// tunnel is solid crate except for an open tunnel along the x axis at y = z = 5, holding the given lamps.
+func tunnel(lamps ...int32) func(p Pos) byte {
+	return func(p Pos) byte {
+		if p[1] != 5 || p[2] != 5 {
+			return Crate
+		}
+		for _, x := range lamps {
+			if p[0] == x {
+				return Lamp
+			}
+		}
+		return Air
+	}
+}
+
+func TestSkyLightDownColumn(t *testing.T) {
+	w := newTestWorld()
+	// Open air but for one crate at 5, 10, 5.
+	w.add(CellID{}, func(p Pos) byte {
+		if p == (Pos{5, 10, 5}) {
+			return Crate
+		}
+		return Air
+	})
+
+	for y := int32(11); y < Size; y++ {
+		if got := w.light(Pos{5, y, 5}, SkyChannel); got != MaxLight {
+			t.Errorf("sky light above the crate at y = %d is %d, want %d", y, got, MaxLight)
+		}
+	}
+	for y := int32(0); y < Size; y++ {
+		if got := w.light(Pos{6, y, 5}, SkyChannel); got != MaxLight {
+			t.Errorf("sky light in the open column at y = %d is %d, want %d", y, got, MaxLight)
+		}
+	}
+	// Under the crate sunlight only arrives sideways, one level dimmer.
+	for y := int32(0); y < 10; y++ {
+		if got := w.light(Pos{5, y, 5}, SkyChannel); got != MaxLight-1 {
+			t.Errorf("sky light under the crate at y = %d is %d, want %d", y, got, MaxLight-1)
+		}
+	}
+}
+
+func TestSkyLightBlockedByCellAbove(t *testing.T) {
+	w := newTestWorld()
+	// A cave: a solid roof layer from y = 32 up, open air below it.
+	world := func(p Pos) byte {
+		if p[1] >= Size {
+			return Crate
+		}
+		return Air
+	}
+	w.add(CellID{}, world)
+	if got := w.light(Pos{5, 5, 5}, SkyChannel); got != MaxLight {
+		t.Fatalf("sky light before the roof loaded is %d, want %d", got, MaxLight)
+	}
+	w.add(CellID{0, 1, 0}, world)
+	if got := w.light(Pos{5, 5, 5}, SkyChannel); got != 0 {
+		t.Errorf("sky light under the roof is %d, want 0", got)
+	}
+}
+
+func TestLightSpreadsAcrossCells(t *testing.T) {
+	w := newTestWorld()
+	world := tunnel(Size - 4)
+	w.add(CellID{}, world)
+	w.changed = map[CellID]bool{}
+	w.add(CellID{1, 0, 0}, world)
+
+	for x := int32(Size - 4); x < 2*Size; x++ {
+		want := int(Blocks[Lamp].Emission) - int(x-(Size-4))
+		if want < 0 {
+			want = 0
+		}
+		if got := int(w.light(Pos{x, 5, 5}, BlockChannel)); got != want {
+			t.Errorf("block light at x = %d is %d, want %d", x, got, want)
+		}
+	}
+	if !w.changed[CellID{}] {
+		t.Errorf("light entering the new cell didn't change the cell whose padding it fills")
+	}
+}
+
+func TestRemoveOverlappingLamp(t *testing.T) {
+	w := newTestWorld()
+	c := w.add(CellID{}, tunnel(5, 15))
+	if got := w.light(Pos{8, 5, 5}, BlockChannel); got != Blocks[Lamp].Emission-3 {
+		t.Fatalf("block light between the lamps is %d, want %d", got, Blocks[Lamp].Emission-3)
+	}
+
+	if !SetBlock(w, Pos{5, 5, 5}, Air) {
+		t.Fatalf("SetBlock didn't remove the lamp")
+	}
+
+	// The tunnel should now be lit exactly as if only the second lamp had ever been there.
+	fresh := newTestWorld().add(CellID{}, tunnel(15))
+	for x := int32(0); x < Size; x++ {
+		got, want := GetLight(c.Light[Lidx(x, 5, 5)], BlockChannel), GetLight(fresh.Light[Lidx(x, 5, 5)], BlockChannel)
+		if got != want {
+			t.Errorf("block light at x = %d is %d, want %d", x, got, want)
+		}
+	}
+}
+
+func TestLightIndependentOfLoadOrder(t *testing.T) {
+	// Hills crossing the cell boundary at y = 32, riddled with air pockets and a few lamps.
+	world := func(p Pos) byte {
+		x, y, z := p[0], p[1], p[2]
+		if y >= 30+(x*7+z*13)%11 {
+			return Air
+		}
+		if (x/3+y/3+z/3)%5 == 0 {
+			return Air
+		}
+		if (x*31+y*17+z*7)%97 == 0 {
+			return Lamp
+		}
+		return Crate
+	}
+	var ids []CellID
+	for x := int32(0); x < 2; x++ {
+		for y := int32(0); y < 2; y++ {
+			for z := int32(0); z < 2; z++ {
+				ids = append(ids, CellID{x, y, z})
+			}
+		}
+	}
+
+	forward, backward := newTestWorld(), newTestWorld()
+	for i := range ids {
+		forward.add(ids[i], world)
+		backward.add(ids[len(ids)-1-i], world)
+	}
+	for _, id := range ids {
+		if forward.cells[id].Light != backward.cells[id].Light {
+			t.Errorf("cell %v is lit differently depending on load order", id)
+		}
+	}
+}
@@ -0,0 +1,194 @@
+package voxel
+
+import (
+	"github.com/brandonnelson3/GoPlay/shaders"
+)
+
+// faceAxes lists, for faces perpendicular to each axis, the axes the texture's s and t coordinates run
+// along, and which way the triangles {0, 1, 2} and {2, 1, 3} face. t always runs up the y axis on side
+// faces so textures stay upright. The terrain vertex shaders have the same table.
+var faceAxes = [3]struct {
+	s, t   int
+	facing int32
+}{
+	{s: 2, t: 1, facing: 1},
+	{s: 0, t: 2, facing: 1},
+	{s: 0, t: 1, facing: -1},
+}
+
+// Triangle corners for each winding, see faceAxes. Corner i sits at s = i>>1, t = i&1, and is the quad's
+// i-th vertex. The rotated variants split the quad along the 0-3 diagonal instead of 1-2.
+var (
+	quadCorners               = [6]uint32{0, 1, 2, 2, 1, 3}
+	quadCornersFlipped        = [6]uint32{0, 2, 1, 1, 2, 3}
+	quadCornersRotated        = [6]uint32{0, 1, 3, 0, 3, 2}
+	quadCornersRotatedFlipped = [6]uint32{0, 3, 1, 0, 2, 3}
+)
+
+// vertexAO is the classic voxel ambient occlusion term for one face corner, from 0 (fully occluded) to 3
+// (open), given whether the two voxels along the face edges and the one diagonally across are solid.
+func vertexAO(side1, side2, corner bool) uint32 {
+	if side1 && side2 {
+		// The corner voxel can't make it any darker, and it may be hidden entirely.
+		return 0
+	}
+	ao := uint32(3)
+	for _, solid := range []bool{side1, side2, corner} {
+		if solid {
+			ao--
+		}
+	}
+	return ao
+}
+
+// Mesh is the part of a cell's faces drawn with one render mode. Each quad is four vertices in corner
+// order and six indices.
+type Mesh struct {
+	Verts   []shaders.TerrainShader_Vertex
+	Indices []uint32
+	// Centers holds twice the cell local centre of each quad in a translucent mesh, so it can be sorted.
+	Centers [][3]int32
+}
+
+// Snapshot is a copy of everything meshing a cell reads, so it can be meshed without holding whatever
+// guards the world.
+type Snapshot struct {
+	ID   CellID
+	Data [sizep2_3]byte
+	// Light is padded like Data.
+	Light [sizep2_3]byte
+}
+
+// Snap copies c, which w must return, and the light of the voxels bordering it.
+func Snap(w Cells, c *Cell) *Snapshot {
+	s := &Snapshot{ID: c.ID, Data: c.Data}
+	origin := c.ID.origin()
+	for x := int32(-1); x <= Size; x++ {
+		for y := int32(-1); y <= Size; y++ {
+			for z := int32(-1); z <= Size; z++ {
+				if x >= 0 && x < Size && y >= 0 && y < Size && z >= 0 && z < Size {
+					s.Light[Idx(x, y, z)] = c.Light[Lidx(x, y, z)]
+				} else {
+					s.Light[Idx(x, y, z)] = LightAt(w, origin.Add(Pos{x, y, z}))
+				}
+			}
+		}
+	}
+	return s
+}
+
+// faceShading computes the ambient occlusion and smooth light of each corner of the face of voxel pos
+// perpendicular to axis and facing dir, from the voxels in the layer the face looks out into. Each corner's
+// light is the average over the open voxels around it, packed like the voxel light nibbles.
+func (s *Snapshot) faceShading(pos [3]int32, axis int, dir int32) (ao [4]uint32, light [4]uint32) {
+	a := faceAxes[axis]
+	front := pos
+	front[axis] += dir
+	at := func(ds, dt int32) [3]int32 {
+		p := front
+		p[a.s] += ds
+		p[a.t] += dt
+		return p
+	}
+	solid := func(p [3]int32) bool {
+		return IsOpaque(s.Data[Idx(p[0], p[1], p[2])])
+	}
+
+	for corner := range ao {
+		ds := int32(corner>>1)*2 - 1
+		dt := int32(corner&1)*2 - 1
+		side1, side2, diagonal := at(ds, 0), at(0, dt), at(ds, dt)
+		ao[corner] = vertexAO(solid(side1), solid(side2), solid(diagonal))
+
+		samples := [][3]int32{front}
+		if !solid(side1) {
+			samples = append(samples, side1)
+		}
+		if !solid(side2) {
+			samples = append(samples, side2)
+		}
+		if !solid(diagonal) && (!solid(side1) || !solid(side2)) {
+			samples = append(samples, diagonal)
+		}
+		var sky, block uint32
+		for _, p := range samples {
+			v := s.Light[Idx(p[0], p[1], p[2])]
+			sky += uint32(GetLight(v, SkyChannel))
+			block += uint32(GetLight(v, BlockChannel))
+		}
+		n := uint32(len(samples))
+		light[corner] = (sky+n/2)/n<<uint32(SkyChannel) | (block+n/2)/n<<uint32(BlockChannel)
+	}
+	return ao, light
+}
+
+// appendQuad appends the two triangles of the face of voxel pos perpendicular to axis to m. The face lies on
+// the far side of the voxel when dir is 1 and the near side when it is -1, and faces outwards along dir.
+func (s *Snapshot) appendQuad(m *Mesh, pos [3]int32, axis int, dir int32, material byte) {
+	a := faceAxes[axis]
+	ao, light := s.faceShading(pos, axis, dir)
+
+	// Vertices are packed relative to the voxel the face is on the far side of.
+	normal := uint32(axis * 2)
+	if dir < 0 {
+		pos[axis]--
+		normal++
+	}
+
+	// Split the quad along whichever diagonal is brighter, otherwise the occlusion from a single corner
+	// smears across the whole quad.
+	rotate := ao[0]+ao[3] > ao[1]+ao[2]
+	var corners [6]uint32
+	switch {
+	case a.facing == dir && !rotate:
+		corners = quadCorners
+	case a.facing == dir && rotate:
+		corners = quadCornersRotated
+	case !rotate:
+		corners = quadCornersFlipped
+	default:
+		corners = quadCornersRotatedFlipped
+	}
+
+	base := uint32(len(m.Verts))
+	for corner := range ao {
+		m.Verts = append(m.Verts, shaders.PackTerrainVertex(pos[0], pos[1], pos[2], normal, ao[corner], uint32(material), light[corner]))
+	}
+	for _, corner := range corners {
+		m.Indices = append(m.Indices, base+corner)
+	}
+	if Blocks[material].Mode == RenderTranslucent {
+		center := [3]int32{pos[0] * 2, pos[1] * 2, pos[2] * 2}
+		center[axis] += 2
+		center[a.s]++
+		center[a.t]++
+		m.Centers = append(m.Centers, center)
+	}
+}
+
+// Polygonize meshes every visible face into the mesh of its block's render mode. Voxel x, y, z occupies
+// [x, x+1] on each axis. Only faces on the far side of each voxel are generated here, faces on the near
+// side at 0 are the neighbouring cell's far faces.
+func (s *Snapshot) Polygonize() [RenderModes]Mesh {
+	var meshes [RenderModes]Mesh
+	for x := int32(0); x < Size; x++ {
+		for y := int32(0); y < Size; y++ {
+			for z := int32(0); z < Size; z++ {
+				pos := [3]int32{x, y, z}
+				a := s.Data[Idx(x, y, z)]
+				for axis := 0; axis < 3; axis++ {
+					n := pos
+					n[axis]++
+					b := s.Data[Idx(n[0], n[1], n[2])]
+					if faceVisible(a, b) {
+						s.appendQuad(&meshes[Blocks[a].Mode], pos, axis, 1, a)
+					}
+					if faceVisible(b, a) {
+						s.appendQuad(&meshes[Blocks[b].Mode], n, axis, -1, b)
+					}
+				}
+			}
+		}
+	}
+	return meshes
+}
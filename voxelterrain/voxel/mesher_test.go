@@ -0,0 +1,162 @@
+package voxel
+
+import (
+	"testing"
+
+	"github.com/brandonnelson3/GoPlay/shaders"
+)
+
+func TestVertexAO(t *testing.T) {
+	for _, tc := range []struct {
+		side1, side2, corner bool
+		want                 uint32
+	}{
+		{false, false, false, 3},
+		{true, false, false, 2},
+		{false, true, false, 2},
+		{false, false, true, 2},
+		{true, false, true, 1},
+		{false, true, true, 1},
+		// Two sides close the corner off, whatever is diagonally across.
+		{true, true, false, 0},
+		{true, true, true, 0},
+	} {
+		if got := vertexAO(tc.side1, tc.side2, tc.corner); got != tc.want {
+			t.Errorf("vertexAO(%v, %v, %v) = %d, want %d", tc.side1, tc.side2, tc.corner, got, tc.want)
+		}
+	}
+}
+
+// vertexPosition returns the cell local position of vertex i of m, worked out the way the terrain vertex
+// shaders do.
+func vertexPosition(m Mesh, i uint32) [3]int32 {
+	x, y, z, normal, _, _, _ := m.Verts[i].Unpack()
+	p := [3]int32{x, y, z}
+	axis := int(normal / 2)
+	corner := i & 3
+	p[axis]++
+	p[faceAxes[axis].s] += int32(corner >> 1)
+	p[faceAxes[axis].t] += int32(corner & 1)
+	return p
+}
+
+// findQuad returns the corners and positions of the triangle vertices of the quad in m with the given
+// normal whose lower corner is at p.
+func findQuad(t *testing.T, m Mesh, normal uint32, p [3]int32) (corners [6]uint32, positions [6][3]int32) {
+	for q := 0; q+6 <= len(m.Indices); q += 6 {
+		found := true
+		for i, index := range m.Indices[q : q+6] {
+			_, _, _, n, _, _, _ := m.Verts[index].Unpack()
+			v := vertexPosition(m, index)
+			if n != normal || v[0] < p[0] || v[1] < p[1] || v[2] < p[2] || v[0] > p[0]+1 || v[1] > p[1]+1 || v[2] > p[2]+1 {
+				found = false
+				break
+			}
+			corners[i], positions[i] = index&3, v
+		}
+		if found {
+			return corners, positions
+		}
+	}
+	t.Fatalf("no quad with normal %d at %v", normal, p)
+	return
+}
+
+func TestQuadSplitIsolatesOccludedCorner(t *testing.T) {
+	for _, face := range []struct {
+		name   string
+		dir    int32
+		normal uint32
+		// quad is the lower corner of the face.
+		quad [3]int32
+	}{
+		{"top", 1, 2, [3]int32{5, 6, 5}},
+		{"bottom", -1, 3, [3]int32{5, 5, 5}},
+	} {
+		for corner := uint32(0); corner < 4; corner++ {
+			// A crate at 5, 5, 5, and another diagonally across one corner of the face.
+			s := &Snapshot{}
+			s.Data[Idx(5, 5, 5)] = Crate
+			ds, dt := int32(corner>>1)*2-1, int32(corner&1)*2-1
+			s.Data[Idx(5+ds, 5+face.dir, 5+dt)] = Crate
+
+			corners, positions := findQuad(t, s.Polygonize()[RenderOpaque], face.normal, face.quad)
+
+			// The occluded corner must be in only one of the two triangles, so its darkness doesn't spread
+			// along the diagonal.
+			triangles := 0
+			for tri := 0; tri < 2; tri++ {
+				for _, c := range corners[tri*3 : tri*3+3] {
+					if c == corner {
+						triangles++
+					}
+				}
+			}
+			if triangles != 1 {
+				t.Errorf("%s face occluded at corner %d: corner is in %d triangles, want 1 (corners %v)", face.name, corner, triangles, corners)
+			}
+
+			// Whichever way the quad is split, both triangles still face outwards.
+			for tri := 0; tri < 2; tri++ {
+				p0, p1, p2 := positions[tri*3], positions[tri*3+1], positions[tri*3+2]
+				e1 := [3]int32{p1[0] - p0[0], p1[1] - p0[1], p1[2] - p0[2]}
+				e2 := [3]int32{p2[0] - p0[0], p2[1] - p0[1], p2[2] - p0[2]}
+				if ny := e1[2]*e2[0] - e1[0]*e2[2]; ny*face.dir <= 0 {
+					t.Errorf("%s face occluded at corner %d: triangle %d faces the wrong way (positions %v)", face.name, corner, tri, positions)
+				}
+			}
+		}
+	}
+}
+
+func TestQuadVertices(t *testing.T) {
+	// A crate against the cell's far x boundary, whose far face is on the plane the vertices can't name.
+	s := &Snapshot{}
+	s.Data[Idx(Size-1, 5, 5)] = Crate
+	m := s.Polygonize()[RenderOpaque]
+	if len(m.Verts) != 6*4 || len(m.Indices) != 6*6 {
+		t.Fatalf("crate meshed to %d vertices and %d indices, want 24 and 36", len(m.Verts), len(m.Indices))
+	}
+	normals := [6][3]int32{{1, 0, 0}, {-1, 0, 0}, {0, 1, 0}, {0, -1, 0}, {0, 0, 1}, {0, 0, -1}}
+	for q := 0; q < 6; q++ {
+		_, _, _, normal, _, material, _ := m.Verts[q*4].Unpack()
+		if material != uint32(Crate) {
+			t.Errorf("quad %d has material %d, want %d", q, material, Crate)
+		}
+		n := normals[normal]
+		seen := map[[3]int32]bool{}
+		for i := uint32(q * 4); i < uint32(q*4+4); i++ {
+			p := vertexPosition(m, i)
+			seen[p] = true
+			for axis := 0; axis < 3; axis++ {
+				lo := [3]int32{Size - 1, 5, 5}[axis]
+				if p[axis] < lo || p[axis] > lo+1 || n[axis] != 0 && p[axis] != lo+(n[axis]+1)/2 {
+					t.Errorf("quad %d with normal %v has a vertex at %v, off its face of the crate", q, n, p)
+				}
+			}
+		}
+		if len(seen) != 4 {
+			t.Errorf("quad %d with normal %v has %d distinct corners, want 4", q, n, len(seen))
+		}
+		// Both triangles face along the normal.
+		for tri := q * 6; tri < q*6+6; tri += 3 {
+			p0, p1, p2 := vertexPosition(m, m.Indices[tri]), vertexPosition(m, m.Indices[tri+1]), vertexPosition(m, m.Indices[tri+2])
+			e1 := [3]int32{p1[0] - p0[0], p1[1] - p0[1], p1[2] - p0[2]}
+			e2 := [3]int32{p2[0] - p0[0], p2[1] - p0[1], p2[2] - p0[2]}
+			cross := [3]int32{e1[1]*e2[2] - e1[2]*e2[1], e1[2]*e2[0] - e1[0]*e2[2], e1[0]*e2[1] - e1[1]*e2[0]}
+			if cross[0]*n[0]+cross[1]*n[1]+cross[2]*n[2] <= 0 {
+				t.Errorf("quad %d with normal %v has a triangle facing %v", q, n, cross)
+			}
+		}
+	}
+}
+
+func TestEveryBlockFitsAVertex(t *testing.T) {
+	for id := range Blocks {
+		v := shaders.PackTerrainVertex(Size-1, 0, Size-1, shaders.NormalNegZ, 3, uint32(id), 0xFF)
+		x, y, z, normal, ao, material, light := v.Unpack()
+		if x != Size-1 || y != 0 || z != Size-1 || normal != shaders.NormalNegZ || ao != 3 || material != uint32(id) || light != 0xFF {
+			t.Errorf("block %d packed as %#x, which unpacks to %d %d %d %d %d %d %#x", id, v.Packed, x, y, z, normal, ao, material, light)
+		}
+	}
+}